@@ -9,6 +9,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"net"
@@ -16,6 +17,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
@@ -28,11 +30,15 @@ import (
 	"github.com/energizer-project/energizer/internal/cli"
 	"github.com/energizer-project/energizer/internal/config"
 	"github.com/energizer-project/energizer/internal/connector"
+	"github.com/energizer-project/energizer/internal/db"
 	"github.com/energizer-project/energizer/internal/events"
 	"github.com/energizer-project/energizer/internal/health"
 	"github.com/energizer-project/energizer/internal/network"
+	"github.com/energizer-project/energizer/internal/patcher"
+	"github.com/energizer-project/energizer/internal/replay"
 	"github.com/energizer-project/energizer/internal/scheduler"
 	"github.com/energizer-project/energizer/internal/server"
+	"github.com/energizer-project/energizer/internal/simulate"
 	"github.com/energizer-project/energizer/internal/telemetry"
 	"github.com/energizer-project/energizer/internal/util"
 )
@@ -54,6 +60,30 @@ const (
 )
 
 func main() {
+	// Sandboxed game servers are started through Energizer itself, which sets
+	// up their namespaces before executing the server binary
+	if len(os.Args) > 1 && os.Args[1] == server.SandboxInitArg {
+		server.RunSandboxInit(os.Args[2:])
+		return
+	}
+
+	// In simulation mode the game servers are Energizer itself, re-executed
+	// as fake servers
+	if len(os.Args) > 1 && os.Args[1] == simulate.ServerArg {
+		simulate.RunFakeServer(os.Args[2:])
+		return
+	}
+
+	// Offline replay of a manager-port capture (application_data.capture)
+	if len(os.Args) > 1 && os.Args[1] == server.ReplayCaptureArg {
+		server.RunReplayCapture(os.Args[2:])
+		return
+	}
+
+	simulateMode := flag.Bool("simulate", false, "run built-in fake game servers instead of HoN (no game files needed)")
+	scenarioPath := flag.String("scenario", "", "scenario file for --simulate (built-in scenario if empty)")
+	flag.Parse()
+
 	// Print banner
 	fmt.Printf(Banner, AppVersion)
 	fmt.Println()
@@ -89,8 +119,29 @@ func main() {
 		log.Warn().Err(err).Msg("failed to reconfigure logger, using defaults")
 	}
 
+	// Simulation mode: check the scenario now rather than in every fake server
+	if *simulateMode {
+		if *scenarioPath != "" {
+			if abs, err := filepath.Abs(*scenarioPath); err == nil {
+				*scenarioPath = abs
+			}
+		}
+		scenario, err := simulate.LoadScenario(*scenarioPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load simulation scenario")
+		}
+		server.EnableSimulation(*scenarioPath)
+		log.Warn().
+			Str("scenario", scenario.Name).
+			Int("steps", len(scenario.Steps)).
+			Msg("SIMULATION MODE: game servers are fakes, no HoN files are used")
+	}
+
 	// Validate configuration
 	validation := config.Validate(cfg)
+	if *simulateMode {
+		relaxForSimulation(validation)
+	}
 	for _, w := range validation.Warnings {
 		log.Warn().Str("field", w.Field).Msg(w.Message)
 	}
@@ -236,7 +287,11 @@ func main() {
 		log.Fatal().Err(err).Msg("failed to create server manager")
 	}
 
-	// PID-based cleanup of leftover game servers
+	// Re-adopt game servers still running from a previous run so their
+	// matches survive the restart, then PID-based cleanup of the rest
+	if cfg.GetHoNData().AdoptServers && !server.Simulating() {
+		mgr.AdoptRunningServers()
+	}
 	mgr.CleanupLeftoverServers()
 
 	// Initialize connectors
@@ -249,6 +304,7 @@ func main() {
 
 	// Initialize REST API
 	apiServer := api.NewServer(cfg, eventBus, mgr)
+	apiServer.SetUnknownPackets(tcpListener.UnknownPackets())
 
 	// Initialize health check manager
 	healthMgr := health.NewManager(cfg, eventBus, mgr, masterConn)
@@ -263,7 +319,66 @@ func main() {
 	}
 
 	// Initialize scheduler
-	sched := scheduler.NewScheduler(cfg, eventBus)
+	sched := scheduler.NewScheduler(cfg, eventBus, mgr)
+
+	// Initialize match history (non-fatal: the API reports it as unavailable)
+	matchDB, err := db.NewMatchDatabase("config/matches.db")
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to open match history database, match history disabled")
+	} else {
+		server.NewMatchRecorder(eventBus, matchDB)
+		apiServer.SetMatchHistory(matchDB)
+		defer matchDB.Close()
+	}
+
+	// Initialize player session history (non-fatal, like match history)
+	sessionDB, err := db.NewSessionDatabase("config/sessions.db")
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to open player session database, session history disabled")
+	} else {
+		server.NewSessionRecorder(eventBus, sessionDB)
+		apiServer.SetPlayerSessions(sessionDB)
+		defer sessionDB.Close()
+	}
+
+	// Initialize console command audit trail (non-fatal: commands are still logged)
+	auditDB, err := db.NewAuditDatabase("config/audit.db")
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to open audit database, console commands are only logged")
+	} else {
+		mgr.SetCommandAudit(auditDB)
+		apiServer.SetCommandAudit(auditDB)
+		defer auditDB.Close()
+	}
+
+	// Initialize CowMaster (Linux only): game servers are forked from a
+	// pre-loaded master process instead of exec'd
+	var cowMaster *server.CowMaster
+	if cfg.GetHoNData().UseCowMaster && !server.Simulating() {
+		cowMaster = server.NewCowMaster(cfg, eventBus, mgr.GetConnectionRegistry())
+		if cowMaster.IsSupported() {
+			mgr.SetCowMaster(cowMaster)
+		} else {
+			log.Warn().Msg("CowMaster is only supported on Linux, game servers will be exec'd")
+			cowMaster = nil
+		}
+	}
+
+	// Initialize autoscaler (idles when autoscale.enabled is false)
+	autoscaler := server.NewAutoscaler(cfg, mgr)
+	apiServer.SetAutoscaler(autoscaler)
+
+	// Initialize blue/green rollout (restores a rollout in progress before
+	// the servers start, so they come up on the right install)
+	rollout := server.NewRollout(cfg, eventBus, mgr)
+	apiServer.SetRollout(rollout)
+
+	// Initialize game patcher (reacts to patch_server events)
+	gamePatcher := patcher.NewPatcher(cfg, eventBus, mgr, masterConn)
+	apiServer.SetPatcher(gamePatcher)
+
+	// Initialize replay uploads (reacts to replay requests from the chat server)
+	replayUploader := replay.NewUploader(cfg, eventBus, masterConn, chatConn)
 
 	// Initialize CLI
 	cliHandler := cli.NewCLI(cfg, eventBus, mgr)
@@ -306,6 +421,16 @@ func main() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		// Give the CowMaster time to load and connect so the first servers
+		// are forked; servers started before then are exec'd
+		if cowMaster != nil {
+			if err := cowMaster.Start(ctx); err != nil {
+				log.Warn().Err(err).Msg("failed to start CowMaster, game servers will be exec'd")
+			} else if !cowMaster.WaitReady(ctx, 60*time.Second) {
+				log.Warn().Msg("CowMaster did not connect in time, game servers will be exec'd until it does")
+			}
+		}
+
 		log.Info().Msg("starting game servers")
 		if err := mgr.StartAll(ctx); err != nil {
 			log.Warn().Err(err).Msg("some game servers failed to start (non-fatal)")
@@ -365,7 +490,7 @@ func main() {
 		}()
 	}
 
-	// Task 8: Scheduler (replay cleanup, stats)
+	// Task 8: Scheduler (replay cleanup, stats, maintenance windows)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -373,7 +498,35 @@ func main() {
 		sched.Start(ctx)
 	}()
 
-	// Task 9: Interactive CLI
+	// Task 9: Autoscaler
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		autoscaler.Start(ctx)
+	}()
+
+	// Task 10: Blue/green rollout (idles unless a rollout is started)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rollout.Start(ctx)
+	}()
+
+	// Task 11: Game patcher (idles until a patch is found)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		gamePatcher.Start(ctx)
+	}()
+
+	// Task 12: Replay uploads (idles until a player requests a replay)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		replayUploader.Start(ctx)
+	}()
+
+	// Task 13: Interactive CLI
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -419,6 +572,13 @@ func main() {
 		log.Warn().Msg("shutdown timed out after 30 seconds, forcing exit")
 	}
 
+	// Stop the CowMaster; servers forked from it are handled by their instances
+	if cowMaster != nil {
+		if err := cowMaster.Stop(); err != nil {
+			log.Warn().Err(err).Msg("failed to stop CowMaster")
+		}
+	}
+
 	// Remove PID file on clean shutdown
 	mgr.RemovePIDFile()
 
@@ -433,6 +593,27 @@ func main() {
 	log.Info().Msg("Energizer stopped")
 }
 
+// simulationFields are the settings only real game servers need. Their
+// errors are downgraded to warnings in simulation mode.
+var simulationFields = map[string]bool{
+	"hon_data.svr_login":             true,
+	"hon_data.svr_password":          true,
+	"hon_data.hon_install_directory": true,
+}
+
+// relaxForSimulation moves errors about simulationFields to the warnings.
+func relaxForSimulation(result *config.ValidationResult) {
+	var errs []config.ValidationError
+	for _, e := range result.Errors {
+		if simulationFields[e.Field] {
+			result.Warnings = append(result.Warnings, e)
+		} else {
+			errs = append(errs, e)
+		}
+	}
+	result.Errors = errs
+}
+
 // cleanupLeftoverProcesses kills any hon_x64 and old energizer processes from a previous run.
 // This prevents port conflicts when Energizer is restarted.
 func cleanupLeftoverProcesses(cfg *config.Config) {
@@ -461,13 +642,15 @@ func cleanupLeftoverProcesses(cfg *config.Config) {
 			cleaned = true
 		}
 
-		// Kill leftover game servers
-		cmd = exec.Command("taskkill", "/F", "/IM", exeName)
-		if err := cmd.Run(); err == nil {
-			log.Info().Str("executable", exeName).Msg("cleaned up leftover game server processes")
-			cleaned = true
+		// Kill leftover game servers (unless they are to be re-adopted)
+		if !cfg.GetHoNData().AdoptServers {
+			cmd = exec.Command("taskkill", "/F", "/IM", exeName)
+			if err := cmd.Run(); err == nil {
+				log.Info().Str("executable", exeName).Msg("cleaned up leftover game server processes")
+				cleaned = true
+			}
 		}
-	} else {
+	} else if !cfg.GetHoNData().AdoptServers {
 		cmd := exec.Command("pkill", "-9", "-f", exeName)
 		if err := cmd.Run(); err == nil {
 			log.Info().Str("executable", exeName).Msg("cleaned up leftover game server processes")
@@ -25,6 +25,11 @@ async function request<T>(path: string, options?: RequestInit): Promise<T> {
   return res.json() as Promise<T>;
 }
 
+/** Absolute URL for streaming endpoints (EventSource). */
+export function streamUrl(path: string): string {
+  return `${API_BASE}${path}`;
+}
+
 export function fetcher<T>(path: string): () => Promise<T> {
   return () => request<T>(path);
 }
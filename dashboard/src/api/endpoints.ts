@@ -1,4 +1,4 @@
-import { api, fetcher } from './client';
+import { api, fetcher, streamUrl } from './client';
 import type {
   InstanceInfo,
   ServerInfo,
@@ -7,6 +7,18 @@ import type {
   LogEntry,
   HoNData,
   ApplicationData,
+  AutoscalerStatus,
+  Match,
+  PlayerSession,
+  AffinityPlan,
+  ConsoleLine,
+  CommandAudit,
+  ConfigReload,
+  RolloutStatus,
+  PatchStatus,
+  StateTransition,
+  UnknownPacket,
+  UnknownPacketCount,
 } from '@/types';
 
 // ---- SWR fetchers (for polling) ----
@@ -36,6 +48,73 @@ export const fetchTasksStatus = fetcher<Record<string, unknown>>(
   '/api/monitor/get_tasks_status'
 );
 
+export const fetchAffinityPlan = fetcher<AffinityPlan>('/api/monitor/get_affinity_plan');
+
+export const fetchAutoscalerStatus = fetcher<AutoscalerStatus>(
+  '/api/monitor/get_autoscaler_status'
+);
+
+export const fetchRolloutStatus = fetcher<RolloutStatus>('/api/monitor/get_rollout_status');
+
+export const fetchPatchStatus = fetcher<PatchStatus>('/api/monitor/get_patch_status');
+
+export function fetchMatches(page = 1, perPage = 25) {
+  return () =>
+    api.get<{ matches: Match[]; total: number; page: number; per_page: number }>(
+      `/api/monitor/matches?page=${page}&per_page=${perPage}`
+    );
+}
+
+export function fetchMatch(matchId: number) {
+  return () => api.get<Match>(`/api/monitor/matches/${matchId}`);
+}
+
+export function fetchPlayerSessions(query: { name?: string; accountId?: number }, limit = 20) {
+  const params = new URLSearchParams({ limit: String(limit) });
+  if (query.name) params.set('name', query.name);
+  if (query.accountId) params.set('account_id', String(query.accountId));
+  return () =>
+    api.get<{ sessions: PlayerSession[]; count: number }>(
+      `/api/monitor/player_sessions?${params.toString()}`
+    );
+}
+
+export function fetchConsole(port: number, lines = 200) {
+  return () =>
+    api.get<{ port: number; lines: ConsoleLine[]; count: number }>(
+      `/api/monitor/instances/${port}/console?lines=${lines}`
+    );
+}
+
+/** Server-sent events: "line" events carry a ConsoleLine. */
+export function consoleStreamUrl(port: number, lines = 200) {
+  return streamUrl(`/api/monitor/instances/${port}/console/stream?lines=${lines}`);
+}
+
+/** limit 0 returns the whole timeline. */
+export function fetchTimeline(port: number, limit = 0) {
+  return () =>
+    api.get<{ port: number; transitions: StateTransition[]; count: number }>(
+      `/api/monitor/instances/${port}/timeline?limit=${limit}`
+    );
+}
+
+export function fetchUnknownPackets(limit = 20) {
+  return () =>
+    api.get<{ counts: UnknownPacketCount[]; recent: UnknownPacket[] }>(
+      `/api/monitor/get_unknown_packets?limit=${limit}`
+    );
+}
+
+export function fetchCommandAudit(port?: number, limit = 100) {
+  const params = new URLSearchParams({ limit: String(limit) });
+  if (port) params.set('port', String(port));
+  return () =>
+    api.get<{ entries: CommandAudit[]; count: number }>(
+      `/api/configure/command_audit?${params.toString()}`
+    );
+}
+
 // ---- Actions ----
 
 export const serverActions = {
@@ -46,17 +125,34 @@ export const serverActions = {
   disable: (port: number) => api.post(`/api/control/disable_server/${port}`),
   message: (port: number, message: string) =>
     api.post(`/api/control/message_server/${port}`, { message }),
+  command: (port: number, command: string) =>
+    api.post(`/api/control/command_server/${port}`, { command }),
   startAll: () => api.post('/api/control/start_all'),
   stopAll: () => api.post('/api/control/stop_all'),
   restartAll: () => api.post('/api/control/restart_all'),
+  rollingRestart: (maxUnavailable = 1) =>
+    api.post('/api/control/rolling_restart', { max_unavailable: maxUnavailable }),
+  drain: (port: number, action: 'restart' | 'stop' = 'restart') =>
+    api.post(`/api/control/drain_server/${port}`, { action }),
+};
+
+// ---- Rollout ----
+
+export const rolloutActions = {
+  start: () => api.post<RolloutStatus>('/api/control/rollout/start'),
+  pause: () => api.post<RolloutStatus>('/api/control/rollout/pause'),
+  resume: () => api.post<RolloutStatus>('/api/control/rollout/resume'),
+  promote: () => api.post<RolloutStatus>('/api/control/rollout/promote'),
+  rollback: () => api.post<RolloutStatus>('/api/control/rollout/rollback'),
 };
 
 // ---- Configuration ----
 
 export const configActions = {
-  setHoNData: (data: Partial<HoNData>) => api.post('/api/configure/set_hon_data', data),
+  setHoNData: (data: Partial<HoNData>) =>
+    api.post<{ status: string; reload: ConfigReload }>('/api/configure/set_hon_data', data),
   setAppData: (data: Partial<ApplicationData>) =>
-    api.post('/api/configure/set_app_data', data),
+    api.post<{ status: string; reload: ConfigReload }>('/api/configure/set_app_data', data),
   addServers: (count: number) => api.post('/api/configure/add_servers', { count }),
   removeServers: (ports: number[]) => api.post('/api/configure/remove_servers', { ports }),
 };
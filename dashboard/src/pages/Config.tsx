@@ -77,6 +77,7 @@ const honSections: SectionDef[] = [
       { key: 'hon_install_directory', label: 'Install Dir', type: 'text' },
       { key: 'hon_home_directory', label: 'Home Dir', type: 'text' },
       { key: 'hon_executable_name', label: 'Executable', type: 'text' },
+      { key: 'hon_candidate_install_directory', label: 'Candidate Dir', type: 'text' },
     ],
   },
   {
@@ -166,13 +167,21 @@ export function Config() {
     setError(null);
     setSaved(false);
     try {
-      if (tab === 'hon') {
-        await configActions.setHoNData(honData);
-      } else {
-        await configActions.setAppData(appData);
-      }
+      const { reload } =
+        tab === 'hon'
+          ? await configActions.setHoNData(honData)
+          : await configActions.setAppData(appData);
       setSaved(true);
       toast.success('Configuration saved');
+      const restartNeeded = reload.pending.filter((c) => c.apply === 'manager_restart');
+      if (restartNeeded.length > 0) {
+        toast(
+          `Restart Energizer to apply: ${restartNeeded.map((c) => c.field.split('.').pop()).join(', ')}`
+        );
+      }
+      if (reload.servers_stale?.length) {
+        toast(`${reload.servers_stale.length} busy server(s) restart after their match`);
+      }
       setTimeout(() => setSaved(false), 3000);
     } catch (err) {
       const msg = err instanceof Error ? err.message : 'Failed to save';
@@ -71,7 +71,7 @@ export function Overview() {
     setRestartAllLoading(true);
     try {
       await serverActions.restartAll();
-      toast.success('Restart All — servers are restarting');
+      toast.success('Restart All — servers restart as their matches end');
       setTimeout(() => mutate(), 3000);
     } catch (e) {
       const msg = e instanceof Error ? e.message : 'Restart All failed';
@@ -1,4 +1,4 @@
-import { useState } from 'react';
+import { useEffect, useRef, useState } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import useSWR from 'swr';
 import { Header } from '@/components/layout/Header';
@@ -7,18 +7,19 @@ import { Button } from '@/components/ui/button';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
 import { Input } from '@/components/ui/input';
 import { Badge } from '@/components/ui/badge';
-import { fetchInstances, serverActions } from '@/api/endpoints';
+import { consoleStreamUrl, fetchInstances, serverActions } from '@/api/endpoints';
 import {
   Play, Square, RotateCcw, ArrowLeft, Send,
   Users, Gamepad2, Map, Hash, Cpu, AlertTriangle,
-  Activity, MessageSquare,
+  Activity, MessageSquare, Terminal,
 } from 'lucide-react';
 import { relativeTime, num } from '@/lib/utils';
 import { normalizePhase } from '@/components/ServerStatusBadge';
 import { toast } from '@/lib/toast';
-import type { InstanceInfo, PlayerInfo } from '@/types';
+import type { ConsoleLine, InstanceInfo, PlayerInfo } from '@/types';
 
 const POLL_INTERVAL = 3000;
+const CONSOLE_LINES = 200;
 
 export function ServerDetail() {
   const { port: portParam } = useParams<{ port: string }>();
@@ -28,6 +29,31 @@ export function ServerDetail() {
   const [message, setMessage] = useState('');
   const [sending, setSending] = useState(false);
   const [actionLoading, setActionLoading] = useState<string | null>(null);
+  const [consoleLines, setConsoleLines] = useState<ConsoleLine[]>([]);
+  const [consoleLive, setConsoleLive] = useState(false);
+  const [command, setCommand] = useState('');
+  const [sendingCommand, setSendingCommand] = useState(false);
+  const consoleRef = useRef<HTMLDivElement>(null);
+
+  // Live console: each (re)connect replays recent lines, then follows new output
+  useEffect(() => {
+    const source = new EventSource(consoleStreamUrl(port, CONSOLE_LINES));
+    source.onopen = () => {
+      setConsoleLines([]);
+      setConsoleLive(true);
+    };
+    source.onerror = () => setConsoleLive(false);
+    source.addEventListener('line', (e) => {
+      const line = JSON.parse((e as MessageEvent).data) as ConsoleLine;
+      setConsoleLines((prev) => [...prev.slice(-(CONSOLE_LINES - 1)), line]);
+    });
+    return () => source.close();
+  }, [port]);
+
+  useEffect(() => {
+    const el = consoleRef.current;
+    if (el) el.scrollTop = el.scrollHeight;
+  }, [consoleLines]);
 
   const instance: InstanceInfo | undefined = data?.instances?.find(
     (i: InstanceInfo) => i.port === port
@@ -62,6 +88,20 @@ export function ServerDetail() {
     }
   }
 
+  async function handleSendCommand() {
+    if (!command.trim()) return;
+    setSendingCommand(true);
+    try {
+      await serverActions.command(port, command);
+      setCommand('');
+    } catch (err) {
+      const msg = err instanceof Error ? err.message : String(err);
+      toast.error(`Command failed: ${msg}`);
+    } finally {
+      setSendingCommand(false);
+    }
+  }
+
   if (!instance) {
     return (
       <div className="flex flex-col">
@@ -189,10 +229,26 @@ export function ServerDetail() {
                 { label: 'Status Changed', value: relativeTime(state.status_changed_at) },
                 {
                   label: 'Next Restart',
-                  value: instance.next_restart
-                    ? new Date(instance.next_restart).toLocaleTimeString()
-                    : '-',
+                  value: instance.restart_due
+                    ? `When idle (${instance.restart_rule})`
+                    : instance.next_restart
+                      ? `${new Date(instance.next_restart).toLocaleTimeString()} (${instance.restart_rule})`
+                      : instance.restart_rule || '-',
                 },
+                ...(instance.cgroup
+                  ? [{
+                      label: 'Cgroup Memory',
+                      value: `${instance.cgroup.memory_current_mb.toFixed(0)} / ${
+                        instance.cgroup.memory_max_mb > 0 ? `${instance.cgroup.memory_max_mb.toFixed(0)} MB` : 'unlimited'
+                      }${instance.cgroup.oom_kills > 0 ? ` (${instance.cgroup.oom_kills} OOM kills)` : ''}`,
+                    }]
+                  : []),
+                ...(instance.memory
+                  ? [{
+                      label: instance.forked ? 'Memory (forked)' : 'Memory',
+                      value: `${instance.memory.pss_mb.toFixed(0)} MB PSS / ${instance.memory.uss_mb.toFixed(0)} MB USS`,
+                    }]
+                  : []),
               ].map(({ label, value, mono }) => (
                 <div key={label} className="flex items-center justify-between border-b border-border/30 py-2 last:border-0 sm:flex-col sm:items-start sm:border-0 sm:py-0">
                   <p className="text-[10px] uppercase tracking-wider text-muted-foreground">{label}</p>
@@ -259,6 +315,60 @@ export function ServerDetail() {
           </CardContent>
         </Card>
 
+        {/* Console */}
+        <Card className="animate-fade-in">
+          <CardHeader>
+            <CardTitle className="flex items-center gap-2">
+              <Terminal className="h-3.5 w-3.5 text-muted-foreground" />
+              Console
+              <span className="ml-auto flex items-center gap-1.5 text-[11px] font-normal text-muted-foreground">
+                <span className={`h-1.5 w-1.5 rounded-full ${consoleLive ? 'bg-success' : 'bg-muted-foreground/40'}`} />
+                {consoleLive ? 'Live' : 'Disconnected'}
+              </span>
+            </CardTitle>
+          </CardHeader>
+          <CardContent>
+            <div
+              ref={consoleRef}
+              className="h-72 overflow-y-auto rounded-md bg-muted/40 p-3 font-mono text-[11px] leading-relaxed"
+            >
+              {consoleLines.length === 0 ? (
+                <p className="text-muted-foreground">No console output captured</p>
+              ) : (
+                consoleLines.map((line, idx) => (
+                  <div
+                    key={idx}
+                    className={`whitespace-pre-wrap break-all ${line.stream === 'stderr' ? 'text-destructive' : ''}`}
+                  >
+                    <span className="mr-2 text-muted-foreground">
+                      {new Date(line.time).toLocaleTimeString()}
+                    </span>
+                    {line.text}
+                  </div>
+                ))
+              )}
+            </div>
+            <div className="mt-3 flex gap-2">
+              <Input
+                placeholder="Console command..."
+                value={command}
+                onChange={(e) => setCommand(e.target.value)}
+                onKeyDown={(e) => e.key === 'Enter' && handleSendCommand()}
+                disabled={!instance.running}
+                className="font-mono text-xs"
+              />
+              <Button
+                size="sm"
+                variant="outline"
+                onClick={handleSendCommand}
+                disabled={!instance.running || sendingCommand || !command.trim()}
+              >
+                <Send className="h-3 w-3" /> Run
+              </Button>
+            </div>
+          </CardContent>
+        </Card>
+
         {/* Send Message */}
         <Card className="animate-fade-in">
           <CardHeader>
@@ -35,7 +35,83 @@ export interface InstanceInfo {
   pid: number;
   uptime: string;
   state: GameStateSnapshot;
-  next_restart: string;
+  next_restart?: string;
+  restart_rule: string;
+  restart_due: boolean;
+  matches_played: number;
+  quarantined: boolean;
+  recent_crashes: number;
+  draining: boolean;
+  config_stale: boolean;
+  cgroup?: CgroupStats;
+  forked: boolean;
+  memory?: ProcessMemory;
+  install: 'current' | 'candidate';
+  version: string;
+}
+
+export interface ProcessMemory {
+  rss_mb: number;
+  pss_mb: number;
+  uss_mb: number;
+  shared_mb: number;
+  swap_mb: number;
+}
+
+export interface ConsoleLine {
+  time: string;
+  stream: 'stdout' | 'stderr';
+  text: string;
+}
+
+export interface StateTransition {
+  time: string;
+  field: 'status' | 'phase';
+  from: string;
+  to: string;
+  cause: string;
+  actor: string;
+  illegal?: boolean;
+  rejected?: boolean;
+}
+
+export interface UnknownPacket {
+  time: string;
+  port: number;
+  command: string;
+  length: number;
+  hex: string;
+}
+
+export interface UnknownPacketCount {
+  port: number;
+  command: string;
+  count: number;
+  last_seen: string;
+}
+
+export interface CommandAudit {
+  id: number;
+  time: string;
+  user: string;
+  source: string;
+  port: number;
+  command: string;
+  allowed: boolean;
+  error?: string;
+}
+
+export interface CgroupStats {
+  path: string;
+  memory_current_mb: number;
+  memory_peak_mb: number;
+  memory_max_mb: number;
+  swap_current_mb: number;
+  oom_kills: number;
+  cpu_usage_sec: number;
+  cpu_throttled_sec: number;
+  pids_current: number;
+  pids_max: number;
 }
 
 export interface ServerInfo {
@@ -63,6 +139,20 @@ export interface MemoryUsage {
   used_mb: number;
   available_mb: number;
   used_percent: number;
+  game_servers: GameServerMemory;
+}
+
+export interface GameServerMemory {
+  servers: { port: number; pid: number; forked: boolean; memory: ProcessMemory }[];
+  total_rss_mb: number;
+  total_pss_mb: number;
+  total_uss_mb: number;
+  cowmaster_pid?: number;
+  cowmaster?: ProcessMemory;
+  pool_children: number;
+  pool_pss_mb: number;
+  shared_with_cowmaster_mb: number;
+  saved_mb: number;
 }
 
 export interface LogEntry {
@@ -76,6 +166,8 @@ export interface HoNData {
   hon_install_directory: string;
   hon_home_directory: string;
   hon_artefacts_directory: string;
+  hon_candidate_install_directory: string;
+  svr_candidate_version: string;
   hon_executable_name: string;
   svr_login: string;
   svr_password: string;
@@ -97,6 +189,8 @@ export interface HoNData {
   svr_chatPort: number;
   man_enableProxy: boolean;
   man_use_cowmaster: boolean;
+  man_cowmaster_pool_size: number;
+  man_adopt_servers: boolean;
   svr_beta_mode: boolean;
   svr_noConsole: boolean;
   svr_override_affinity: boolean;
@@ -151,5 +245,263 @@ export interface ApplicationData {
     directory: string;
     max_size_mb: number;
     max_backups: number;
+    console_buffer_lines: number;
+  };
+  crash_policy: {
+    auto_restart: boolean;
+    initial_backoff_sec: number;
+    max_backoff_sec: number;
+    quarantine_crashes: number;
+    quarantine_window_sec: number;
+  };
+  autoscale: {
+    enabled: boolean;
+    warm_instances: number;
+    min_instances: number;
+    check_interval_sec: number;
+    scale_down_cooldown_sec: number;
+  };
+  patch: {
+    apply_on_detect: boolean;
+    ready_timeout_sec: number;
+  };
+  capture: {
+    enabled: boolean;
+    directory: string;
+    max_size_mb: number;
+    max_backups: number;
+  };
+  replay_upload: {
+    max_concurrent_uploads: number;
+  };
+  rollout: {
+    canary_percent: number;
+    canary_minutes: number;
+    check_interval_sec: number;
+    tolerance_percent: number;
+  };
+  idle_kick: {
+    enabled: boolean;
+    post_game_delay_sec: number;
+    lobby_afk_sec: number;
+    warning_sec: number;
+    warning_message: string;
+    kick_reason: string;
+    exempt_players: string[] | null;
   };
+  restart_policy: {
+    max_uptime_hours: number;
+    uptime_jitter_hours: number;
+    after_matches: number;
+    memory_growth_percent: number;
+    daily_time: string;
+  };
+  cpu_affinity: {
+    topology_aware: boolean;
+    reserved_cpus: number[] | null;
+  };
+  cgroups: {
+    enabled: boolean;
+    root: string;
+    slice: string;
+    memory_max_mb: number;
+    cpu_weight: number;
+    cpu_max_percent: number;
+    pids_max: number;
+  };
+  sandbox: {
+    run_as_user: string;
+    run_as_group: string;
+    isolate_home: boolean;
+    home_root: string;
+    namespaces: boolean;
+    private_dirs: string[] | null;
+  };
+  remote_commands: {
+    enabled: boolean;
+    allow: string[] | null;
+    deny: string[] | null;
+  };
+  maintenance: {
+    windows: MaintenanceWindow[] | null;
+    warning_minutes: number[] | null;
+    drain_lead_min: number;
+    warning_template: string;
+    start_template: string;
+  };
+}
+
+export interface MaintenanceWindow {
+  name: string;
+  start: string;
+  days?: string[];
+  duration_min: number;
+  action: 'restart' | 'stop' | 'patch';
+}
+
+export interface RolloutTrackStats {
+  servers: number;
+  server_minutes: number;
+  crashes: number;
+  long_frames: number;
+  crashes_per_hour: number;
+  long_frames_per_hour: number;
+}
+
+export interface RolloutEvent {
+  time: string;
+  action: 'start' | 'move' | 'canary_passed' | 'complete' | 'pause' | 'resume' | 'promote' | 'rollback';
+  port?: number;
+  message: string;
+}
+
+export interface RolloutStatus {
+  state: 'idle' | 'canary' | 'rolling' | 'paused' | 'complete';
+  pause_reason?: string;
+  current_version: string;
+  candidate_version: string;
+  candidate_install_directory: string;
+  started_at: string;
+  canary_target: number;
+  candidate_ports: number[];
+  current: RolloutTrackStats;
+  candidate: RolloutTrackStats;
+  events: RolloutEvent[];
+}
+
+export interface PatchStatus {
+  state:
+    | 'idle'
+    | 'downloading'
+    | 'staged'
+    | 'draining'
+    | 'applying'
+    | 'verifying'
+    | 'applied'
+    | 'rolled_back'
+    | 'failed';
+  version?: string;
+  previous_version?: string;
+  reason?: string;
+  files: number;
+  bytes: number;
+  downloaded: number;
+  not_ready?: number[];
+  error?: string;
+  updated_at: string;
+}
+
+export interface FieldChange {
+  field: string;
+  old: unknown;
+  new: unknown;
+  apply: 'live' | 'idle_restart' | 'manager_restart';
+}
+
+export interface ConfigReload {
+  changes: FieldChange[];
+  pending: FieldChange[];
+  servers_added: number[] | null;
+  servers_removing: number[] | null;
+  servers_restarting: number[] | null;
+  servers_stale: number[] | null;
+}
+
+export interface ScaleDecision {
+  time: string;
+  action: 'wake' | 'start' | 'add' | 'sleep';
+  port?: number;
+  reason: string;
+  active: number;
+  ready: number;
+  occupied: number;
+}
+
+export interface AutoscalerStatus {
+  enabled: boolean;
+  warm_target: number;
+  min_instances: number;
+  max_instances: number;
+  desired_active: number;
+  active: number;
+  ready: number;
+  starting: number;
+  occupied: number;
+  last_evaluated: string;
+  last_scale_up: string;
+  last_scale_down: string;
+  decisions: ScaleDecision[];
+}
+
+export interface MatchPhase {
+  phase: GamePhase;
+  entered_at: string;
+  duration_ms: number;
+}
+
+export interface MatchPlayer {
+  player_id: number;
+  name: string;
+  joined_at: string;
+  left_at?: string;
+}
+
+export interface Match {
+  match_id: number;
+  port: number;
+  map: string;
+  mode: string;
+  status: 'in_progress' | 'completed' | 'aborted';
+  started_at: string;
+  ended_at?: string;
+  duration_sec: number;
+  lag_events: number;
+  lag_total_ms: number;
+  lag_max_ms: number;
+  phases: MatchPhase[];
+  players: MatchPlayer[];
+}
+
+export interface PlayerSession {
+  id: number;
+  player_name: string;
+  account_id: number;
+  port: number;
+  match_id: number;
+  joined_at: string;
+  left_at?: string;
+  samples: number;
+  ping_min: number;
+  ping_avg: number;
+  ping_p95: number;
+  ping_max: number;
+  spikes: number;
+}
+
+export interface LogicalCPU {
+  id: number;
+  core: number;
+  package: number;
+  node: number;
+  thread: number;
+}
+
+export interface AffinityPlan {
+  source: 'sysfs' | 'flat';
+  servers_per_core: number;
+  numa_nodes: number;
+  physical_cores: number;
+  logical_cpus: number;
+  reserved_cpus: number[];
+  slot_order: number[];
+  capacity: number;
+  oversubscribed: boolean;
+  assignments: {
+    id: number;
+    port: number;
+    cpus: number[] | null;
+    node: number;
+    sibling: boolean;
+  }[];
+  topology: LogicalCPU[];
 }
@@ -23,6 +23,7 @@ const (
 	PermMonitor   = "monitor"   // View server status, stats
 	PermControl   = "control"   // Start/stop servers
 	PermConfigure = "configure" // Modify configuration, manage users
+	PermCommand   = "command"   // Send raw console commands to game servers
 )
 
 // AuthMiddleware handles Discord OAuth2 token verification and RBAC.
@@ -2,11 +2,13 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
 	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/db"
 	"github.com/energizer-project/energizer/internal/events"
 )
 
@@ -33,7 +35,10 @@ func (s *Server) handleSetHoNData(c *gin.Context) {
 		return
 	}
 
-	// Emit config changed event
+	// Apply the change now so the response can report what is pending;
+	// the event reaches the other subsystems (the manager skips events from
+	// the API, so the change is not reconciled twice)
+	report := s.manager.ReconcileConfig()
 	s.eventBus.Emit(c.Request.Context(), events.Event{
 		Type:   events.EventConfigChanged,
 		Source: "api",
@@ -43,11 +48,12 @@ func (s *Server) handleSetHoNData(c *gin.Context) {
 	})
 
 	username, _ := c.Get("discord_username")
-	log.Info().Interface("user", username).Msg("API: HoN data updated")
+	log.Info().Interface("user", username).Int("changes", len(report.Changes)).Msg("API: HoN data updated")
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "updated",
 		"data":   s.cfg.GetHoNData(),
+		"reload": report,
 	})
 }
 
@@ -66,6 +72,7 @@ func (s *Server) handleSetAppData(c *gin.Context) {
 		return
 	}
 
+	report := s.manager.ReconcileConfig()
 	s.eventBus.Emit(c.Request.Context(), events.Event{
 		Type:   events.EventConfigChanged,
 		Source: "api",
@@ -76,6 +83,7 @@ func (s *Server) handleSetAppData(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "updated",
+		"reload": report,
 	})
 }
 
@@ -123,6 +131,48 @@ func (s *Server) handleRemoveServers(c *gin.Context) {
 	})
 }
 
+// handleGetCommandAudit returns the console command audit trail, newest first.
+// Query parameters: port, user, limit (max 500).
+func (s *Server) handleGetCommandAudit(c *gin.Context) {
+	if s.audit == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "command audit not available"})
+		return
+	}
+
+	filter := db.AuditFilter{User: c.Query("user")}
+	if portStr := c.Query("port"); portStr != "" {
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid port number"})
+			return
+		}
+		filter.Port = uint16(port)
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	filter.Limit = limit
+
+	entries, err := s.audit.ListCommands(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []db.CommandAudit{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
 // handleGetUsers returns all registered users.
 func (s *Server) handleGetUsers(c *gin.Context) {
 	users, err := s.rolesDB.GetAllUsers()
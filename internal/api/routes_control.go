@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/energizer-project/energizer/internal/events"
+	"github.com/energizer-project/energizer/internal/server"
 )
 
 // handleStartServer starts a game server on the specified port.
@@ -30,7 +32,7 @@ func (s *Server) handleStartServer(c *gin.Context) {
 	}
 
 	// Use background context — the game server must outlive the HTTP request.
-	if err := inst.Start(context.Background()); err != nil {
+	if err := inst.Start(causeContext(c, "start_server")); err != nil {
 		log.Error().Err(err).Uint16("port", uint16(port)).Msg("API: failed to start server")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -66,7 +68,7 @@ func (s *Server) handleStopServer(c *gin.Context) {
 		return
 	}
 
-	if err := inst.Stop(); err != nil {
+	if err := inst.StopContext(causeContext(c, "stop_server")); err != nil {
 		log.Error().Err(err).Uint16("port", uint16(port)).Msg("API: failed to stop server")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -122,7 +124,7 @@ func (s *Server) handleDisableServer(c *gin.Context) {
 
 	// Also stop if currently running
 	if inst.IsRunning() {
-		inst.Stop()
+		inst.StopContext(causeContext(c, "disable_server"))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -145,8 +147,9 @@ func (s *Server) handleRestartServer(c *gin.Context) {
 	}
 
 	// Use background context — the restart (stop + start) must outlive the HTTP request.
+	ctx := causeContext(c, "restart_server")
 	go func() {
-		if err := inst.Restart(context.Background()); err != nil {
+		if err := inst.Restart(ctx); err != nil {
 			log.Error().Err(err).Uint16("port", uint16(port)).Msg("API: restart failed")
 		}
 	}()
@@ -188,6 +191,52 @@ func (s *Server) handleMessageServer(c *gin.Context) {
 	})
 }
 
+// handleCommandServer sends a raw console command to a server. Requires the
+// command permission in addition to control; the command policy in
+// remote_commands applies and every attempt is audited.
+func (s *Server) handleCommandServer(c *gin.Context) {
+	port, err := parsePort(c)
+	if err != nil {
+		return
+	}
+
+	var body struct {
+		Command string `json:"command" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "command is required"})
+		return
+	}
+
+	if _, ok := s.manager.GetInstance(uint16(port)); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "server not found", "port": port})
+		return
+	}
+
+	username, _ := c.Get("discord_username")
+	userID, _ := c.Get("discord_user_id")
+	err = s.manager.SendCommand(server.CommandRequest{
+		Port:    uint16(port),
+		Command: body.Command,
+		User:    fmt.Sprintf("%v (%v)", username, userID),
+		Source:  "api",
+	})
+	if err != nil {
+		status := http.StatusConflict
+		if _, rejected := err.(*server.CommandRejectedError); rejected {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "port": port})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "command_sent",
+		"port":    port,
+		"command": body.Command,
+	})
+}
+
 // handleStartAll starts all configured game server instances (one-click).
 func (s *Server) handleStartAll(c *gin.Context) {
 	// Use background context — servers must outlive the HTTP request.
@@ -212,21 +261,156 @@ func (s *Server) handleStopAll(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "stopped"})
 }
 
-// handleRestartAll stops all game servers then starts them again (one-click).
+// handleRestartAll restarts all game servers (one-click). Running servers are
+// drained and restarted together, so active matches finish first; stopped
+// servers are started once they are done. The restart runs in the background.
 func (s *Server) handleRestartAll(c *gin.Context) {
-	s.manager.StopAll()
-	// Use background context — servers must outlive the HTTP request.
-	if err := s.manager.StartAll(context.Background()); err != nil {
-		log.Warn().Err(err).Msg("API: restart all had failures")
-		c.JSON(http.StatusOK, gin.H{
-			"status": "restarted_with_errors",
-			"error":  err.Error(),
-		})
+	if s.manager.IsRollingRestart() {
+		c.JSON(http.StatusConflict, gin.H{"error": "rolling restart already in progress"})
 		return
 	}
+
+	maxUnavailable := s.manager.GetTotalServers()
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+
+	// Use background context — servers must outlive the HTTP request.
+	go func() {
+		if err := s.manager.RollingRestart(context.Background(), maxUnavailable); err != nil {
+			log.Error().Err(err).Msg("API: restart all failed")
+		}
+		if err := s.manager.StartAll(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("API: restart all had failures")
+		}
+	}()
+
 	username, _ := c.Get("discord_username")
 	log.Info().Interface("user", username).Msg("API: restart all servers")
-	c.JSON(http.StatusOK, gin.H{"status": "restarted"})
+	c.JSON(http.StatusOK, gin.H{"status": "restart_started"})
+}
+
+// handleRollingRestart restarts all running servers a few at a time, letting
+// active matches finish first. The restart runs in the background.
+func (s *Server) handleRollingRestart(c *gin.Context) {
+	var body struct {
+		MaxUnavailable int `json:"max_unavailable"`
+	}
+	// Body is optional; default to one server at a time
+	_ = c.ShouldBindJSON(&body)
+	if body.MaxUnavailable == 0 {
+		body.MaxUnavailable = 1
+	}
+	if body.MaxUnavailable < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_unavailable must be at least 1"})
+		return
+	}
+
+	if s.manager.IsRollingRestart() {
+		c.JSON(http.StatusConflict, gin.H{"error": "rolling restart already in progress"})
+		return
+	}
+
+	// Use background context — the rolling restart must outlive the HTTP request.
+	go func() {
+		if err := s.manager.RollingRestart(context.Background(), body.MaxUnavailable); err != nil {
+			log.Error().Err(err).Msg("API: rolling restart failed")
+		}
+	}()
+
+	username, _ := c.Get("discord_username")
+	log.Info().
+		Int("max_unavailable", body.MaxUnavailable).
+		Interface("user", username).
+		Msg("API: rolling restart started")
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":          "rolling_restart_started",
+		"max_unavailable": body.MaxUnavailable,
+	})
+}
+
+// handleDrainServer lets the current match finish, then restarts or stops the server.
+func (s *Server) handleDrainServer(c *gin.Context) {
+	port, err := parsePort(c)
+	if err != nil {
+		return
+	}
+
+	var body struct {
+		Action string `json:"action"` // "restart" (default) or "stop"
+	}
+	_ = c.ShouldBindJSON(&body)
+
+	action := server.DrainRestart
+	switch body.Action {
+	case "", "restart":
+	case "stop":
+		action = server.DrainStop
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be 'restart' or 'stop'"})
+		return
+	}
+
+	inst, ok := s.manager.GetInstance(uint16(port))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "server not found", "port": port})
+		return
+	}
+
+	inst.Drain(action)
+
+	username, _ := c.Get("discord_username")
+	log.Info().
+		Uint16("port", uint16(port)).
+		Str("action", action.String()).
+		Interface("user", username).
+		Msg("API: server draining")
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "draining",
+		"port":   port,
+		"action": action.String(),
+	})
+}
+
+// handleRolloutAction returns a handler that starts, pauses, resumes,
+// promotes, or rolls back the blue/green rollout and responds with its status.
+// Promote rewrites hon_install_directory, so its route also requires
+// PermConfigure.
+func (s *Server) handleRolloutAction(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.rollout == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "rollout not available"})
+			return
+		}
+
+		var err error
+		switch action {
+		case "start":
+			err = s.rollout.Begin()
+		case "pause":
+			err = s.rollout.Pause("paused through the API")
+		case "resume":
+			err = s.rollout.Resume()
+		case "promote":
+			err = s.rollout.Promote()
+		case "rollback":
+			err = s.rollout.Rollback()
+		}
+		if err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		username, _ := c.Get("discord_username")
+		log.Info().
+			Str("action", action).
+			Interface("user", username).
+			Msg("API: rollout action")
+
+		c.JSON(http.StatusOK, s.rollout.Status())
+	}
 }
 
 // parsePort extracts and validates the port parameter from the URL.
@@ -239,3 +423,10 @@ func parsePort(c *gin.Context) (uint64, error) {
 	}
 	return port, nil
 }
+
+// causeContext returns a background context that attributes the state
+// transitions of an API action to the calling user.
+func causeContext(c *gin.Context, action string) context.Context {
+	username, _ := c.Get("discord_username")
+	return server.WithCause(context.Background(), server.APICause(action, fmt.Sprint(username)))
+}
@@ -3,14 +3,17 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/energizer-project/energizer/internal/db"
 	"github.com/energizer-project/energizer/internal/util"
 )
 
@@ -32,7 +35,6 @@ func (s *Server) handleGetTotalServers(c *gin.Context) {
 	if perCore < 1 {
 		perCore = 1
 	}
-	maxInstances := sysInfo.CPUCores * perCore
 
 	c.JSON(http.StatusOK, gin.H{
 		"total":            s.manager.GetTotalServers(),
@@ -40,10 +42,65 @@ func (s *Server) handleGetTotalServers(c *gin.Context) {
 		"occupied":         s.manager.GetOccupiedCount(),
 		"cpu_cores":        sysInfo.CPUCores,
 		"servers_per_core": perCore,
-		"max_instances":    maxInstances,
+		"max_instances":    s.manager.MaxInstances(),
 	})
 }
 
+// handleGetAutoscalerStatus returns the autoscaler's targets and decision log.
+func (s *Server) handleGetAutoscalerStatus(c *gin.Context) {
+	if s.autoscaler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "autoscaler not available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.autoscaler.Status())
+}
+
+// handleGetRolloutStatus returns the rollout state, per-install health, and event log.
+func (s *Server) handleGetRolloutStatus(c *gin.Context) {
+	if s.rollout == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "rollout not available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.rollout.Status())
+}
+
+// handleGetPatchStatus returns the state of the latest game patch.
+func (s *Server) handleGetPatchStatus(c *gin.Context) {
+	if s.patcher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "patcher not available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.patcher.Status())
+}
+
+// handleGetUnknownPackets returns how often each game server sent a packet
+// without a codec, and hex dumps of the most recent ones.
+// Query parameters: limit (default 20).
+func (s *Server) handleGetUnknownPackets(c *gin.Context) {
+	if s.unknown == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "game server listener not available"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"counts": s.unknown.Counts(),
+		"recent": s.unknown.Recent(limit),
+	})
+}
+
+// handleGetAffinityPlan returns the CPU topology and each server's CPU placement.
+func (s *Server) handleGetAffinityPlan(c *gin.Context) {
+	c.JSON(http.StatusOK, s.manager.AffinityPlan())
+}
+
 // handleGetCPUUsage returns current system CPU usage.
 func (s *Server) handleGetCPUUsage(c *gin.Context) {
 	usage, err := util.GetCPUUsage()
@@ -57,7 +114,8 @@ func (s *Server) handleGetCPUUsage(c *gin.Context) {
 	})
 }
 
-// handleGetMemoryUsage returns current system memory usage.
+// handleGetMemoryUsage returns current system memory usage and the game
+// servers' PSS/USS, including what they share with the CowMaster.
 func (s *Server) handleGetMemoryUsage(c *gin.Context) {
 	mem, err := util.GetMemoryUsage()
 	if err != nil {
@@ -70,6 +128,7 @@ func (s *Server) handleGetMemoryUsage(c *gin.Context) {
 		"used_mb":      mem.Used,
 		"available_mb": mem.Available,
 		"used_percent": mem.UsedPercent,
+		"game_servers": s.manager.MemoryReport(),
 	})
 }
 
@@ -130,6 +189,129 @@ func (s *Server) handleGetLogEntries(c *gin.Context) {
 	})
 }
 
+// handleListMatches returns a page of the match history, newest first.
+// Query parameters: page (1-based), per_page (max 100), port, status.
+func (s *Server) handleListMatches(c *gin.Context) {
+	if s.matches == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "match history not available"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", "25"))
+	if err != nil || perPage < 1 {
+		perPage = 25
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	filter := db.MatchFilter{
+		Status: c.Query("status"),
+		Limit:  perPage,
+		Offset: (page - 1) * perPage,
+	}
+	if portStr := c.Query("port"); portStr != "" {
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid port number"})
+			return
+		}
+		filter.Port = uint16(port)
+	}
+
+	matches, total, err := s.matches.ListMatches(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if matches == nil {
+		matches = []db.Match{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"matches":  matches,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+// handleGetMatch returns a single match from the match history.
+func (s *Server) handleGetMatch(c *gin.Context) {
+	if s.matches == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "match history not available"})
+		return
+	}
+
+	matchID, err := strconv.ParseUint(c.Param("match_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match ID"})
+		return
+	}
+
+	match, err := s.matches.GetMatch(uint32(matchID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if match == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":    "match not found",
+			"match_id": matchID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, match)
+}
+
+// handleGetPlayerSessions returns a player's recent sessions with their ping
+// statistics. Query parameters: name or account_id, and limit (max 200).
+// Statistics of sessions still open are at most a minute old.
+func (s *Server) handleGetPlayerSessions(c *gin.Context) {
+	if s.sessions == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "player sessions not available"})
+		return
+	}
+
+	filter := db.SessionFilter{PlayerName: strings.TrimSpace(c.Query("name"))}
+	if filter.PlayerName == "" {
+		accountID, err := strconv.ParseUint(c.Query("account_id"), 10, 32)
+		if err != nil || accountID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name or account_id is required"})
+			return
+		}
+		filter.AccountID = uint32(accountID)
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	filter.Limit = limit
+
+	sessions, err := s.sessions.FindSessions(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if sessions == nil {
+		sessions = []db.PlayerSession{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
 // handleGetTasksStatus returns the status of background tasks.
 func (s *Server) handleGetTasksStatus(c *gin.Context) {
 	// Return basic task status (health checks, scheduler, etc.)
@@ -244,3 +426,114 @@ func stringFromMap(m map[string]interface{}, key string) string {
 	}
 	return ""
 }
+
+// handleGetConsole returns the most recent console lines of a game server.
+// Query parameters: lines (default 200).
+func (s *Server) handleGetConsole(c *gin.Context) {
+	port, err := parsePort(c)
+	if err != nil {
+		return
+	}
+
+	inst, ok := s.manager.GetInstance(uint16(port))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "server not found", "port": port})
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("lines", "200"))
+	if err != nil || count < 1 {
+		count = 200
+	}
+
+	lines := inst.Console().Lines(count)
+	c.JSON(http.StatusOK, gin.H{
+		"port":  port,
+		"lines": lines,
+		"count": len(lines),
+	})
+}
+
+// handleGetTimeline returns the recorded status and phase transitions of a
+// game server, oldest first. Query parameters: limit (default all).
+func (s *Server) handleGetTimeline(c *gin.Context) {
+	port, err := parsePort(c)
+	if err != nil {
+		return
+	}
+
+	inst, ok := s.manager.GetInstance(uint16(port))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "server not found", "port": port})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		limit = 0
+	}
+
+	transitions := inst.State().Timeline(limit)
+	c.JSON(http.StatusOK, gin.H{
+		"port":        port,
+		"transitions": transitions,
+		"count":       len(transitions),
+	})
+}
+
+// handleStreamConsole streams a game server's console as server-sent events.
+// The most recent lines (query parameter lines, default 200) are sent first,
+// then each new line as it is written. A "ping" event is sent every 15
+// seconds so proxies keep the connection open.
+func (s *Server) handleStreamConsole(c *gin.Context) {
+	port, err := parsePort(c)
+	if err != nil {
+		return
+	}
+
+	inst, ok := s.manager.GetInstance(uint16(port))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "server not found", "port": port})
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("lines", "200"))
+	if err != nil || count < 1 {
+		count = 200
+	}
+
+	// The stream outlives the server's write timeout
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	backlog, lines, unsubscribe := inst.Console().Subscribe(count)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	for _, line := range backlog {
+		c.SSEvent("line", line)
+	}
+	c.Writer.Flush()
+
+	ping := time.NewTicker(15 * time.Second)
+	defer ping.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case line, ok := <-lines:
+			if !ok {
+				return false
+			}
+			c.SSEvent("line", line)
+			return true
+		case <-ping.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
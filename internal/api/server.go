@@ -19,6 +19,8 @@ import (
 	"github.com/energizer-project/energizer/internal/db"
 	"github.com/energizer-project/energizer/internal/events"
 	intnet "github.com/energizer-project/energizer/internal/network"
+	"github.com/energizer-project/energizer/internal/patcher"
+	"github.com/energizer-project/energizer/internal/protocol"
 	"github.com/energizer-project/energizer/internal/server"
 )
 
@@ -33,6 +35,14 @@ type Server struct {
 	discord  *connector.DiscordConnector
 	rolesDB  *db.RolesDatabase
 
+	autoscaler *server.Autoscaler
+	rollout    *server.Rollout
+	patcher    *patcher.Patcher
+	matches    *db.MatchDatabase
+	sessions   *db.SessionDatabase
+	audit      *db.AuditDatabase
+	unknown    *protocol.UnknownPackets
+
 	// HTTP server
 	httpServer *http.Server
 	router     *gin.Engine
@@ -62,6 +72,42 @@ func (s *Server) SetDependencies(discord *connector.DiscordConnector, rolesDB *d
 	s.rolesDB = rolesDB
 }
 
+// SetAutoscaler exposes the autoscaler's status through the monitor API.
+func (s *Server) SetAutoscaler(autoscaler *server.Autoscaler) {
+	s.autoscaler = autoscaler
+}
+
+// SetRollout exposes the blue/green rollout through the monitor and control APIs.
+func (s *Server) SetRollout(rollout *server.Rollout) {
+	s.rollout = rollout
+}
+
+// SetPatcher exposes the game patch status through the monitor API.
+func (s *Server) SetPatcher(p *patcher.Patcher) {
+	s.patcher = p
+}
+
+// SetMatchHistory exposes the match history through the monitor API.
+func (s *Server) SetMatchHistory(matches *db.MatchDatabase) {
+	s.matches = matches
+}
+
+// SetPlayerSessions exposes player session lookups through the monitor API.
+func (s *Server) SetPlayerSessions(sessions *db.SessionDatabase) {
+	s.sessions = sessions
+}
+
+// SetCommandAudit exposes the console command audit trail through the configure API.
+func (s *Server) SetCommandAudit(audit *db.AuditDatabase) {
+	s.audit = audit
+}
+
+// SetUnknownPackets exposes the game server packets received without a codec
+// through the monitor API.
+func (s *Server) SetUnknownPackets(unknown *protocol.UnknownPackets) {
+	s.unknown = unknown
+}
+
 // Start initializes and starts the API server.
 func (s *Server) Start(ctx context.Context) error {
 	// Initialize dependencies if not set
@@ -190,6 +236,17 @@ func (s *Server) buildRouter() *gin.Engine {
 		monitor.GET("/get_replay/:match_id", s.handleGetReplay)
 		monitor.GET("/get_energizer_log_entries", s.handleGetLogEntries)
 		monitor.GET("/get_tasks_status", s.handleGetTasksStatus)
+		monitor.GET("/get_autoscaler_status", s.handleGetAutoscalerStatus)
+		monitor.GET("/get_rollout_status", s.handleGetRolloutStatus)
+		monitor.GET("/get_patch_status", s.handleGetPatchStatus)
+		monitor.GET("/get_unknown_packets", s.handleGetUnknownPackets)
+		monitor.GET("/get_affinity_plan", s.handleGetAffinityPlan)
+		monitor.GET("/matches", s.handleListMatches)
+		monitor.GET("/matches/:match_id", s.handleGetMatch)
+		monitor.GET("/player_sessions", s.handleGetPlayerSessions)
+		monitor.GET("/instances/:port/console", s.handleGetConsole)
+		monitor.GET("/instances/:port/console/stream", s.handleStreamConsole)
+		monitor.GET("/instances/:port/timeline", s.handleGetTimeline)
 	}
 
 	// Control-level endpoints
@@ -201,10 +258,18 @@ func (s *Server) buildRouter() *gin.Engine {
 		control.POST("/start_all", s.handleStartAll)
 		control.POST("/stop_all", s.handleStopAll)
 		control.POST("/restart_all", s.handleRestartAll)
+		control.POST("/rolling_restart", s.handleRollingRestart)
+		control.POST("/drain_server/:port", s.handleDrainServer)
+		control.POST("/rollout/start", s.handleRolloutAction("start"))
+		control.POST("/rollout/pause", s.handleRolloutAction("pause"))
+		control.POST("/rollout/resume", s.handleRolloutAction("resume"))
+		control.POST("/rollout/promote", auth.RequirePermission(PermConfigure), s.handleRolloutAction("promote"))
+		control.POST("/rollout/rollback", s.handleRolloutAction("rollback"))
 		control.POST("/enable_server/:port", s.handleEnableServer)
 		control.POST("/disable_server/:port", s.handleDisableServer)
 		control.POST("/restart_server/:port", s.handleRestartServer)
 		control.POST("/message_server/:port", s.handleMessageServer)
+		control.POST("/command_server/:port", auth.RequirePermission(PermCommand), s.handleCommandServer)
 	}
 
 	// Configure-level endpoints
@@ -216,6 +281,7 @@ func (s *Server) buildRouter() *gin.Engine {
 		configure.POST("/set_app_data", s.handleSetAppData)
 		configure.POST("/add_servers", s.handleAddServers)
 		configure.POST("/remove_servers", s.handleRemoveServers)
+		configure.GET("/command_audit", s.handleGetCommandAudit)
 
 		// User/Role management
 		configure.GET("/users", s.handleGetUsers)
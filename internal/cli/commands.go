@@ -95,8 +95,14 @@ func (c *CLI) execute(ctx context.Context, cmd string, args []string) error {
 		return c.cmdSleep(ctx, args)
 	case "message", "msg":
 		return c.cmdMessage(ctx, args)
+	case "command", "cmd":
+		return c.cmdCommand(args)
 	case "startup", "start":
 		return c.cmdStartup(ctx, args)
+	case "drain":
+		return c.cmdDrain(args)
+	case "rollingrestart":
+		return c.cmdRollingRestart(ctx, args)
 	case "addservers":
 		return c.cmdAddServers(ctx, args)
 	case "reconnect":
@@ -126,8 +132,11 @@ func (c *CLI) printHelp() {
 	fmt.Println("║  shutdown <port>    Stop a game server                      ║")
 	fmt.Println("║  startup <port>     Start a game server                     ║")
 	fmt.Println("║  wake <port>        Wake a sleeping server                  ║")
-	fmt.Println("║  sleep <port>       Put a server to sleep                   ║")
+	fmt.Println("║  sleep <port>       Put a server to sleep (after its match) ║")
 	fmt.Println("║  message <port> msg Send in-game message                    ║")
+	fmt.Println("║  command <port> cmd Send a raw console command              ║")
+	fmt.Println("║  drain <port> [stop] Finish match, then restart (or stop)   ║")
+	fmt.Println("║  rollingrestart [n] Restart all, n servers at a time        ║")
 	fmt.Println("║  addservers <n>     Add N new server instances              ║")
 	fmt.Println("║  reconnect          Reconnect to upstream services          ║")
 	fmt.Println("║  setconfig <k> <v>  Update a configuration value            ║")
@@ -179,10 +188,19 @@ func (c *CLI) printStatus(args []string) {
 			uptime = "-"
 			pid = "-"
 		}
+		if inst.State.Status == events.GameStatusSleeping {
+			status = "SLEEPING"
+		}
 
 		if !inst.Enabled {
 			status = "DISABLED"
 		}
+		if inst.Quarantined {
+			status = "QUARANTINED"
+		}
+		if inst.Draining {
+			status = "DRAINING"
+		}
 
 		tw.Append([]string{
 			fmt.Sprintf("%d", inst.Port),
@@ -213,7 +231,16 @@ func (c *CLI) printServerDetail(inst server.InstanceInfo) {
 	fmt.Printf("  Players:      %d\n", inst.State.PlayerCount)
 	fmt.Printf("  CPU Usage:    %.1f%%\n", inst.State.CPUUsage)
 	fmt.Printf("  Lag Events:   %d\n", inst.State.TotalLagEvents)
-	fmt.Printf("  Next Restart: %s\n", inst.NextRestart.Format(time.RFC3339))
+	switch {
+	case inst.RestartDue:
+		fmt.Printf("  Next Restart: when idle (%s)\n", inst.RestartRule)
+	case inst.NextRestart != nil:
+		fmt.Printf("  Next Restart: %s (%s)\n", inst.NextRestart.Format(time.RFC3339), inst.RestartRule)
+	case inst.RestartRule != "":
+		fmt.Printf("  Next Restart: %s\n", inst.RestartRule)
+	default:
+		fmt.Printf("  Next Restart: -\n")
+	}
 
 	if len(inst.State.Players) > 0 {
 		fmt.Println("  Players:")
@@ -292,6 +319,30 @@ func (c *CLI) cmdMessage(ctx context.Context, args []string) error {
 	return nil
 }
 
+func (c *CLI) cmdCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: command <port> <command>")
+	}
+
+	port, err := strconv.ParseUint(args[0], 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port: %s", args[0])
+	}
+
+	command := strings.Join(args[1:], " ")
+	err = c.manager.SendCommand(server.CommandRequest{
+		Port:    uint16(port),
+		Command: command,
+		User:    "cli",
+		Source:  "cli",
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Command sent to port %d: %s\n", port, command)
+	return nil
+}
+
 func (c *CLI) cmdStartup(ctx context.Context, args []string) error {
 	port, err := parsePortArg(args)
 	if err != nil {
@@ -310,6 +361,56 @@ func (c *CLI) cmdStartup(ctx context.Context, args []string) error {
 	return nil
 }
 
+func (c *CLI) cmdDrain(args []string) error {
+	port, err := parsePortArg(args)
+	if err != nil {
+		return err
+	}
+
+	action := server.DrainRestart
+	if len(args) > 1 {
+		switch strings.ToLower(args[1]) {
+		case "restart":
+		case "stop":
+			action = server.DrainStop
+		default:
+			return fmt.Errorf("usage: drain <port> [restart|stop]")
+		}
+	}
+
+	inst, ok := c.manager.GetInstance(uint16(port))
+	if !ok {
+		return fmt.Errorf("server not found on port %d", port)
+	}
+
+	inst.Drain(action)
+	fmt.Printf("Server on port %d draining (will %s when idle)\n", port, action)
+	return nil
+}
+
+func (c *CLI) cmdRollingRestart(ctx context.Context, args []string) error {
+	maxUnavailable := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid count: %s", args[0])
+		}
+		maxUnavailable = n
+	}
+
+	if c.manager.IsRollingRestart() {
+		return fmt.Errorf("rolling restart already in progress")
+	}
+
+	go func() {
+		if err := c.manager.RollingRestart(ctx, maxUnavailable); err != nil {
+			log.Error().Err(err).Msg("CLI: rolling restart failed")
+		}
+	}()
+	fmt.Printf("Rolling restart started (%d server(s) at a time)\n", maxUnavailable)
+	return nil
+}
+
 func (c *CLI) cmdAddServers(ctx context.Context, args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("usage: addservers <count>")
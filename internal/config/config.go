@@ -37,6 +37,11 @@ type HoNData struct {
 	HomeDirectory      string `json:"hon_home_directory"`
 	ArtefactsDirectory string `json:"hon_artefacts_directory"`
 
+	// Blue/green rollout: a second install that part of the fleet runs while
+	// it is being rolled out (see application_data.rollout)
+	CandidateInstallDirectory string `json:"hon_candidate_install_directory"`
+	CandidateVersion          string `json:"svr_candidate_version"`
+
 	// Executable
 	ExecutableName string `json:"hon_executable_name"`
 
@@ -69,11 +74,13 @@ type HoNData struct {
 	ChatPort        int    `json:"svr_chatPort"`
 
 	// Features
-	EnableProxy      bool `json:"man_enableProxy"`
-	UseCowMaster     bool `json:"man_use_cowmaster"`
-	BetaMode         bool `json:"svr_beta_mode"`
-	NoConsole        bool `json:"svr_noConsole"`
-	OverrideAffinity bool `json:"svr_override_affinity"`
+	EnableProxy       bool `json:"man_enableProxy"`
+	UseCowMaster      bool `json:"man_use_cowmaster"`
+	CowMasterPoolSize int  `json:"man_cowmaster_pool_size"` // Idle children the CowMaster keeps pre-forked
+	BetaMode          bool `json:"svr_beta_mode"`
+	NoConsole         bool `json:"svr_noConsole"`
+	OverrideAffinity  bool `json:"svr_override_affinity"`
+	AdoptServers      bool `json:"man_adopt_servers"` // Re-adopt running servers on restart instead of killing them
 
 	// Game settings
 	AllowBotMatches     bool   `json:"svr_allow_bot_matches"`
@@ -92,6 +99,19 @@ type ApplicationData struct {
 	MQTT            MQTTConfig           `json:"mqtt"`
 	Security        SecurityConfig       `json:"security"`
 	Logging         LoggingConfig        `json:"logging"`
+	CrashPolicy     CrashPolicyConfig    `json:"crash_policy"`
+	Autoscale       AutoscaleConfig      `json:"autoscale"`
+	IdleKick        IdleKickConfig       `json:"idle_kick"`
+	Maintenance     MaintenanceConfig    `json:"maintenance"`
+	RestartPolicy   RestartPolicyConfig  `json:"restart_policy"`
+	CPUAffinity     CPUAffinityConfig    `json:"cpu_affinity"`
+	Cgroups         CgroupConfig         `json:"cgroups"`
+	Sandbox         SandboxConfig        `json:"sandbox"`
+	RemoteCommands  RemoteCommandConfig  `json:"remote_commands"`
+	Rollout         RolloutConfig        `json:"rollout"`
+	Patch           PatchConfig          `json:"patch"`
+	Capture         CaptureConfig        `json:"capture"`
+	ReplayUpload    ReplayUploadConfig   `json:"replay_upload"`
 }
 
 // TimerConfig holds health check and task interval settings.
@@ -107,6 +127,160 @@ type TimerConfig struct {
 	StatsPollingInterval     int `json:"stats_polling_interval_sec"`
 	HeartbeatInterval        int `json:"heartbeat_interval_sec"`
 	TaskCleanupInterval      int `json:"task_cleanup_interval_sec"`
+	IdlePlayerCheckInterval  int `json:"idle_player_check_interval_sec"`
+}
+
+// CrashPolicyConfig controls how unexpected game server exits are handled.
+// Restarts back off exponentially from InitialBackoffSec up to MaxBackoffSec;
+// a server that crashes QuarantineCrashes times within QuarantineWindowSec is
+// disabled until an operator re-enables it.
+type CrashPolicyConfig struct {
+	AutoRestart         bool `json:"auto_restart"`
+	InitialBackoffSec   int  `json:"initial_backoff_sec"`
+	MaxBackoffSec       int  `json:"max_backoff_sec"`
+	QuarantineCrashes   int  `json:"quarantine_crashes"`
+	QuarantineWindowSec int  `json:"quarantine_window_sec"`
+}
+
+// RestartPolicyConfig controls periodic restarts of game servers. A server is
+// restarted as soon as any enabled rule fires, but only while it is idle.
+// A zero value disables a rule. The uptime rule restarts after
+// MaxUptimeHours plus a random 0..UptimeJitterHours, so servers started
+// together do not all restart at once.
+type RestartPolicyConfig struct {
+	MaxUptimeHours      int    `json:"max_uptime_hours"`
+	UptimeJitterHours   int    `json:"uptime_jitter_hours"`
+	AfterMatches        int    `json:"after_matches"`
+	MemoryGrowthPercent int    `json:"memory_growth_percent"` // RSS growth over the READY baseline
+	DailyTime           string `json:"daily_time"`            // "HH:MM", once a day
+}
+
+// CPUAffinityConfig controls how game servers are pinned to CPUs (see also
+// svr_total_per_core). When TopologyAware is set, the host's SMT siblings and
+// NUMA nodes are taken into account on Linux. ReservedCPUs lists logical CPU
+// IDs kept free for the OS and Energizer itself.
+type CPUAffinityConfig struct {
+	TopologyAware bool  `json:"topology_aware"`
+	ReservedCPUs  []int `json:"reserved_cpus"`
+}
+
+// CgroupConfig runs each game server in its own cgroup v2 group,
+// <Root>/<Slice>/server-<port>, with the given resource limits (Linux only).
+// A zero limit is left unset. If the cgroup hierarchy is not writable,
+// servers run without limits and a warning is logged.
+type CgroupConfig struct {
+	Enabled       bool   `json:"enabled"`
+	Root          string `json:"root"`            // cgroup v2 mount point
+	Slice         string `json:"slice"`           // Parent group, relative to Root
+	MemoryMaxMB   int    `json:"memory_max_mb"`   // memory.max
+	CPUWeight     int    `json:"cpu_weight"`      // cpu.weight, 1-10000 (kernel default 100)
+	CPUMaxPercent int    `json:"cpu_max_percent"` // cpu.max, as a percentage of one CPU
+	PidsMax       int    `json:"pids_max"`        // pids.max
+}
+
+// SandboxConfig isolates game servers from each other and from Energizer
+// (Linux only). RunAsUser and Namespaces require Energizer to run as root.
+// With IsolateHome, each server gets its own HOME and XDG directories under
+// HomeRoot/server-<port>. With Namespaces, each server runs in a new mount
+// and PID namespace, and sees a private copy of every PrivateDirs entry,
+// kept in its home directory.
+type SandboxConfig struct {
+	RunAsUser   string   `json:"run_as_user"`  // User name or uid, empty keeps Energizer's user
+	RunAsGroup  string   `json:"run_as_group"` // Group name or gid, empty uses the user's primary group
+	IsolateHome bool     `json:"isolate_home"`
+	HomeRoot    string   `json:"home_root"`
+	Namespaces  bool     `json:"namespaces"`
+	PrivateDirs []string `json:"private_dirs"` // Absolute paths, requires namespaces and isolate_home
+}
+
+// RemoteCommandConfig controls raw console commands sent to game servers from
+// the API and CLI. A command is allowed if it matches at least one Allow
+// pattern (any command if Allow is empty) and no Deny pattern. Patterns are
+// regular expressions matched against the whole command, ignoring case.
+type RemoteCommandConfig struct {
+	Enabled bool     `json:"enabled"`
+	Allow   []string `json:"allow"`
+	Deny    []string `json:"deny"`
+}
+
+// AutoscaleConfig controls the demand-driven instance autoscaler.
+// It keeps WarmInstances idle READY servers available on top of occupied ones,
+// bounded by MinInstances and the host's CPU capacity.
+type AutoscaleConfig struct {
+	Enabled              bool `json:"enabled"`
+	WarmInstances        int  `json:"warm_instances"`
+	MinInstances         int  `json:"min_instances"`
+	CheckIntervalSec     int  `json:"check_interval_sec"`
+	ScaleDownCooldownSec int  `json:"scale_down_cooldown_sec"`
+}
+
+// RolloutConfig controls blue/green rollouts of a candidate HoN install.
+// Servers move to the candidate one at a time as they become idle:
+// CanaryPercent of the fleet first, then the rest once the canaries have run
+// for CanaryMinutes. The rollout pauses if the candidate's crash or lag rate
+// exceeds the current install's by more than TolerancePercent.
+type RolloutConfig struct {
+	CanaryPercent    int `json:"canary_percent"`
+	CanaryMinutes    int `json:"canary_minutes"`
+	CheckIntervalSec int `json:"check_interval_sec"`
+	TolerancePercent int `json:"tolerance_percent"`
+}
+
+// PatchConfig controls game patches found by the patch_version check or due
+// in a "patch" maintenance window. Patches are always downloaded and verified
+// as soon as they are found; with ApplyOnDetect they are also applied right
+// away (servers stop once their match ends), otherwise in the next patch
+// window. If a patched server is not READY within ReadyTimeoutSec, the
+// previous files are restored.
+type PatchConfig struct {
+	ApplyOnDetect   bool `json:"apply_on_detect"`
+	ReadyTimeoutSec int  `json:"ready_timeout_sec"`
+}
+
+// CaptureConfig controls recording of every packet on the manager port to
+// <Directory>/manager.cap, for reproducing state bugs with
+// "energizer replay-capture". The file is rotated like the log files.
+type CaptureConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Directory  string `json:"directory"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	MaxBackups int    `json:"max_backups"`
+}
+
+// IdleKickConfig controls kicking of players who linger after a match ends
+// or go AFK in the lobby. Players are warned in-game WarningSec before the
+// kick; WarningMessage may use the {player} and {seconds} placeholders.
+type IdleKickConfig struct {
+	Enabled          bool     `json:"enabled"`
+	PostGameDelaySec int      `json:"post_game_delay_sec"`
+	LobbyAFKSec      int      `json:"lobby_afk_sec"` // 0 disables lobby AFK detection
+	WarningSec       int      `json:"warning_sec"`
+	WarningMessage   string   `json:"warning_message"`
+	KickReason       string   `json:"kick_reason"`
+	ExemptPlayers    []string `json:"exempt_players"`
+}
+
+// MaintenanceConfig schedules maintenance windows. Before each window, idle
+// and lobby servers receive countdown messages at each of WarningMinutes, and
+// DrainLeadMin before the start all servers are drained so no new matches
+// begin. Templates may use {name}, {action}, {minutes}, {start} and {duration}.
+type MaintenanceConfig struct {
+	Windows         []MaintenanceWindow `json:"windows"`
+	WarningMinutes  []int               `json:"warning_minutes"`
+	DrainLeadMin    int                 `json:"drain_lead_min"`
+	WarningTemplate string              `json:"warning_template"`
+	StartTemplate   string              `json:"start_template"`
+}
+
+// MaintenanceWindow is a single scheduled maintenance window. Start is either
+// a daily "HH:MM" time (optionally limited to Days, e.g. ["sat", "sun"]) or an
+// RFC 3339 timestamp for a one-off window.
+type MaintenanceWindow struct {
+	Name        string   `json:"name"`
+	Start       string   `json:"start"`
+	Days        []string `json:"days,omitempty"`
+	DurationMin int      `json:"duration_min"`
+	Action      string   `json:"action"` // "restart", "stop" or "patch"
 }
 
 // ReplayCleanerConfig holds replay cleanup settings.
@@ -117,6 +291,12 @@ type ReplayCleanerConfig struct {
 	TmpRetentionDays int    `json:"tmp_retention_days"`
 }
 
+// ReplayUploadConfig controls uploads of replays that players request
+// through the chat server.
+type ReplayUploadConfig struct {
+	MaxConcurrentUploads int `json:"max_concurrent_uploads"`
+}
+
 // LongTermStorageConfig holds offsite replay storage settings.
 type LongTermStorageConfig struct {
 	Enabled   bool   `json:"enabled"`
@@ -166,6 +346,11 @@ type LoggingConfig struct {
 	Directory  string `json:"directory"`
 	MaxSizeMB  int    `json:"max_size_mb"`
 	MaxBackups int    `json:"max_backups"`
+
+	// Game server console output (Linux only) is written to
+	// <Directory>/servers/server-<port>.log with the same rotation settings;
+	// the last ConsoleBufferLines lines are kept in memory.
+	ConsoleBufferLines int `json:"console_buffer_lines"`
 }
 
 // DefaultConfig returns a configuration with sensible defaults.
@@ -187,21 +372,23 @@ func DefaultConfig() *Config {
 			MaxIdleTime:               60,
 			AllowBotMatches:           false,
 			OverrideAffinity:          true,
+			AdoptServers:              true,
 			MaxConcurrentStarts:       5,
 		},
 		ApplicationData: ApplicationData{
 			Timers: TimerConfig{
-				PatchCheckInterval:    120,
-				VersionCheckInterval:  60,
-				PublicIPCheckInterval: 1800,
-				GeneralHealthInterval: 60,
-				DiskCheckInterval:     3600,
-				LagCheckInterval:      120,
-				FilebeatCheckInterval: 10800,
-				AutopingCheckInterval: 300,
-				StatsPollingInterval:  10,
-				HeartbeatInterval:     60,
-				TaskCleanupInterval:   1800,
+				PatchCheckInterval:      120,
+				VersionCheckInterval:    60,
+				PublicIPCheckInterval:   1800,
+				GeneralHealthInterval:   60,
+				DiskCheckInterval:       3600,
+				LagCheckInterval:        120,
+				FilebeatCheckInterval:   10800,
+				AutopingCheckInterval:   300,
+				StatsPollingInterval:    10,
+				HeartbeatInterval:       60,
+				TaskCleanupInterval:     1800,
+				IdlePlayerCheckInterval: 10,
 			},
 			ReplayCleaner: ReplayCleanerConfig{
 				Enabled:          true,
@@ -229,6 +416,72 @@ func DefaultConfig() *Config {
 				Directory:  "logs",
 				MaxSizeMB:  10,
 				MaxBackups: 5,
+
+				ConsoleBufferLines: 500,
+			},
+			CrashPolicy: CrashPolicyConfig{
+				AutoRestart:         true,
+				InitialBackoffSec:   5,
+				MaxBackoffSec:       300,
+				QuarantineCrashes:   5,
+				QuarantineWindowSec: 600,
+			},
+			RestartPolicy: RestartPolicyConfig{
+				MaxUptimeHours:    24,
+				UptimeJitterHours: 24,
+			},
+			CPUAffinity: CPUAffinityConfig{
+				TopologyAware: true,
+			},
+			Cgroups: CgroupConfig{
+				Enabled: false,
+				Root:    "/sys/fs/cgroup",
+				Slice:   "energizer.slice",
+			},
+			Sandbox: SandboxConfig{
+				HomeRoot: "instances",
+			},
+			RemoteCommands: RemoteCommandConfig{
+				Enabled: false,
+				Deny:    []string{`quit\b.*`, `exit\b.*`},
+			},
+			Autoscale: AutoscaleConfig{
+				Enabled:              false,
+				WarmInstances:        2,
+				MinInstances:         1,
+				CheckIntervalSec:     30,
+				ScaleDownCooldownSec: 600,
+			},
+			IdleKick: IdleKickConfig{
+				Enabled:          true,
+				PostGameDelaySec: 60,
+				LobbyAFKSec:      180,
+				WarningSec:       15,
+				WarningMessage:   "{player}, you will be kicked in {seconds} seconds for inactivity",
+				KickReason:       "Kicked for inactivity",
+			},
+			Rollout: RolloutConfig{
+				CanaryPercent:    10,
+				CanaryMinutes:    30,
+				CheckIntervalSec: 30,
+				TolerancePercent: 25,
+			},
+			Patch: PatchConfig{
+				ReadyTimeoutSec: 300,
+			},
+			Capture: CaptureConfig{
+				Directory:  "captures",
+				MaxSizeMB:  100,
+				MaxBackups: 5,
+			},
+			ReplayUpload: ReplayUploadConfig{
+				MaxConcurrentUploads: 2,
+			},
+			Maintenance: MaintenanceConfig{
+				WarningMinutes:  []int{30, 15, 5, 1},
+				DrainLeadMin:    5,
+				WarningTemplate: "Scheduled maintenance ({action}) in {minutes} minute(s) at {start}, expected to last {duration} minutes",
+				StartTemplate:   "Maintenance ({action}) is starting now, servers will be back in about {duration} minutes",
 			},
 		},
 	}
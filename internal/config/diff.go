@@ -0,0 +1,188 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ChangeClass tells when a configuration change takes effect.
+type ChangeClass string
+
+const (
+	// ApplyLive changes take effect as soon as the configuration is saved.
+	ApplyLive ChangeClass = "live"
+	// ApplyIdleRestart changes reach a game server when its process is next
+	// started; running servers are restarted once they are idle.
+	ApplyIdleRestart ChangeClass = "idle_restart"
+	// ApplyManagerRestart changes are only read when Energizer starts.
+	ApplyManagerRestart ChangeClass = "manager_restart"
+)
+
+// FieldChange is one configuration field that differs between two versions.
+type FieldChange struct {
+	Field string      `json:"field"` // JSON path, e.g. "hon_data.svr_name"
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+	Apply ChangeClass `json:"apply"`
+}
+
+// changeRules classifies fields by JSON path. The longest matching path
+// wins; fields without a rule fall back to the section default.
+var changeRules = map[string]ChangeClass{
+	// Fleet size is reconciled by adding or removing instances
+	"hon_data.svr_total":               ApplyLive,
+	"hon_data.man_adopt_servers":       ApplyLive,
+	"hon_data.man_cowmaster_pool_size": ApplyLive,
+
+	// Only read by servers moved to the candidate install, on their next start
+	"hon_data.hon_candidate_install_directory": ApplyLive,
+	"hon_data.svr_candidate_version":           ApplyLive,
+
+	// Read once by the connectors, listeners, and CPU planner
+	"hon_data.svr_login":                         ApplyManagerRestart,
+	"hon_data.svr_password":                      ApplyManagerRestart,
+	"hon_data.svr_masterServer":                  ApplyManagerRestart,
+	"hon_data.svr_version":                       ApplyManagerRestart,
+	"hon_data.svr_region":                        ApplyManagerRestart,
+	"hon_data.svr_managerPort":                   ApplyManagerRestart,
+	"hon_data.svr_api_port":                      ApplyManagerRestart,
+	"hon_data.svr_starting_gamePort":             ApplyManagerRestart,
+	"hon_data.svr_starting_voicePort":            ApplyManagerRestart,
+	"hon_data.svr_starting_proxyPort":            ApplyManagerRestart,
+	"hon_data.svr_starting_voiceProxyLocalPort":  ApplyManagerRestart,
+	"hon_data.svr_starting_voiceProxyRemotePort": ApplyManagerRestart,
+	"hon_data.svr_total_per_core":                ApplyManagerRestart,
+	"hon_data.svr_max_concurrent_starts":         ApplyManagerRestart,
+	"hon_data.man_use_cowmaster":                 ApplyManagerRestart,
+
+	// Re-read on every check or event
+	"application_data.timers":          ApplyLive,
+	"application_data.crash_policy":    ApplyLive,
+	"application_data.autoscale":       ApplyLive,
+	"application_data.idle_kick":       ApplyLive,
+	"application_data.maintenance":     ApplyLive,
+	"application_data.remote_commands": ApplyLive,
+	"application_data.restart_policy":  ApplyLive,
+	"application_data.discord":         ApplyLive,
+	"application_data.rollout":         ApplyLive,
+	"application_data.patch":           ApplyLive,
+
+	"application_data.autoscale.check_interval_sec": ApplyManagerRestart,
+	"application_data.rollout.check_interval_sec":   ApplyManagerRestart,
+
+	// Part of the game server's process settings
+	"application_data.cgroups": ApplyIdleRestart,
+	"application_data.sandbox": ApplyIdleRestart,
+}
+
+// sectionDefaults classifies fields without a rule. Anything else in
+// hon_data ends up on the game server command line.
+var sectionDefaults = map[string]ChangeClass{
+	"hon_data":         ApplyIdleRestart,
+	"application_data": ApplyManagerRestart,
+}
+
+// secretFields are reported as changed without their values.
+var secretFields = []string{"password", "secret", "token", "webhook_url"}
+
+// DiffHoNData returns the fields that differ between two HoNData versions.
+func DiffHoNData(oldData, newData HoNData) []FieldChange {
+	return diffSection("hon_data", oldData, newData)
+}
+
+// DiffApplicationData returns the fields that differ between two
+// ApplicationData versions.
+func DiffApplicationData(oldData, newData ApplicationData) []FieldChange {
+	return diffSection("application_data", oldData, newData)
+}
+
+// FilterChanges returns the changes of the given class.
+func FilterChanges(changes []FieldChange, class ChangeClass) []FieldChange {
+	var out []FieldChange
+	for _, ch := range changes {
+		if ch.Apply == class {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// HasChanges reports whether any change belongs to the given class.
+func HasChanges(changes []FieldChange, class ChangeClass) bool {
+	for _, ch := range changes {
+		if ch.Apply == class {
+			return true
+		}
+	}
+	return false
+}
+
+func diffSection(section string, oldData, newData interface{}) []FieldChange {
+	var changes []FieldChange
+	diffValue(section, reflect.ValueOf(oldData), reflect.ValueOf(newData), &changes)
+	return changes
+}
+
+// diffValue walks nested structs by their JSON names and compares everything
+// else (including slices) as a whole.
+func diffValue(path string, oldVal, newVal reflect.Value, changes *[]FieldChange) {
+	if oldVal.Kind() == reflect.Struct {
+		t := oldVal.Type()
+		for idx := 0; idx < t.NumField(); idx++ {
+			field := t.Field(idx)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if field.PkgPath != "" || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			diffValue(path+"."+name, oldVal.Field(idx), newVal.Field(idx), changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(oldVal.Interface(), newVal.Interface()) {
+		return
+	}
+
+	change := FieldChange{
+		Field: path,
+		Old:   oldVal.Interface(),
+		New:   newVal.Interface(),
+		Apply: classifyChange(path),
+	}
+	if isSecretField(path) {
+		change.Old, change.New = "********", "********"
+	}
+	*changes = append(*changes, change)
+}
+
+// classifyChange looks up the rule for path, then for each parent path.
+func classifyChange(path string) ChangeClass {
+	for key := path; ; {
+		if class, ok := changeRules[key]; ok {
+			return class
+		}
+		dot := strings.LastIndex(key, ".")
+		if dot < 0 {
+			break
+		}
+		key = key[:dot]
+	}
+	section := strings.SplitN(path, ".", 2)[0]
+	if class, ok := sectionDefaults[section]; ok {
+		return class
+	}
+	return ApplyManagerRestart
+}
+
+func isSecretField(path string) bool {
+	name := path[strings.LastIndex(path, ".")+1:]
+	for _, secret := range secretFields {
+		if strings.Contains(strings.ToLower(name), secret) {
+			return true
+		}
+	}
+	return false
+}
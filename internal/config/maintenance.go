@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Maintenance window actions.
+const (
+	MaintenanceRestart = "restart" // Restart the whole fleet
+	MaintenanceStop    = "stop"    // Keep servers stopped until the window ends
+	MaintenancePatch   = "patch"   // Stop servers and request a game patch
+)
+
+// weekdays maps the accepted day names to time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Duration returns the length of the window.
+func (w MaintenanceWindow) Duration() time.Duration {
+	return time.Duration(w.DurationMin) * time.Minute
+}
+
+// NextStart returns the first start of the window strictly after the given
+// time, or the zero time if a one-off window has already started.
+func (w MaintenanceWindow) NextStart(after time.Time) (time.Time, error) {
+	// One-off window
+	if strings.Contains(w.Start, "T") {
+		start, err := time.Parse(time.RFC3339, w.Start)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid start %q: %w", w.Start, err)
+		}
+		if !start.After(after) {
+			return time.Time{}, nil
+		}
+		return start, nil
+	}
+
+	// Daily window
+	clock, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start %q: expected HH:MM or RFC 3339", w.Start)
+	}
+
+	days := make(map[time.Weekday]bool, len(w.Days))
+	for _, d := range w.Days {
+		day, ok := weekdays[strings.ToLower(d)]
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid day %q", d)
+		}
+		days[day] = true
+	}
+
+	local := after.Local()
+	for i := 0; i <= 7; i++ {
+		day := local.AddDate(0, 0, i)
+		start := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, local.Location())
+		if !start.After(after) {
+			continue
+		}
+		if len(days) > 0 && !days[start.Weekday()] {
+			continue
+		}
+		return start, nil
+	}
+
+	return time.Time{}, nil
+}
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
+	"time"
 )
 
 // ValidationError represents a configuration validation error.
@@ -45,6 +49,12 @@ func Validate(cfg *Config) *ValidationResult {
 	validateHoNData(&cfg.HoNData, result)
 	validateApplicationData(&cfg.ApplicationData, result)
 
+	if sb := cfg.ApplicationData.Sandbox; cfg.HoNData.UseCowMaster &&
+		(sb.RunAsUser != "" || sb.IsolateHome || sb.Namespaces) {
+		result.AddWarning("hon_data.man_use_cowmaster",
+			"servers are not forked from the CowMaster while the sandbox is enabled")
+	}
+
 	return result
 }
 
@@ -65,6 +75,21 @@ func validateHoNData(data *HoNData, result *ValidationResult) {
 			fmt.Sprintf("directory does not exist: %s", data.InstallDirectory))
 	}
 
+	// Blue/green rollout
+	if candidate := strings.TrimSpace(data.CandidateInstallDirectory); candidate != "" {
+		if filepath.Clean(candidate) == filepath.Clean(data.InstallDirectory) {
+			result.AddError("hon_data.hon_candidate_install_directory",
+				"candidate install must differ from hon_install_directory")
+		} else if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			result.AddWarning("hon_data.hon_candidate_install_directory",
+				fmt.Sprintf("directory does not exist: %s", candidate))
+		}
+		if data.CandidateVersion == "" {
+			result.AddWarning("hon_data.svr_candidate_version",
+				"candidate install has no version, rollout status cannot tell the installs apart")
+		}
+	}
+
 	// Server count validation
 	if data.TotalServers < 1 {
 		result.AddError("hon_data.svr_total", "must have at least 1 server")
@@ -95,6 +120,14 @@ func validateHoNData(data *HoNData, result *ValidationResult) {
 		result.AddError("hon_data.ports", "port conflict detected: all ports must be unique")
 	}
 
+	// CowMaster warm pool
+	if data.CowMasterPoolSize < 0 {
+		result.AddError("hon_data.man_cowmaster_pool_size", "pool size must not be negative")
+	} else if data.CowMasterPoolSize > 0 && !data.UseCowMaster {
+		result.AddWarning("hon_data.man_cowmaster_pool_size",
+			"pool size has no effect while man_use_cowmaster is disabled")
+	}
+
 	// Idle time
 	if data.MaxIdleTime < 10 {
 		result.AddWarning("hon_data.svr_max_idle_time", "idle time less than 10 seconds may cause issues")
@@ -140,6 +173,211 @@ func validateApplicationData(data *ApplicationData, result *ValidationResult) {
 			"rate limit is disabled (0 RPS), this may expose the API to abuse")
 	}
 
+	// Crash policy
+	if data.CrashPolicy.AutoRestart {
+		if data.CrashPolicy.InitialBackoffSec < 1 {
+			result.AddError("application_data.crash_policy.initial_backoff_sec",
+				"initial backoff must be at least 1 second")
+		}
+		if data.CrashPolicy.MaxBackoffSec < data.CrashPolicy.InitialBackoffSec {
+			result.AddError("application_data.crash_policy.max_backoff_sec",
+				"max backoff must not be less than initial backoff")
+		}
+	}
+	if data.CrashPolicy.QuarantineCrashes > 0 && data.CrashPolicy.QuarantineWindowSec < 1 {
+		result.AddError("application_data.crash_policy.quarantine_window_sec",
+			"quarantine window must be at least 1 second when quarantine is enabled")
+	}
+
+	// Autoscale
+	if data.Autoscale.Enabled {
+		if data.Autoscale.WarmInstances < 0 {
+			result.AddError("application_data.autoscale.warm_instances",
+				"warm instances must not be negative")
+		}
+		if data.Autoscale.MinInstances < 0 {
+			result.AddError("application_data.autoscale.min_instances",
+				"min instances must not be negative")
+		}
+		if data.Autoscale.CheckIntervalSec < 5 {
+			result.AddWarning("application_data.autoscale.check_interval_sec",
+				"autoscale interval less than 5s may cause flapping")
+		}
+	}
+
+	// Rollout
+	if data.Rollout.CanaryPercent < 0 || data.Rollout.CanaryPercent > 100 {
+		result.AddError("application_data.rollout.canary_percent",
+			"canary share must be between 0 and 100")
+	}
+	if data.Rollout.CanaryMinutes < 0 || data.Rollout.TolerancePercent < 0 {
+		result.AddError("application_data.rollout",
+			"rollout canary time and tolerance must not be negative")
+	}
+
+	// Patches
+	if data.Patch.ReadyTimeoutSec < 0 {
+		result.AddError("application_data.patch.ready_timeout_sec",
+			"patch ready timeout must not be negative")
+	}
+
+	// Traffic capture
+	if data.Capture.Enabled && data.Capture.Directory == "" {
+		result.AddError("application_data.capture.directory",
+			"capture directory is required when capture is enabled")
+	}
+	if data.Capture.MaxSizeMB < 0 || data.Capture.MaxBackups < 0 {
+		result.AddError("application_data.capture",
+			"capture file size and backups must not be negative")
+	}
+
+	// Replay uploads
+	if data.ReplayUpload.MaxConcurrentUploads < 1 {
+		result.AddError("application_data.replay_upload.max_concurrent_uploads",
+			"at least one replay upload must be allowed at a time")
+	}
+
+	// Idle kick
+	if data.IdleKick.Enabled {
+		if data.IdleKick.PostGameDelaySec < 0 || data.IdleKick.LobbyAFKSec < 0 || data.IdleKick.WarningSec < 0 {
+			result.AddError("application_data.idle_kick",
+				"idle kick delays must not be negative")
+		}
+		if data.IdleKick.LobbyAFKSec > 0 && data.IdleKick.LobbyAFKSec < 30 {
+			result.AddWarning("application_data.idle_kick.lobby_afk_sec",
+				"lobby AFK timeout less than 30s may kick players who are still picking")
+		}
+	}
+
+	// Restart policy
+	rp := data.RestartPolicy
+	if rp.MaxUptimeHours < 0 || rp.UptimeJitterHours < 0 || rp.AfterMatches < 0 || rp.MemoryGrowthPercent < 0 {
+		result.AddError("application_data.restart_policy",
+			"restart policy values must not be negative")
+	}
+	if rp.DailyTime != "" {
+		if _, err := time.Parse("15:04", rp.DailyTime); err != nil {
+			result.AddError("application_data.restart_policy.daily_time",
+				fmt.Sprintf("invalid time %q (expected HH:MM)", rp.DailyTime))
+		}
+	}
+	if rp.MaxUptimeHours == 0 && rp.AfterMatches == 0 && rp.MemoryGrowthPercent == 0 && rp.DailyTime == "" {
+		result.AddWarning("application_data.restart_policy",
+			"all restart rules are disabled, servers will never be restarted periodically")
+	}
+
+	// CPU affinity
+	for _, id := range data.CPUAffinity.ReservedCPUs {
+		if id < 0 || id >= runtime.NumCPU() {
+			result.AddWarning("application_data.cpu_affinity.reserved_cpus",
+				fmt.Sprintf("reserved CPU %d does not exist on this host (%d CPUs)", id, runtime.NumCPU()))
+		}
+	}
+	if n := len(data.CPUAffinity.ReservedCPUs); n > 0 && n >= runtime.NumCPU() {
+		result.AddWarning("application_data.cpu_affinity.reserved_cpus",
+			"all CPUs are reserved, game servers will not be pinned")
+	}
+
+	// Cgroups
+	if cg := data.Cgroups; cg.Enabled {
+		if runtime.GOOS != "linux" {
+			result.AddWarning("application_data.cgroups.enabled", "cgroups are only supported on Linux")
+		}
+		if cg.Root == "" || !filepath.IsAbs(cg.Root) {
+			result.AddError("application_data.cgroups.root", "cgroup root must be an absolute path")
+		}
+		if cg.Slice == "" || filepath.IsAbs(cg.Slice) || strings.Contains(cg.Slice, "..") {
+			result.AddError("application_data.cgroups.slice", "cgroup slice must be a relative path below the cgroup root")
+		}
+		if cg.MemoryMaxMB < 0 {
+			result.AddError("application_data.cgroups.memory_max_mb",
+				"memory limit must not be negative")
+		} else if cg.MemoryMaxMB > 0 && cg.MemoryMaxMB < 256 {
+			result.AddWarning("application_data.cgroups.memory_max_mb", "limits below 256 MB will likely get game servers OOM-killed")
+		}
+		if cg.CPUWeight < 0 || cg.CPUWeight > 10000 {
+			result.AddError("application_data.cgroups.cpu_weight", "CPU weight must be between 1 and 10000")
+		}
+		if cg.CPUMaxPercent < 0 {
+			result.AddError("application_data.cgroups.cpu_max_percent",
+				"CPU limit must not be negative")
+		}
+		if cg.PidsMax < 0 {
+			result.AddError("application_data.cgroups.pids_max",
+				"process limit must not be negative")
+		}
+	}
+
+	// Sandbox
+	if sb := data.Sandbox; sb.RunAsUser != "" || sb.RunAsGroup != "" || sb.IsolateHome || sb.Namespaces {
+		if runtime.GOOS != "linux" {
+			result.AddWarning("application_data.sandbox", "sandboxing is only supported on Linux")
+		} else if (sb.RunAsUser != "" || sb.RunAsGroup != "" || sb.Namespaces) && os.Geteuid() != 0 {
+			result.AddError("application_data.sandbox",
+				"run_as_user, run_as_group and namespaces require Energizer to run as root")
+		}
+		if sb.RunAsGroup != "" && sb.RunAsUser == "" {
+			result.AddError("application_data.sandbox.run_as_group",
+				"run_as_group requires run_as_user")
+		}
+		if sb.IsolateHome && sb.HomeRoot == "" {
+			result.AddError("application_data.sandbox.home_root",
+				"home root is required when isolate_home is enabled")
+		}
+		for idx, dir := range sb.PrivateDirs {
+			field := fmt.Sprintf("application_data.sandbox.private_dirs[%d]", idx)
+			if !filepath.IsAbs(dir) || filepath.Clean(dir) == "/" {
+				result.AddError(field, "private directory must be an absolute path other than /")
+			}
+		}
+		if len(sb.PrivateDirs) > 0 && (!sb.Namespaces || !sb.IsolateHome) {
+			result.AddError("application_data.sandbox.private_dirs",
+				"private directories require namespaces and isolate_home")
+		}
+	}
+
+	// Remote commands
+	for idx, pattern := range data.RemoteCommands.Allow {
+		if _, err := regexp.Compile(pattern); err != nil {
+			result.AddError(fmt.Sprintf("application_data.remote_commands.allow[%d]", idx),
+				fmt.Sprintf("invalid pattern: %v", err))
+		}
+	}
+	for idx, pattern := range data.RemoteCommands.Deny {
+		if _, err := regexp.Compile(pattern); err != nil {
+			result.AddError(fmt.Sprintf("application_data.remote_commands.deny[%d]", idx),
+				fmt.Sprintf("invalid pattern: %v", err))
+		}
+	}
+
+	// Maintenance windows
+	for idx, w := range data.Maintenance.Windows {
+		field := fmt.Sprintf("application_data.maintenance.windows[%d]", idx)
+		if _, err := w.NextStart(time.Now()); err != nil {
+			result.AddError(field+".start", err.Error())
+		}
+		if w.DurationMin <= 0 {
+			result.AddError(field+".duration_min", "maintenance window duration must be positive")
+		}
+		switch w.Action {
+		case MaintenanceRestart, MaintenanceStop, MaintenancePatch:
+		default:
+			result.AddError(field+".action",
+				fmt.Sprintf("unknown maintenance action %q (expected restart, stop or patch)", w.Action))
+		}
+	}
+	for _, m := range data.Maintenance.WarningMinutes {
+		if m <= 0 {
+			result.AddError("application_data.maintenance.warning_minutes",
+				"maintenance warnings must be a positive number of minutes")
+			break
+		}
+	}
+	if data.Maintenance.DrainLeadMin < 0 {
+		result.AddError("application_data.maintenance.drain_lead_min",
+			"drain lead time must not be negative")
+	}
+
 	// Discord
 	if data.Discord.OwnerID != "" {
 		if len(data.Discord.OwnerID) < 17 || len(data.Discord.OwnerID) > 20 {
@@ -5,10 +5,12 @@ package connector
 import (
 	"bytes"
 	"context"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -25,6 +27,8 @@ const (
 	defaultMasterServerURL = "http://api.kongor.net"
 	replayAuthPath         = "/server_requester.php"
 	patchCheckPath         = "/patcher/patcher.php"
+	patchFilesPath         = "/patcher/files"
+	patchArch              = "x86_64"
 	userAgent              = "S2 Games/Heroes of Newerth/%s/x86_64/%s"
 	authRetryInterval      = 30 * time.Second
 	authMaxRetries         = 5
@@ -50,6 +54,24 @@ type MasterServerConnector struct {
 
 	// Version
 	upstreamVersion string
+	patchURL        string // Where the patcher serves game files (empty: master server)
+}
+
+// PatchManifest lists the files of one game version, as published by the
+// master server's patcher.
+type PatchManifest struct {
+	XMLName xml.Name    `xml:"manifest"`
+	Version string      `xml:"version,attr"`
+	OS      string      `xml:"os,attr"`
+	Arch    string      `xml:"arch,attr"`
+	Files   []PatchFile `xml:"file"`
+}
+
+// PatchFile is one file of a game version.
+type PatchFile struct {
+	Path     string `xml:"path,attr"`     // Relative to the install directory, slash-separated
+	Size     int64  `xml:"size,attr"`     // Bytes
+	Checksum string `xml:"checksum,attr"` // SHA-256, hex
 }
 
 // NewMasterServerConnector creates a new master server connector.
@@ -228,7 +250,7 @@ func (c *MasterServerConnector) CompareUpstreamPatch(ctx context.Context) (bool,
 	patchData := map[string]interface{}{
 		"version": honData.ServerVersion,
 		"os":      getPlatformString(),
-		"arch":    "x86_64",
+		"arch":    patchArch,
 	}
 
 	serialized, err := protocol.PHPSerialize(patchData)
@@ -266,6 +288,12 @@ func (c *MasterServerConnector) CompareUpstreamPatch(ctx context.Context) (bool,
 		return false, "", nil
 	}
 
+	if patchURL, ok := resultMap["url"]; ok {
+		c.mu.Lock()
+		c.patchURL = strings.TrimRight(fmt.Sprintf("%v", patchURL), "/")
+		c.mu.Unlock()
+	}
+
 	if newVersion, ok := resultMap["latest_version"]; ok {
 		versionStr := fmt.Sprintf("%v", newVersion)
 		if versionStr != honData.ServerVersion {
@@ -279,6 +307,71 @@ func (c *MasterServerConnector) CompareUpstreamPatch(ctx context.Context) (bool,
 	return false, "", nil
 }
 
+// FetchPatchManifest downloads the manifest of a game version from the
+// patcher.
+func (c *MasterServerConnector) FetchPatchManifest(ctx context.Context, version string) (*PatchManifest, error) {
+	resp, err := c.getPatchFile(ctx, version, "manifest.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var manifest PatchManifest
+	if err := xml.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse patch manifest: %w", err)
+	}
+	if manifest.Version != "" && manifest.Version != version {
+		return nil, fmt.Errorf("patch manifest is for version %s, expected %s", manifest.Version, version)
+	}
+	return &manifest, nil
+}
+
+// DownloadPatchFile writes one file of a game version to w.
+func (c *MasterServerConnector) DownloadPatchFile(ctx context.Context, version, path string, w io.Writer) error {
+	resp, err := c.getPatchFile(ctx, version, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download %s: %w", path, err)
+	}
+	return nil
+}
+
+// getPatchFile requests {patcher}/{os}/{arch}/{version}/{path}. The caller
+// must close the response body.
+func (c *MasterServerConnector) getPatchFile(ctx context.Context, version, path string) (*http.Response, error) {
+	c.mu.RLock()
+	base := c.patchURL
+	c.mu.RUnlock()
+	if base == "" {
+		base = c.getMasterServerBaseURL() + patchFilesPath
+	}
+
+	rel := &url.URL{Path: strings.Join([]string{getPlatformString(), patchArch, version, path}, "/")}
+	req, err := http.NewRequestWithContext(ctx, "GET", base+"/"+rel.EscapedPath(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create patch download request: %w", err)
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf(userAgent, c.cfg.GetHoNData().ServerVersion, getPlatformString()))
+
+	// Game files can be large: the transfer is bounded by ctx, not the
+	// client timeout
+	client := *c.client
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("patch download failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("patch download of %s returned status %d", path, resp.StatusCode)
+	}
+	return resp, nil
+}
+
 // UploadReplay uploads a replay file to the master server.
 func (c *MasterServerConnector) UploadReplay(ctx context.Context, matchID uint32, filePath string) error {
 	c.mu.RLock()
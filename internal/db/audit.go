@@ -0,0 +1,136 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AuditDatabase records console commands sent to game servers, including
+// those rejected by the command policy.
+type AuditDatabase struct {
+	db *Database
+}
+
+// CommandAudit is one console command invocation.
+type CommandAudit struct {
+	ID      int64     `json:"id"`
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Source  string    `json:"source"` // "api", "cli", or the emitting component
+	Port    uint16    `json:"port"`
+	Command string    `json:"command"`
+	Allowed bool      `json:"allowed"`
+	Error   string    `json:"error,omitempty"` // Why the command was rejected or failed
+}
+
+// AuditFilter selects audit entries. Zero fields match everything.
+type AuditFilter struct {
+	Port  uint16
+	User  string
+	Limit int
+}
+
+// NewAuditDatabase creates and initializes the audit database.
+func NewAuditDatabase(dbPath string) (*AuditDatabase, error) {
+	database, err := NewDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	adb := &AuditDatabase{db: database}
+
+	if err := adb.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate audit database: %w", err)
+	}
+
+	return adb, nil
+}
+
+// migrate creates the database schema.
+func (adb *AuditDatabase) migrate() error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS command_audit (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			time DATETIME NOT NULL,
+			user TEXT NOT NULL,
+			source TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			command TEXT NOT NULL,
+			allowed INTEGER NOT NULL,
+			error TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_command_audit_time ON command_audit(time);
+		CREATE INDEX IF NOT EXISTS idx_command_audit_port ON command_audit(port);
+	`
+
+	_, err := adb.db.Exec(schema)
+	if err != nil {
+		return fmt.Errorf("schema migration failed: %w", err)
+	}
+
+	log.Debug().Msg("audit database schema migrated")
+	return nil
+}
+
+// RecordCommand inserts an audit entry and sets its ID.
+func (adb *AuditDatabase) RecordCommand(a *CommandAudit) error {
+	res, err := adb.db.Exec(`
+		INSERT INTO command_audit (time, user, source, port, command, allowed, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, a.Time, a.User, a.Source, a.Port, a.Command, a.Allowed, a.Error)
+	if err != nil {
+		return fmt.Errorf("failed to record command: %w", err)
+	}
+
+	a.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// ListCommands returns the most recent audit entries, newest first.
+func (adb *AuditDatabase) ListCommands(filter AuditFilter) ([]CommandAudit, error) {
+	query := `
+		SELECT id, time, user, source, port, command, allowed, error
+		FROM command_audit WHERE 1 = 1`
+
+	var args []interface{}
+	if filter.Port != 0 {
+		query += " AND port = ?"
+		args = append(args, filter.Port)
+	}
+	if filter.User != "" {
+		query += " AND user = ?"
+		args = append(args, filter.User)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query += " ORDER BY time DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := adb.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query command audit: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []CommandAudit
+	for rows.Next() {
+		var a CommandAudit
+		if err := rows.Scan(&a.ID, &a.Time, &a.User, &a.Source, &a.Port, &a.Command, &a.Allowed, &a.Error); err != nil {
+			continue
+		}
+		entries = append(entries, a)
+	}
+
+	return entries, nil
+}
+
+// Close closes the database.
+func (adb *AuditDatabase) Close() error {
+	return adb.db.Close()
+}
@@ -0,0 +1,332 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Match status values.
+const (
+	MatchStatusInProgress = "in_progress"
+	MatchStatusCompleted  = "completed"
+	MatchStatusAborted    = "aborted" // Server crashed or was replaced before the lobby closed
+)
+
+// MatchDatabase stores the history of matches played on the managed servers.
+// Matches are keyed by the match ID assigned by the master server.
+type MatchDatabase struct {
+	db *Database
+}
+
+// Match is a single recorded match, from lobby creation to lobby close.
+type Match struct {
+	MatchID     uint32        `json:"match_id"`
+	Port        uint16        `json:"port"`
+	MapName     string        `json:"map"`
+	Mode        string        `json:"mode"`
+	Status      string        `json:"status"`
+	StartedAt   time.Time     `json:"started_at"`
+	EndedAt     *time.Time    `json:"ended_at,omitempty"`
+	DurationSec int64         `json:"duration_sec"`
+	LagEvents   int           `json:"lag_events"`
+	LagTotalMs  uint64        `json:"lag_total_ms"`
+	LagMaxMs    uint32        `json:"lag_max_ms"`
+	Phases      []MatchPhase  `json:"phases"`
+	Players     []MatchPlayer `json:"players"`
+}
+
+// MatchPhase records how long a match spent in one game phase.
+// DurationMs is zero for the phase the match is currently in.
+type MatchPhase struct {
+	Phase      string    `json:"phase"`
+	EnteredAt  time.Time `json:"entered_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// MatchPlayer records a player joining (and possibly leaving) a match.
+// A player who reconnects appears once per connection.
+type MatchPlayer struct {
+	PlayerID uint32     `json:"player_id"`
+	Name     string     `json:"name"`
+	JoinedAt time.Time  `json:"joined_at"`
+	LeftAt   *time.Time `json:"left_at,omitempty"`
+}
+
+// MatchFilter selects a page of matches. Zero values mean "any".
+type MatchFilter struct {
+	Port   uint16
+	Status string
+	Limit  int
+	Offset int
+}
+
+// NewMatchDatabase creates and initializes the match history database.
+func NewMatchDatabase(dbPath string) (*MatchDatabase, error) {
+	database, err := NewDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	mdb := &MatchDatabase{db: database}
+
+	if err := mdb.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate match database: %w", err)
+	}
+
+	return mdb, nil
+}
+
+// migrate creates the database schema.
+func (mdb *MatchDatabase) migrate() error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS matches (
+			match_id INTEGER PRIMARY KEY,
+			port INTEGER NOT NULL,
+			map TEXT NOT NULL DEFAULT '',
+			mode TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			ended_at DATETIME,
+			duration_sec INTEGER NOT NULL DEFAULT 0,
+			lag_events INTEGER NOT NULL DEFAULT 0,
+			lag_total_ms INTEGER NOT NULL DEFAULT 0,
+			lag_max_ms INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS match_phases (
+			match_id INTEGER NOT NULL,
+			seq INTEGER NOT NULL,
+			phase TEXT NOT NULL,
+			entered_at DATETIME NOT NULL,
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (match_id, seq),
+			FOREIGN KEY (match_id) REFERENCES matches(match_id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS match_players (
+			match_id INTEGER NOT NULL,
+			seq INTEGER NOT NULL,
+			player_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			joined_at DATETIME NOT NULL,
+			left_at DATETIME,
+			PRIMARY KEY (match_id, seq),
+			FOREIGN KEY (match_id) REFERENCES matches(match_id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_matches_started_at ON matches(started_at);
+		CREATE INDEX IF NOT EXISTS idx_matches_port ON matches(port);
+		CREATE INDEX IF NOT EXISTS idx_matches_status ON matches(status);
+	`
+
+	_, err := mdb.db.Exec(schema)
+	if err != nil {
+		return fmt.Errorf("schema migration failed: %w", err)
+	}
+
+	log.Debug().Msg("match database schema migrated")
+	return nil
+}
+
+// SaveMatch inserts or replaces a match together with its phases and players.
+func (mdb *MatchDatabase) SaveMatch(m *Match) error {
+	return mdb.db.Transaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO matches (match_id, port, map, mode, status, started_at, ended_at,
+				duration_sec, lag_events, lag_total_ms, lag_max_ms)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(match_id) DO UPDATE SET
+				port = excluded.port, map = excluded.map, mode = excluded.mode,
+				status = excluded.status, started_at = excluded.started_at,
+				ended_at = excluded.ended_at, duration_sec = excluded.duration_sec,
+				lag_events = excluded.lag_events, lag_total_ms = excluded.lag_total_ms,
+				lag_max_ms = excluded.lag_max_ms
+		`, m.MatchID, m.Port, m.MapName, m.Mode, m.Status, m.StartedAt, nullTime(m.EndedAt),
+			m.DurationSec, m.LagEvents, m.LagTotalMs, m.LagMaxMs)
+		if err != nil {
+			return fmt.Errorf("failed to save match %d: %w", m.MatchID, err)
+		}
+
+		if _, err := tx.Exec("DELETE FROM match_phases WHERE match_id = ?", m.MatchID); err != nil {
+			return err
+		}
+		for seq, p := range m.Phases {
+			_, err := tx.Exec(
+				"INSERT INTO match_phases (match_id, seq, phase, entered_at, duration_ms) VALUES (?, ?, ?, ?, ?)",
+				m.MatchID, seq, p.Phase, p.EnteredAt, p.DurationMs)
+			if err != nil {
+				return fmt.Errorf("failed to save match phases: %w", err)
+			}
+		}
+
+		if _, err := tx.Exec("DELETE FROM match_players WHERE match_id = ?", m.MatchID); err != nil {
+			return err
+		}
+		for seq, p := range m.Players {
+			_, err := tx.Exec(
+				"INSERT INTO match_players (match_id, seq, player_id, name, joined_at, left_at) VALUES (?, ?, ?, ?, ?, ?)",
+				m.MatchID, seq, p.PlayerID, p.Name, p.JoinedAt, nullTime(p.LeftAt))
+			if err != nil {
+				return fmt.Errorf("failed to save match players: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetMatch returns a single match by ID, or nil if it was never recorded.
+func (mdb *MatchDatabase) GetMatch(matchID uint32) (*Match, error) {
+	row := mdb.db.QueryRow(matchSelect+" WHERE match_id = ?", matchID)
+
+	m, err := scanMatch(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mdb.loadDetails(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ListMatches returns a page of matches, newest first, and the total number
+// of matches that satisfy the filter.
+func (mdb *MatchDatabase) ListMatches(filter MatchFilter) ([]Match, int, error) {
+	where := " WHERE 1 = 1"
+	var args []interface{}
+	if filter.Port != 0 {
+		where += " AND port = ?"
+		args = append(args, filter.Port)
+	}
+	if filter.Status != "" {
+		where += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+
+	var total int
+	if err := mdb.db.QueryRow("SELECT COUNT(*) FROM matches"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count matches: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = 50 // A negative limit returns every match
+	}
+	rows, err := mdb.db.Query(
+		matchSelect+where+" ORDER BY started_at DESC, match_id DESC LIMIT ? OFFSET ?",
+		append(args, limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list matches: %w", err)
+	}
+
+	var matches []Match
+	for rows.Next() {
+		m, err := scanMatch(rows)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, *m)
+	}
+	rows.Close()
+
+	for i := range matches {
+		if err := mdb.loadDetails(&matches[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return matches, total, nil
+}
+
+// OpenMatches returns all matches that were still in progress when last saved.
+func (mdb *MatchDatabase) OpenMatches() ([]Match, error) {
+	matches, _, err := mdb.ListMatches(MatchFilter{Status: MatchStatusInProgress, Limit: -1})
+	return matches, err
+}
+
+// Close closes the database.
+func (mdb *MatchDatabase) Close() error {
+	return mdb.db.Close()
+}
+
+const matchSelect = `
+	SELECT match_id, port, map, mode, status, started_at, ended_at,
+		duration_sec, lag_events, lag_total_ms, lag_max_ms
+	FROM matches`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMatch reads a matchSelect row.
+func scanMatch(row rowScanner) (*Match, error) {
+	var m Match
+	var endedAt sql.NullTime
+	err := row.Scan(&m.MatchID, &m.Port, &m.MapName, &m.Mode, &m.Status, &m.StartedAt, &endedAt,
+		&m.DurationSec, &m.LagEvents, &m.LagTotalMs, &m.LagMaxMs)
+	if err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		m.EndedAt = &endedAt.Time
+	}
+	return &m, nil
+}
+
+// loadDetails fills in the phases and players of a match.
+func (mdb *MatchDatabase) loadDetails(m *Match) error {
+	m.Phases = []MatchPhase{}
+	m.Players = []MatchPlayer{}
+
+	phaseRows, err := mdb.db.Query(
+		"SELECT phase, entered_at, duration_ms FROM match_phases WHERE match_id = ? ORDER BY seq",
+		m.MatchID)
+	if err != nil {
+		return fmt.Errorf("failed to load match phases: %w", err)
+	}
+	for phaseRows.Next() {
+		var p MatchPhase
+		if err := phaseRows.Scan(&p.Phase, &p.EnteredAt, &p.DurationMs); err != nil {
+			continue
+		}
+		m.Phases = append(m.Phases, p)
+	}
+	phaseRows.Close()
+
+	playerRows, err := mdb.db.Query(
+		"SELECT player_id, name, joined_at, left_at FROM match_players WHERE match_id = ? ORDER BY seq",
+		m.MatchID)
+	if err != nil {
+		return fmt.Errorf("failed to load match players: %w", err)
+	}
+	for playerRows.Next() {
+		var p MatchPlayer
+		var leftAt sql.NullTime
+		if err := playerRows.Scan(&p.PlayerID, &p.Name, &p.JoinedAt, &leftAt); err != nil {
+			continue
+		}
+		if leftAt.Valid {
+			p.LeftAt = &leftAt.Time
+		}
+		m.Players = append(m.Players, p)
+	}
+	playerRows.Close()
+
+	return nil
+}
+
+// nullTime converts an optional time to a nullable column value.
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
@@ -119,7 +119,7 @@ func (rdb *RolesDatabase) migrate() error {
 func (rdb *RolesDatabase) seedDefaults() error {
 	return rdb.db.Transaction(func(tx *sql.Tx) error {
 		// Seed permissions
-		permissions := []string{"monitor", "control", "configure"}
+		permissions := []string{"monitor", "control", "configure", "command"}
 		for _, perm := range permissions {
 			_, err := tx.Exec(
 				"INSERT OR IGNORE INTO permissions (name) VALUES (?)", perm)
@@ -136,7 +136,7 @@ func (rdb *RolesDatabase) seedDefaults() error {
 		}{
 			{name: "user", perms: []string{"monitor"}, inherits: ""},
 			{name: "admin", perms: []string{"monitor", "control"}, inherits: "user"},
-			{name: "superadmin", perms: []string{"monitor", "control", "configure"}, inherits: "admin"},
+			{name: "superadmin", perms: []string{"monitor", "control", "configure", "command"}, inherits: "admin"},
 		}
 
 		for _, role := range roles {
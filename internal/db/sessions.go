@@ -0,0 +1,182 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SessionDatabase stores per-player connection sessions and the connection
+// quality observed during each one, so lag complaints can be checked later.
+type SessionDatabase struct {
+	db *Database
+}
+
+// PlayerSession is one connection of a player to a game server.
+// Ping statistics are computed from the pings reported in server status
+// packets; samples of zero (no measurement yet) are ignored.
+type PlayerSession struct {
+	ID         int64      `json:"id"`
+	PlayerName string     `json:"player_name"`
+	AccountID  uint32     `json:"account_id"`
+	Port       uint16     `json:"port"`
+	MatchID    uint32     `json:"match_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LeftAt     *time.Time `json:"left_at,omitempty"`
+	Samples    int        `json:"samples"`
+	PingMin    uint16     `json:"ping_min"`
+	PingAvg    float64    `json:"ping_avg"`
+	PingP95    uint16     `json:"ping_p95"`
+	PingMax    uint16     `json:"ping_max"`
+	Spikes     int        `json:"spikes"`
+}
+
+// SessionFilter selects a player's sessions. Exactly one of PlayerName and
+// AccountID should be set.
+type SessionFilter struct {
+	PlayerName string
+	AccountID  uint32
+	Limit      int
+}
+
+// NewSessionDatabase creates and initializes the player session database.
+func NewSessionDatabase(dbPath string) (*SessionDatabase, error) {
+	database, err := NewDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	sdb := &SessionDatabase{db: database}
+
+	if err := sdb.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate session database: %w", err)
+	}
+
+	return sdb, nil
+}
+
+// migrate creates the database schema.
+func (sdb *SessionDatabase) migrate() error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS player_sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			player_name TEXT NOT NULL COLLATE NOCASE,
+			account_id INTEGER NOT NULL DEFAULT 0,
+			port INTEGER NOT NULL,
+			match_id INTEGER NOT NULL DEFAULT 0,
+			joined_at DATETIME NOT NULL,
+			left_at DATETIME,
+			samples INTEGER NOT NULL DEFAULT 0,
+			ping_min INTEGER NOT NULL DEFAULT 0,
+			ping_avg REAL NOT NULL DEFAULT 0,
+			ping_p95 INTEGER NOT NULL DEFAULT 0,
+			ping_max INTEGER NOT NULL DEFAULT 0,
+			spikes INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_sessions_player_name ON player_sessions(player_name);
+		CREATE INDEX IF NOT EXISTS idx_sessions_account_id ON player_sessions(account_id);
+		CREATE INDEX IF NOT EXISTS idx_sessions_joined_at ON player_sessions(joined_at);
+	`
+
+	_, err := sdb.db.Exec(schema)
+	if err != nil {
+		return fmt.Errorf("schema migration failed: %w", err)
+	}
+
+	log.Debug().Msg("session database schema migrated")
+	return nil
+}
+
+// CreateSession inserts a new session and sets its ID.
+func (sdb *SessionDatabase) CreateSession(s *PlayerSession) error {
+	res, err := sdb.db.Exec(`
+		INSERT INTO player_sessions (player_name, account_id, port, match_id, joined_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, s.PlayerName, s.AccountID, s.Port, s.MatchID, s.JoinedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	s.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// UpdateSession writes the match, leave time and ping statistics of a session.
+func (sdb *SessionDatabase) UpdateSession(s *PlayerSession) error {
+	_, err := sdb.db.Exec(`
+		UPDATE player_sessions SET
+			account_id = ?, match_id = ?, left_at = ?, samples = ?,
+			ping_min = ?, ping_avg = ?, ping_p95 = ?, ping_max = ?, spikes = ?
+		WHERE id = ?
+	`, s.AccountID, s.MatchID, nullTime(s.LeftAt), s.Samples,
+		s.PingMin, s.PingAvg, s.PingP95, s.PingMax, s.Spikes, s.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update session %d: %w", s.ID, err)
+	}
+	return nil
+}
+
+// CloseOpenSessions marks sessions left open by a previous run as ended.
+// Their ping statistics are whatever was last written.
+func (sdb *SessionDatabase) CloseOpenSessions(at time.Time) (int64, error) {
+	res, err := sdb.db.Exec("UPDATE player_sessions SET left_at = ? WHERE left_at IS NULL", at)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// FindSessions returns a player's most recent sessions, newest first.
+// Player names are matched case-insensitively.
+func (sdb *SessionDatabase) FindSessions(filter SessionFilter) ([]PlayerSession, error) {
+	query := `
+		SELECT id, player_name, account_id, port, match_id, joined_at, left_at,
+			samples, ping_min, ping_avg, ping_p95, ping_max, spikes
+		FROM player_sessions`
+
+	var arg interface{}
+	if filter.PlayerName != "" {
+		query += " WHERE player_name = ?"
+		arg = filter.PlayerName
+	} else {
+		query += " WHERE account_id = ?"
+		arg = filter.AccountID
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	query += " ORDER BY joined_at DESC, id DESC LIMIT ?"
+
+	rows, err := sdb.db.Query(query, arg, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []PlayerSession
+	for rows.Next() {
+		var s PlayerSession
+		var leftAt sql.NullTime
+		err := rows.Scan(&s.ID, &s.PlayerName, &s.AccountID, &s.Port, &s.MatchID, &s.JoinedAt, &leftAt,
+			&s.Samples, &s.PingMin, &s.PingAvg, &s.PingP95, &s.PingMax, &s.Spikes)
+		if err != nil {
+			continue
+		}
+		if leftAt.Valid {
+			s.LeftAt = &leftAt.Time
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+// Close closes the database.
+func (sdb *SessionDatabase) Close() error {
+	return sdb.db.Close()
+}
@@ -1,6 +1,8 @@
 // Package events defines event types and enumerations for the Energizer event system.
 package events
 
+import "time"
+
 // EventType represents the type of event emitted through the EventBus.
 type EventType string
 
@@ -18,6 +20,7 @@ const (
 	// Connection events
 	EventServerAnnounce      EventType = "server_announce"
 	EventServerClosed        EventType = "server_closed"
+	EventServerCrashed       EventType = "server_crashed"
 	EventServerStatus        EventType = "server_status"
 	EventLongFrame           EventType = "long_frame"
 	EventLobbyCreated        EventType = "lobby_created"
@@ -196,6 +199,17 @@ type ServerStatusPayload struct {
 	PlayerPings map[string]uint16
 }
 
+// ServerCrashedPayload describes an unexpected exit of a game server process.
+// Signal is empty when the process exited normally or the platform cannot
+// report it (Windows).
+type ServerCrashedPayload struct {
+	Port     uint16
+	PID      int
+	ExitCode int
+	Signal   string
+	Uptime   time.Duration
+}
+
 // LobbyCreatedPayload contains data from a lobby created packet (0x44).
 type LobbyCreatedPayload struct {
 	Port    uint16
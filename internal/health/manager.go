@@ -6,6 +6,7 @@ package health
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -24,6 +25,11 @@ type Manager struct {
 	eventBus  *events.EventBus
 	serverMgr *server.Manager
 	masterSvr *connector.MasterServerConnector
+
+	// Running check loops, restarted when the timers change
+	mu         sync.Mutex
+	timers     config.TimerConfig
+	stopChecks context.CancelFunc
 }
 
 // NewManager creates a new health check manager.
@@ -43,7 +49,47 @@ func NewManager(
 
 // Start launches all health check goroutines.
 func (m *Manager) Start(ctx context.Context) {
-	timers := m.cfg.ApplicationData.Timers
+	m.eventBus.Subscribe(events.EventConfigChanged, "health.configChanged", func(_ context.Context, _ events.Event) error {
+		m.reloadTimers(ctx)
+		return nil
+	})
+
+	checks := m.startChecks(ctx, true)
+	log.Info().Int("checks", checks).Msg("health check manager started")
+
+	// Block until context is cancelled
+	<-ctx.Done()
+	log.Info().Msg("health check manager stopped")
+}
+
+// reloadTimers restarts the check loops if any interval has changed.
+func (m *Manager) reloadTimers(ctx context.Context) {
+	m.mu.Lock()
+	changed := m.timers != m.cfg.GetApplicationData().Timers
+	m.mu.Unlock()
+
+	if !changed || ctx.Err() != nil {
+		return
+	}
+	checks := m.startChecks(ctx, false)
+	log.Info().Int("checks", checks).Msg("health check timers changed, checks rescheduled")
+}
+
+// startChecks stops the running check loops and launches a goroutine with its
+// own ticker for each enabled check, returning how many were started. With
+// initial set every check also runs once right away.
+func (m *Manager) startChecks(parent context.Context, initial bool) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopChecks != nil {
+		m.stopChecks()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	m.stopChecks = cancel
+
+	timers := m.cfg.GetApplicationData().Timers
+	m.timers = timers
 
 	// Launch each health check as a separate goroutine with its own ticker
 	checks := []struct {
@@ -59,12 +105,15 @@ func (m *Manager) Start(ctx context.Context) {
 		{"lag_health", timers.LagCheckInterval, m.checkLagHealth},
 		{"autoping_listener", timers.AutopingCheckInterval, m.checkAutoPingListener},
 		{"stats_polling", timers.StatsPollingInterval, m.pollGameStats},
+		{"idle_players", timers.IdlePlayerCheckInterval, m.checkIdlePlayers},
 	}
 
+	started := 0
 	for _, check := range checks {
 		if check.interval <= 0 {
 			continue
 		}
+		started++
 
 		check := check
 		go func() {
@@ -72,8 +121,10 @@ func (m *Manager) Start(ctx context.Context) {
 			defer ticker.Stop()
 
 			// Run immediately on startup
-			log.Debug().Str("check", check.name).Msg("running initial health check")
-			check.fn(ctx)
+			if initial {
+				log.Debug().Str("check", check.name).Msg("running initial health check")
+				check.fn(ctx)
+			}
 
 			for {
 				select {
@@ -87,13 +138,11 @@ func (m *Manager) Start(ctx context.Context) {
 	}
 
 	// Heartbeat (special: publishes MQTT status)
-	go m.heartbeatLoop(ctx, time.Duration(timers.HeartbeatInterval)*time.Second)
-
-	log.Info().Int("checks", len(checks)).Msg("health check manager started")
+	if timers.HeartbeatInterval > 0 {
+		go m.heartbeatLoop(ctx, time.Duration(timers.HeartbeatInterval)*time.Second)
+	}
 
-	// Block until context is cancelled
-	<-ctx.Done()
-	log.Info().Msg("health check manager stopped")
+	return started
 }
 
 // checkPatchVersion checks for upstream game patches.
@@ -160,6 +209,9 @@ func (m *Manager) checkPublicIP(ctx context.Context) {
 func (m *Manager) checkGeneralHealth(ctx context.Context) {
 	instances := m.serverMgr.GetAllInstances()
 
+	// Restarts run on a background context: ctx is cancelled whenever the
+	// check timers are reloaded, and would take the restarted servers'
+	// proxies down with it
 	for port, inst := range instances {
 		// Check for stuck servers (running but not responding)
 		if inst.IsRunning() {
@@ -170,14 +222,14 @@ func (m *Manager) checkGeneralHealth(ctx context.Context) {
 			if status == events.GameStatusStarting {
 				if time.Since(state.StatusChangedAt) > 2*time.Minute {
 					log.Warn().Uint16("port", port).Msg("server stuck in STARTING state, restarting")
-					go inst.Restart(ctx)
+					go inst.Restart(server.WithCause(context.Background(), server.HealthCheckCause("general_health")))
 				}
 			}
 
 			// Check for periodic restart
 			if inst.NeedsRestart() {
 				log.Info().Uint16("port", port).Msg("scheduled periodic restart")
-				go inst.Restart(ctx)
+				go inst.Restart(server.WithCause(context.Background(), server.HealthCheckCause("restart_policy")))
 			}
 		}
 	}
@@ -268,6 +320,16 @@ func (m *Manager) checkLagHealth(ctx context.Context) {
 	}
 }
 
+// checkIdlePlayers applies the idle/AFK kick policy on every running server.
+func (m *Manager) checkIdlePlayers(ctx context.Context) {
+	registry := m.serverMgr.GetConnectionRegistry()
+	for _, inst := range m.serverMgr.GetAllInstances() {
+		if inst.IsRunning() {
+			inst.CheckIdlePlayers(registry)
+		}
+	}
+}
+
 // checkAutoPingListener validates the UDP auto-ping listener.
 func (m *Manager) checkAutoPingListener(ctx context.Context) {
 	// Self-test would be implemented here
@@ -30,6 +30,9 @@ type Connection struct {
 
 	// State
 	closed bool
+
+	// Traffic capture, nil unless enabled
+	recorder *Recorder
 }
 
 // NewConnection wraps an existing net.Conn.
@@ -54,6 +57,14 @@ func (c *Connection) SetPort(port uint16) {
 		Logger()
 }
 
+// SetRecorder records the packets read and written from now on, under the
+// connection's port.
+func (c *Connection) SetRecorder(recorder *Recorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recorder = recorder
+}
+
 // Port returns the associated game server port.
 func (c *Connection) Port() uint16 {
 	c.mu.Lock()
@@ -75,6 +86,7 @@ func (c *Connection) ReadPacket(timeout time.Duration) ([]byte, error) {
 
 	c.mu.Lock()
 	c.lastActivity = time.Now()
+	c.recorder.Record(c.port, protocol.DirectionIn, data)
 	c.mu.Unlock()
 
 	return data, nil
@@ -96,6 +108,7 @@ func (c *Connection) WritePacket(data []byte) error {
 	}
 
 	c.lastActivity = time.Now()
+	c.recorder.Record(c.port, protocol.DirectionOut, data)
 	return nil
 }
 
@@ -257,14 +270,23 @@ func (r *ConnectionRegistry) CleanStale(timeout time.Duration) int {
 	return cleaned
 }
 
-// SendToAll sends a packet to all connected game servers.
-func (r *ConnectionRegistry) SendToAll(ctx context.Context, data []byte) {
+// SendToAll sends a packet to all connected game servers. If include is not
+// nil, only servers for whose port it returns true receive the packet.
+// Returns the number of servers the packet was sent to.
+func (r *ConnectionRegistry) SendToAll(ctx context.Context, data []byte, include func(port uint16) bool) int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	sent := 0
 	for port, conn := range r.conns {
+		if include != nil && !include(port) {
+			continue
+		}
 		if err := conn.WritePacket(data); err != nil {
 			log.Warn().Err(err).Uint16("port", port).Msg("failed to send to server")
+			continue
 		}
+		sent++
 	}
+	return sent
 }
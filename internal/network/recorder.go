@@ -0,0 +1,121 @@
+package network
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/protocol"
+	"github.com/energizer-project/energizer/internal/util"
+)
+
+// captureFileName is the capture file in the capture directory.
+const captureFileName = "manager.cap"
+
+// recordQueueSize is the number of packets that can wait for the writer
+// before further packets are dropped.
+const recordQueueSize = 4096
+
+// Recorder writes every packet on the manager port to a capture file (see
+// protocol.AppendCaptureRecord). Packets are queued and written by a
+// background goroutine, so recording never blocks the connection on disk
+// I/O. A nil Recorder records nothing.
+type Recorder struct {
+	file    *util.RotatingFile
+	records chan protocol.CaptureRecord
+	done    chan struct{} // Closed once the writer has drained the queue
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int // Packets dropped because the queue was full; logged once
+}
+
+// NewRecorder opens the capture file in cfg.Directory for appending and
+// starts its writer.
+func NewRecorder(cfg config.CaptureConfig) (*Recorder, error) {
+	path := filepath.Join(cfg.Directory, captureFileName)
+	file, err := util.NewRotatingFile(path, cfg.MaxSizeMB, cfg.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("file", path).Msg("recording manager port traffic")
+
+	r := &Recorder{
+		file:    file,
+		records: make(chan protocol.CaptureRecord, recordQueueSize),
+		done:    make(chan struct{}),
+	}
+	go r.write()
+	return r, nil
+}
+
+// Record queues one packet sent to or received from the game server on port.
+// If the writer has fallen behind, the packet is dropped.
+func (r *Recorder) Record(port uint16, dir protocol.Direction, data []byte) {
+	if r == nil {
+		return
+	}
+
+	record := protocol.CaptureRecord{
+		Time:      time.Now(),
+		Port:      port,
+		Direction: dir,
+		Data:      append([]byte(nil), data...), // The caller may reuse data
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	select {
+	case r.records <- record:
+	default:
+		r.dropped++
+		if r.dropped == 1 {
+			log.Warn().Msg("traffic capture cannot keep up, dropping packets; further drops are not logged")
+		}
+	}
+}
+
+// write appends queued packets to the capture file until the queue is closed.
+func (r *Recorder) write() {
+	defer close(r.done)
+
+	var buf []byte
+	failed := false // A write failed; logged once
+	for record := range r.records {
+		buf = protocol.AppendCaptureRecord(buf[:0], record)
+		if _, err := r.file.Write(buf); err != nil && !failed {
+			failed = true
+			log.Error().Err(err).Msg("failed to write traffic capture, further errors are not logged")
+		}
+	}
+}
+
+// Close writes the packets still queued and closes the capture file.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.records)
+	dropped := r.dropped
+	r.mu.Unlock()
+
+	<-r.done
+	if dropped > 0 {
+		log.Warn().Int("dropped", dropped).Msg("traffic capture dropped packets")
+	}
+	return r.file.Close()
+}
@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"time"
@@ -33,17 +34,41 @@ type TCPListener struct {
 	eventBus *events.EventBus
 	manager  ServerManagerInterface
 	parser   *protocol.GameManagerParser
+	unknown  *protocol.UnknownPackets
+	recorder *Recorder // nil unless capture is enabled
 	listener net.Listener
 }
 
 // NewTCPListener creates a new TCP listener.
 func NewTCPListener(cfg *config.Config, eventBus *events.EventBus, manager ServerManagerInterface) *TCPListener {
-	return &TCPListener{
+	l := &TCPListener{
 		cfg:      cfg,
 		eventBus: eventBus,
 		manager:  manager,
 		parser:   protocol.NewGameManagerParser(),
+		unknown:  protocol.NewUnknownPackets(),
 	}
+
+	if capture := cfg.GetApplicationData().Capture; capture.Enabled {
+		recorder, err := NewRecorder(capture)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to open traffic capture, not recording")
+		} else {
+			l.recorder = recorder
+		}
+	}
+	return l
+}
+
+// Codecs returns the registry of packet codecs used to parse game server
+// packets, so new packet types can be registered.
+func (l *TCPListener) Codecs() *protocol.CodecRegistry {
+	return l.parser.Codecs()
+}
+
+// UnknownPackets returns the packets received without a codec.
+func (l *TCPListener) UnknownPackets() *protocol.UnknownPackets {
+	return l.unknown
 }
 
 // Start begins listening for game server TCP connections.
@@ -66,6 +91,7 @@ func (l *TCPListener) Start(ctx context.Context) error {
 		<-ctx.Done()
 		l.listener.Close()
 	}()
+	defer l.recorder.Close()
 
 	for {
 		conn, err := l.listener.Accept()
@@ -133,6 +159,10 @@ func (l *TCPListener) handleConnection(ctx context.Context, rawConn net.Conn) {
 	port := announce.Port
 	conn.SetPort(port)
 
+	// The handshake was read before the port was known
+	l.recorder.Record(port, protocol.DirectionIn, data)
+	conn.SetRecorder(l.recorder)
+
 	logger = log.With().
 		Str("component", "tcp_handler").
 		Uint16("port", port).
@@ -179,6 +209,10 @@ func (l *TCPListener) handleConnection(ctx context.Context, rawConn net.Conn) {
 
 		// Parse the packet
 		event, err := l.parser.Parse(data)
+		if errors.Is(err, protocol.ErrUnknownCommand) {
+			l.unknown.Capture(port, data)
+			continue
+		}
 		if err != nil {
 			logger.Warn().Err(err).Msg("failed to parse packet")
 			continue
@@ -0,0 +1,325 @@
+package patcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/connector"
+)
+
+// patchesDir holds one directory per game version: "staging" with the
+// downloaded files and, once applied, "backup" with the files they replaced.
+const patchesDir = "patches"
+
+// stagedPatch is a game version downloaded and verified in its staging
+// directory, holding only the files that differ from the install.
+type stagedPatch struct {
+	version string
+	dir     string
+	files   []connector.PatchFile
+	bytes   int64
+}
+
+// appliedPatch records what applying a patch changed in the install, so the
+// previous files can be put back.
+type appliedPatch struct {
+	installDir string
+	backupDir  string
+	replaced   []string // Moved to backupDir
+	added      []string // Did not exist before
+}
+
+// stagingDir returns where the files of version are downloaded to.
+func stagingDir(version string) string {
+	return filepath.Join(patchesDir, version, "staging")
+}
+
+// backupDir returns where the files replaced by version are kept.
+func backupDir(version string) string {
+	return filepath.Join(patchesDir, version, "backup")
+}
+
+// localPath converts a manifest path to a path inside dir, rejecting paths
+// that would escape it.
+func localPath(dir, manifestPath string) (string, error) {
+	rel := filepath.FromSlash(manifestPath)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("patch manifest path %q is outside the install directory", manifestPath)
+	}
+	return filepath.Join(dir, rel), nil
+}
+
+// fileMatches reports whether the file at path has the given size and
+// SHA-256 checksum. A missing file does not match.
+func fileMatches(path string, size int64, checksum string) (bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if info.Size() != size {
+		return false, nil
+	}
+
+	sum, err := fileChecksum(path)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(sum, checksum), nil
+}
+
+// fileChecksum returns the SHA-256 of a file, hex-encoded.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stage downloads the files of manifest that differ from installDir into the
+// version's staging directory and verifies each against its checksum. Files
+// already staged by an earlier attempt are not downloaded again. progress is
+// called with the bytes staged so far.
+func stage(ctx context.Context, src *connector.MasterServerConnector, manifest *connector.PatchManifest,
+	version, installDir string, progress func(staged, total int64)) (*stagedPatch, error) {
+
+	patch := &stagedPatch{version: version, dir: stagingDir(version)}
+
+	for _, file := range manifest.Files {
+		target, err := localPath(installDir, file.Path)
+		if err != nil {
+			return nil, err
+		}
+		same, err := fileMatches(target, file.Size, file.Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", file.Path, err)
+		}
+		if !same {
+			patch.files = append(patch.files, file)
+			patch.bytes += file.Size
+		}
+	}
+
+	var staged int64
+	progress(staged, patch.bytes)
+	for _, file := range patch.files {
+		path, _ := localPath(patch.dir, file.Path)
+		done, err := fileMatches(path, file.Size, file.Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check staged %s: %w", file.Path, err)
+		}
+		if !done {
+			if err := download(ctx, src, version, file, path); err != nil {
+				return nil, err
+			}
+		}
+		staged += file.Size
+		progress(staged, patch.bytes)
+	}
+
+	log.Info().
+		Str("version", version).
+		Int("files", len(patch.files)).
+		Int("unchanged", len(manifest.Files)-len(patch.files)).
+		Int64("bytes", patch.bytes).
+		Msg("game patch staged and verified")
+	return patch, nil
+}
+
+// download fetches one file to path, verifying its size and checksum before
+// it is moved into place.
+func download(ctx context.Context, src *connector.MasterServerConnector, version string, file connector.PatchFile, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	err = src.DownloadPatchFile(ctx, version, file.Path, io.MultiWriter(f, h))
+	f.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if info, err := os.Stat(tmp); err != nil || info.Size() != file.Size {
+		os.Remove(tmp)
+		return fmt.Errorf("%s: size mismatch, expected %d bytes", file.Path, file.Size)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, file.Checksum) {
+		os.Remove(tmp)
+		return fmt.Errorf("%s: checksum mismatch, expected %s, got %s", file.Path, file.Checksum, sum)
+	}
+	return os.Rename(tmp, path)
+}
+
+// verify checks the staged files again, right before they are applied.
+func (p *stagedPatch) verify() error {
+	for _, file := range p.files {
+		path, _ := localPath(p.dir, file.Path)
+		ok, err := fileMatches(path, file.Size, file.Checksum)
+		if err != nil {
+			return fmt.Errorf("failed to verify staged %s: %w", file.Path, err)
+		}
+		if !ok {
+			return fmt.Errorf("staged %s no longer matches its checksum", file.Path)
+		}
+	}
+	return nil
+}
+
+// apply copies the staged files into installDir. Each file it replaces is
+// moved to the backup directory first and passes its permissions on to the
+// new file; new files are made executable if they are the server executable.
+// If a file cannot be applied, the files applied so far are restored.
+func (p *stagedPatch) apply(installDir, executable string) (*appliedPatch, error) {
+	applied := &appliedPatch{installDir: installDir, backupDir: backupDir(p.version)}
+	if err := os.RemoveAll(applied.backupDir); err != nil {
+		return nil, err
+	}
+
+	for _, file := range p.files {
+		if err := applied.applyFile(p.dir, file.Path, executable); err != nil {
+			if restoreErr := applied.restore(); restoreErr != nil {
+				log.Error().Err(restoreErr).Msg("failed to restore install after a failed patch")
+			}
+			return nil, fmt.Errorf("failed to apply %s: %w", file.Path, err)
+		}
+	}
+	return applied, nil
+}
+
+func (a *appliedPatch) applyFile(stageDir, manifestPath, executable string) error {
+	staged, _ := localPath(stageDir, manifestPath)
+	target, _ := localPath(a.installDir, manifestPath)
+	backup, _ := localPath(a.backupDir, manifestPath)
+
+	mode := os.FileMode(0644)
+	if filepath.Base(target) == executable {
+		mode = 0755
+	}
+
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+		if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+			return err
+		}
+		if err := moveFile(target, backup); err != nil {
+			return err
+		}
+		a.replaced = append(a.replaced, manifestPath)
+	} else if errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		a.added = append(a.added, manifestPath)
+	} else {
+		return err
+	}
+
+	return copyFile(staged, target, mode)
+}
+
+// restore puts the install back the way it was before apply.
+func (a *appliedPatch) restore() error {
+	var errs []error
+	for _, manifestPath := range a.added {
+		target, _ := localPath(a.installDir, manifestPath)
+		if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	for _, manifestPath := range a.replaced {
+		target, _ := localPath(a.installDir, manifestPath)
+		backup, _ := localPath(a.backupDir, manifestPath)
+		if err := moveFile(backup, target); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// moveFile renames src to dst, copying if they are on different file systems.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := copyFile(src, dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies src to dst, replacing dst, with the given permissions.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".part"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// prunePatches removes the directories of every version except keep.
+func prunePatches(keep ...string) {
+	entries, err := os.ReadDir(patchesDir)
+	if err != nil {
+		return
+	}
+	kept := make(map[string]bool, len(keep))
+	for _, version := range keep {
+		kept[version] = true
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !kept[entry.Name()] {
+			if err := os.RemoveAll(filepath.Join(patchesDir, entry.Name())); err != nil {
+				log.Warn().Err(err).Str("version", entry.Name()).Msg("failed to remove old patch files")
+			}
+		}
+	}
+}
@@ -0,0 +1,416 @@
+// Package patcher applies game patches to the HoN install. It reacts to
+// EventPatchServer (emitted by the patch_version health check and by "patch"
+// maintenance windows): the new version's files are downloaded from the
+// master server's patcher into a staging directory and verified, then
+// applied while the fleet is drained. The files they replace are kept, and
+// put back if the patched servers do not reach READY.
+package patcher
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/connector"
+	"github.com/energizer-project/energizer/internal/events"
+	"github.com/energizer-project/energizer/internal/server"
+)
+
+// Patch states.
+const (
+	StateIdle        = "idle"        // No patch found yet
+	StateDownloading = "downloading" // Downloading and verifying into staging
+	StateStaged      = "staged"      // Verified, waiting for a patch window (apply_on_detect off)
+	StateDraining    = "draining"    // Waiting for servers to finish their matches
+	StateApplying    = "applying"    // Copying files into the install
+	StateVerifying   = "verifying"   // Waiting for patched servers to report READY
+	StateApplied     = "applied"     // Patched servers are READY
+	StateRolledBack  = "rolled_back" // Servers failed on the patch, previous files restored
+	StateFailed      = "failed"      // Download, verification, or apply failed; install unchanged
+)
+
+// Status is the state of the latest patch, for the API.
+type Status struct {
+	State           string    `json:"state"`
+	Version         string    `json:"version,omitempty"`          // Version being patched to
+	PreviousVersion string    `json:"previous_version,omitempty"` // Version it replaces
+	Reason          string    `json:"reason,omitempty"`           // What found the patch
+	Files           int       `json:"files"`                      // Files that differ from the install
+	Bytes           int64     `json:"bytes"`
+	Downloaded      int64     `json:"downloaded"`
+	NotReady        []uint16  `json:"not_ready,omitempty"` // Servers that did not reach READY on the patch
+	Error           string    `json:"error,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Patcher downloads, applies, and if necessary rolls back game patches.
+// One patch runs at a time; patch events that arrive meanwhile are ignored.
+type Patcher struct {
+	mu        sync.Mutex
+	cfg       *config.Config
+	bus       *events.EventBus
+	manager   *server.Manager
+	masterSvr *connector.MasterServerConnector
+	ctx       context.Context // Set by Start, cancels downloads and waits on shutdown
+
+	running       bool
+	status        Status
+	staged        *stagedPatch // Verified, not yet applied
+	failedVersion string       // Rolled back; only retried in a patch window
+	notifiedError string       // Version whose failure the admin was told about
+}
+
+// request is one EventPatchServer.
+type request struct {
+	version string // Empty: ask the master server
+	window  string // Maintenance window that asked for the patch, if any
+	source  string
+}
+
+// NewPatcher creates the patcher and subscribes it to EventPatchServer.
+func NewPatcher(cfg *config.Config, eventBus *events.EventBus, manager *server.Manager,
+	masterSvr *connector.MasterServerConnector) *Patcher {
+
+	p := &Patcher{
+		cfg:       cfg,
+		bus:       eventBus,
+		manager:   manager,
+		masterSvr: masterSvr,
+		ctx:       context.Background(),
+		status:    Status{State: StateIdle, UpdatedAt: time.Now()},
+	}
+	eventBus.Subscribe(events.EventPatchServer, "patcher.patchServer", p.onPatchServer)
+	return p
+}
+
+// Start removes patch files left from versions other than the installed
+// one and keeps the patcher's context until it is cancelled.
+func (p *Patcher) Start(ctx context.Context) {
+	p.mu.Lock()
+	p.ctx = ctx
+	p.mu.Unlock()
+
+	prunePatches(p.cfg.GetHoNData().ServerVersion)
+
+	<-ctx.Done()
+}
+
+// Status returns the state of the latest patch.
+func (p *Patcher) Status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := p.status
+	status.NotReady = append([]uint16(nil), p.status.NotReady...)
+	return status
+}
+
+func (p *Patcher) onPatchServer(_ context.Context, event events.Event) error {
+	payload, _ := event.Payload.(map[string]string)
+	req := request{
+		version: payload["new_version"],
+		window:  payload["window"],
+		source:  event.Source,
+	}
+
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		log.Debug().Str("source", req.source).Msg("game patch already in progress, ignoring patch event")
+		return nil
+	}
+	p.running = true
+	ctx := p.ctx
+	p.mu.Unlock()
+
+	go p.run(ctx, req)
+	return nil
+}
+
+// run handles one patch request from start to finish.
+func (p *Patcher) run(ctx context.Context, req request) {
+	defer func() {
+		p.mu.Lock()
+		p.running = false
+		p.mu.Unlock()
+	}()
+
+	honData := p.cfg.GetHoNData()
+	if honData.CandidateInstallDirectory != "" {
+		log.Warn().Msg("game patch skipped: a blue/green rollout is configured, roll the new version out as the candidate instead")
+		return
+	}
+
+	version := req.version
+	if version == "" {
+		if p.masterSvr == nil || !p.masterSvr.IsAuthenticated() {
+			log.Warn().Msg("game patch requested, but the master server is not connected")
+			return
+		}
+		available, latest, err := p.masterSvr.CompareUpstreamPatch(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("game patch check failed")
+			return
+		}
+		if !available {
+			log.Info().Str("version", honData.ServerVersion).Msg("no game patch available")
+			return
+		}
+		version = latest
+	}
+
+	if version == honData.ServerVersion {
+		return
+	}
+	if !filepath.IsLocal(version) || strings.ContainsAny(version, `/\`) {
+		p.fail(version, req, fmt.Errorf("invalid game version %q", version))
+		return
+	}
+
+	p.mu.Lock()
+	failed := version == p.failedVersion
+	p.mu.Unlock()
+	if failed && req.window == "" {
+		log.Debug().Str("version", version).Msg("game patch was rolled back before, waiting for a patch window to retry")
+		return
+	}
+
+	patch, fresh, err := p.prepare(ctx, version, honData.InstallDirectory, req)
+	if err != nil {
+		p.fail(version, req, err)
+		return
+	}
+
+	if req.window == "" && !p.cfg.GetApplicationData().Patch.ApplyOnDetect {
+		p.setState(StateStaged, nil)
+		if fresh {
+			p.notify("Game Patch Ready", fmt.Sprintf(
+				"Game version %s is downloaded and verified, it will be applied in the next patch maintenance window", version), "info")
+		}
+		return
+	}
+
+	p.apply(ctx, patch, honData, req)
+}
+
+// prepare returns the staged patch for version, downloading it unless it is
+// already staged. fresh reports whether it was downloaded now.
+func (p *Patcher) prepare(ctx context.Context, version, installDir string, req request) (*stagedPatch, bool, error) {
+	p.mu.Lock()
+	if p.staged != nil && p.staged.version == version {
+		patch := p.staged
+		p.status.Reason = reason(req)
+		p.mu.Unlock()
+		return patch, false, nil
+	}
+	p.staged = nil
+	p.status = Status{
+		State:           StateDownloading,
+		Version:         version,
+		PreviousVersion: p.cfg.GetHoNData().ServerVersion,
+		Reason:          reason(req),
+		UpdatedAt:       time.Now(),
+	}
+	p.mu.Unlock()
+
+	if p.masterSvr == nil {
+		return nil, false, fmt.Errorf("master server connector not available")
+	}
+
+	log.Info().Str("version", version).Str("reason", reason(req)).Msg("downloading game patch")
+
+	manifest, err := p.masterSvr.FetchPatchManifest(ctx, version)
+	if err != nil {
+		return nil, false, err
+	}
+	patch, err := stage(ctx, p.masterSvr, manifest, version, installDir, func(staged, total int64) {
+		p.mu.Lock()
+		p.status.Downloaded, p.status.Bytes = staged, total
+		p.mu.Unlock()
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.mu.Lock()
+	p.staged = patch
+	p.status.Files = len(patch.files)
+	p.mu.Unlock()
+
+	// Older staged versions are no longer needed
+	prunePatches(p.cfg.GetHoNData().ServerVersion, version)
+	return patch, true, nil
+}
+
+// apply drains the fleet, installs the patch, and rolls it back if the
+// servers do not come back READY.
+func (p *Patcher) apply(ctx context.Context, patch *stagedPatch, honData config.HoNData, req request) {
+	timeout := time.Duration(p.cfg.GetApplicationData().Patch.ReadyTimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	previous := honData.ServerVersion
+
+	p.setState(StateDraining, nil)
+	log.Info().Str("version", patch.version).Msg("draining servers to apply game patch")
+
+	servers, err := p.manager.HaltForPatch(ctx)
+	if err != nil {
+		p.fail(patch.version, req, err)
+		return
+	}
+
+	p.setState(StateApplying, nil)
+	applied, err := p.install(patch, honData)
+	if err != nil {
+		p.manager.ResumeAfterPatch(ctx, servers, timeout)
+		p.fail(patch.version, req, err)
+		return
+	}
+
+	p.setState(StateVerifying, nil)
+	notReady := p.manager.ResumeAfterPatch(ctx, servers, timeout)
+	if ctx.Err() != nil {
+		return
+	}
+	if len(notReady) == 0 {
+		p.mu.Lock()
+		p.staged = nil
+		p.mu.Unlock()
+		p.setState(StateApplied, nil)
+		prunePatches(patch.version)
+
+		log.Info().Str("version", patch.version).Str("previous", previous).Int("servers", len(servers)).Msg("game patch applied")
+		p.notify("Game Patch Applied", fmt.Sprintf("Game servers updated from %s to %s", previous, patch.version), "info")
+		p.emitConfigChanged()
+		return
+	}
+
+	// Some servers failed on the new files: put the old ones back
+	log.Error().Str("version", patch.version).Interface("ports", notReady).Msg("servers did not reach READY after patching, rolling back")
+	p.mu.Lock()
+	p.status.NotReady = notReady
+	p.mu.Unlock()
+
+	if _, err := p.manager.HaltForPatch(ctx); err != nil {
+		return
+	}
+	if err := applied.restore(); err != nil {
+		log.Error().Err(err).Msg("failed to restore previous game files")
+	}
+	if err := p.manager.SetInstalledVersion(previous); err != nil {
+		log.Warn().Err(err).Msg("failed to restore svr_version after rolling back game patch")
+	}
+	if stillDown := p.manager.ResumeAfterPatch(ctx, servers, timeout); len(stillDown) > 0 {
+		log.Error().Interface("ports", stillDown).Msg("servers did not reach READY after rolling back game patch")
+	}
+
+	p.mu.Lock()
+	p.failedVersion = patch.version
+	p.mu.Unlock()
+	p.setState(StateRolledBack, fmt.Errorf("%d server(s) did not reach READY within %s", len(notReady), timeout))
+	p.notify("Game Patch Rolled Back", fmt.Sprintf(
+		"Game version %s was rolled back to %s: servers %v did not reach READY within %s",
+		patch.version, previous, notReady, timeout), "error")
+	p.emitConfigChanged()
+}
+
+// install verifies the staged files once more, copies them into the install,
+// and records the new version.
+func (p *Patcher) install(patch *stagedPatch, honData config.HoNData) (*appliedPatch, error) {
+	if err := patch.verify(); err != nil {
+		p.mu.Lock()
+		p.staged = nil // Download again next time
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	applied, err := patch.apply(honData.InstallDirectory, honData.ExecutableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.manager.SetInstalledVersion(patch.version); err != nil {
+		log.Warn().Err(err).Msg("game patch applied, but svr_version could not be saved")
+	}
+
+	log.Info().
+		Str("version", patch.version).
+		Int("replaced", len(applied.replaced)).
+		Int("added", len(applied.added)).
+		Msg("game patch files installed")
+	return applied, nil
+}
+
+// fail records a patch that could not be downloaded or applied.
+func (p *Patcher) fail(version string, req request, err error) {
+	if p.ctxDone() {
+		return
+	}
+	log.Error().Err(err).Str("version", version).Msg("game patch failed")
+
+	p.mu.Lock()
+	p.status.Version = version
+	p.status.Reason = reason(req)
+	// The patch check retries on every interval; tell the admin once
+	repeated := p.notifiedError == version
+	p.notifiedError = version
+	p.mu.Unlock()
+	p.setState(StateFailed, err)
+
+	if !repeated {
+		p.notify("Game Patch Failed", fmt.Sprintf("Game version %s could not be applied: %v", version, err), "error")
+	}
+}
+
+func (p *Patcher) ctxDone() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ctx.Err() != nil
+}
+
+func (p *Patcher) setState(state string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.State = state
+	p.status.Error = ""
+	if err != nil {
+		p.status.Error = err.Error()
+	}
+	p.status.UpdatedAt = time.Now()
+}
+
+func (p *Patcher) notify(title, message, level string) {
+	p.bus.Emit(context.Background(), events.Event{
+		Type:   events.EventNotifyDiscordAdmin,
+		Source: "patcher",
+		Payload: events.NotifyDiscordPayload{
+			Title:   title,
+			Message: message,
+			Level:   level,
+		},
+	})
+}
+
+// emitConfigChanged lets other subsystems pick up the new svr_version.
+func (p *Patcher) emitConfigChanged() {
+	p.bus.Emit(context.Background(), events.Event{
+		Type:    events.EventConfigChanged,
+		Source:  "patcher",
+		Payload: events.ConfigChangedPayload{Section: "hon_data"},
+	})
+}
+
+// reason describes what asked for a patch.
+func reason(req request) string {
+	if req.window != "" {
+		return "maintenance window " + req.window
+	}
+	return req.source
+}
@@ -151,6 +151,127 @@ func BuildManagerMessage(message string) []byte {
 	return b.Build()
 }
 
+// BuildServerAnnounce creates a server hello packet (0x40), the first packet
+// a game server sends after connecting to the manager.
+// Format: [cmd:1][port:2]
+func BuildServerAnnounce(port uint16) []byte {
+	b := NewPacketBuilder()
+	b.WriteByte(PktServerAnnounce)
+	b.WriteUint16(port)
+	return b.Build()
+}
+
+// BuildServerClosed creates a server shutdown packet (0x41).
+// Format: [cmd:1][port:2]
+func BuildServerClosed(port uint16) []byte {
+	b := NewPacketBuilder()
+	b.WriteByte(PktServerClosed)
+	b.WriteUint16(port)
+	return b.Build()
+}
+
+// PlayerPing is one player's entry in a server status packet.
+type PlayerPing struct {
+	Name string
+	Ping uint16
+}
+
+// BuildServerStatus creates a server telemetry packet (0x42).
+// Format: [cmd:1][port:2][uptime:4][cpu:4][player_count:1][game_phase:1][match_id:4]
+//
+//	[per-player pings: player_count * (name_len:1, name:var, ping:2)]
+func BuildServerStatus(port uint16, uptime uint32, cpu float32, phase uint8, matchID uint32, players []PlayerPing) []byte {
+	if len(players) > 255 {
+		players = players[:255]
+	}
+	b := NewPacketBuilder()
+	b.WriteByte(PktServerStatus)
+	b.WriteUint16(port)
+	b.WriteUint32(uptime)
+	b.WriteFloat32(cpu)
+	b.WriteByte(byte(len(players)))
+	b.WriteByte(phase)
+	b.WriteUint32(matchID)
+	for _, p := range players {
+		b.WriteString(p.Name)
+		b.WriteUint16(p.Ping)
+	}
+	return b.Build()
+}
+
+// BuildLongFrame creates a long frame packet (0x43).
+// Format: [cmd:1][port:2][duration_ms:4]
+func BuildLongFrame(port uint16, durationMS uint32) []byte {
+	b := NewPacketBuilder()
+	b.WriteByte(PktLongFrame)
+	b.WriteUint16(port)
+	b.WriteUint32(durationMS)
+	return b.Build()
+}
+
+// BuildLobbyCreated creates a lobby created packet (0x44).
+// Format: [cmd:1][port:2][match_id:4][map:str][mode:str]
+func BuildLobbyCreated(port uint16, matchID uint32, mapName, mode string) []byte {
+	b := NewPacketBuilder()
+	b.WriteByte(PktLobbyCreated)
+	b.WriteUint16(port)
+	b.WriteUint32(matchID)
+	b.WriteString(mapName)
+	b.WriteString(mode)
+	return b.Build()
+}
+
+// BuildLobbyClosed creates a lobby closed packet (0x45).
+// Format: [cmd:1][port:2]
+func BuildLobbyClosed(port uint16) []byte {
+	b := NewPacketBuilder()
+	b.WriteByte(PktLobbyClosed)
+	b.WriteUint16(port)
+	return b.Build()
+}
+
+// BuildPlayerConnection creates a player connect/disconnect packet (0x47).
+// Format: [cmd:1][port:2][name:str][player_id:4][connected:1]
+func BuildPlayerConnection(port uint16, name string, playerID uint32, connected bool) []byte {
+	b := NewPacketBuilder()
+	b.WriteByte(PktPlayerConnection)
+	b.WriteUint16(port)
+	b.WriteString(name)
+	b.WriteUint32(playerID)
+	if connected {
+		b.WriteByte(1)
+	} else {
+		b.WriteByte(0)
+	}
+	return b.Build()
+}
+
+// BuildReplayStatus creates a replay status packet (0x4A).
+// Format: [cmd:1][port:2][match_id:4][status:1]
+func BuildReplayStatus(port uint16, matchID uint32, status byte) []byte {
+	b := NewPacketBuilder()
+	b.WriteByte(PktReplayStatus)
+	b.WriteUint16(port)
+	b.WriteUint32(matchID)
+	b.WriteByte(status)
+	return b.Build()
+}
+
+// BuildCowMasterResponse creates a CowMaster fork response packet (0x49).
+// Format: [cmd:1][port:2][success:1][pid:4]
+func BuildCowMasterResponse(port uint16, success bool, pid int32) []byte {
+	b := NewPacketBuilder()
+	b.WriteByte(PktCowMasterResponse)
+	b.WriteUint16(port)
+	if success {
+		b.WriteByte(1)
+	} else {
+		b.WriteByte(0)
+	}
+	b.WriteInt32(pid)
+	return b.Build()
+}
+
 // BuildAutoPingResponse creates a UDP auto-ping response.
 // Format: [magic:1][server_name:null_str][version:null_str]
 func BuildAutoPingResponse(serverName, version string) []byte {
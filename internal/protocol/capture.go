@@ -0,0 +1,97 @@
+package protocol
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Direction tells which way a captured packet went on the manager port.
+type Direction byte
+
+const (
+	DirectionIn  Direction = 0 // Game server to manager
+	DirectionOut Direction = 1 // Manager to game server
+)
+
+func (d Direction) String() string {
+	if d == DirectionOut {
+		return "out"
+	}
+	return "in"
+}
+
+// captureMagic starts every record of a capture file.
+const captureMagic byte = 0xEC
+
+// captureHeaderSize is the size of a record before its packet data.
+const captureHeaderSize = 14
+
+// CaptureRecord is one raw packet of a manager-port capture.
+type CaptureRecord struct {
+	Time      time.Time
+	Port      uint16
+	Direction Direction
+	Data      []byte // Packet without its length prefix, command byte first
+}
+
+// AppendCaptureRecord encodes rec and appends it to buf. Records carry their
+// own timestamp and need no file header, so a capture file can be appended
+// to, rotated, or concatenated freely.
+// Format: [magic:1][unix_nano:8][port:2][direction:1][length:2][data...]
+func AppendCaptureRecord(buf []byte, rec CaptureRecord) []byte {
+	var header [captureHeaderSize]byte
+	header[0] = captureMagic
+	binary.LittleEndian.PutUint64(header[1:9], uint64(rec.Time.UnixNano()))
+	binary.LittleEndian.PutUint16(header[9:11], rec.Port)
+	header[11] = byte(rec.Direction)
+	binary.LittleEndian.PutUint16(header[12:14], uint16(len(rec.Data)))
+	buf = append(buf, header[:]...)
+	return append(buf, rec.Data...)
+}
+
+// CaptureReader reads the records of a capture file in order.
+type CaptureReader struct {
+	r      *bufio.Reader
+	offset int64
+}
+
+// NewCaptureReader creates a reader for a capture file.
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record, or io.EOF at the end of the capture. A
+// record cut off by the end of the file (e.g. a crash while writing) also
+// ends the capture with io.EOF.
+func (c *CaptureReader) Next() (CaptureRecord, error) {
+	var header [captureHeaderSize]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = io.EOF
+		}
+		return CaptureRecord{}, err
+	}
+	if header[0] != captureMagic {
+		return CaptureRecord{}, fmt.Errorf("corrupt capture at offset %d: bad record marker 0x%02X", c.offset, header[0])
+	}
+
+	rec := CaptureRecord{
+		Time:      time.Unix(0, int64(binary.LittleEndian.Uint64(header[1:9]))),
+		Port:      binary.LittleEndian.Uint16(header[9:11]),
+		Direction: Direction(header[11]),
+		Data:      make([]byte, binary.LittleEndian.Uint16(header[12:14])),
+	}
+	if _, err := io.ReadFull(c.r, rec.Data); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = io.EOF
+		}
+		return CaptureRecord{}, err
+	}
+
+	c.offset += int64(captureHeaderSize + len(rec.Data))
+	return rec, nil
+}
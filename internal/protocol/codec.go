@@ -0,0 +1,185 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+// ErrUnknownCommand is returned for a packet whose command byte has no codec.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// PacketCodec decodes and encodes one game-manager packet type.
+type PacketCodec struct {
+	Command byte
+	Name    string // e.g. "server_status", for logs
+
+	// Decode parses the packet payload (after the command byte) into an event.
+	Decode func(r *bytes.Reader) (*events.Event, error)
+
+	// Encode builds the packet, command byte included, from an event payload
+	// of the type Decode produces.
+	Encode func(payload interface{}) ([]byte, error)
+}
+
+// CodecRegistry holds the codecs of the game-manager protocol, keyed by
+// command byte. It is safe for concurrent use.
+type CodecRegistry struct {
+	mu     sync.RWMutex
+	codecs map[byte]PacketCodec
+}
+
+// NewCodecRegistry creates an empty registry.
+func NewCodecRegistry() *CodecRegistry {
+	return &CodecRegistry{codecs: make(map[byte]PacketCodec)}
+}
+
+// Register adds a codec. Each command byte can only be registered once.
+func (r *CodecRegistry) Register(codec PacketCodec) error {
+	if codec.Decode == nil || codec.Encode == nil {
+		return fmt.Errorf("codec 0x%02X (%s) needs both Decode and Encode", codec.Command, codec.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.codecs[codec.Command]; ok {
+		return fmt.Errorf("command 0x%02X is already registered as %s", codec.Command, existing.Name)
+	}
+	r.codecs[codec.Command] = codec
+	return nil
+}
+
+// Lookup returns the codec for a command byte.
+func (r *CodecRegistry) Lookup(cmd byte) (PacketCodec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	codec, ok := r.codecs[cmd]
+	return codec, ok
+}
+
+// Codecs returns the registered codecs, ordered by command byte.
+func (r *CodecRegistry) Codecs() []PacketCodec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]PacketCodec, 0, len(r.codecs))
+	for _, codec := range r.codecs {
+		out = append(out, codec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Command < out[j].Command })
+	return out
+}
+
+// Decode parses a raw packet (command byte first) with the codec for its
+// command. Packets without a codec return an error wrapping ErrUnknownCommand.
+func (r *CodecRegistry) Decode(data []byte) (*events.Event, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("empty packet")
+	}
+
+	codec, ok := r.Lookup(data[0])
+	if !ok {
+		return nil, fmt.Errorf("%w: 0x%02X", ErrUnknownCommand, data[0])
+	}
+	return codec.Decode(bytes.NewReader(data[1:]))
+}
+
+// Encode builds a packet for cmd from an event payload.
+func (r *CodecRegistry) Encode(cmd byte, payload interface{}) ([]byte, error) {
+	codec, ok := r.Lookup(cmd)
+	if !ok {
+		return nil, fmt.Errorf("%w: 0x%02X", ErrUnknownCommand, cmd)
+	}
+	return codec.Encode(payload)
+}
+
+// ---- Encoders of the built-in packets ----
+
+// payloadError reports an event payload that does not fit the packet.
+func payloadError(cmd byte, payload interface{}) error {
+	return fmt.Errorf("cannot encode %T as packet 0x%02X", payload, cmd)
+}
+
+func encodeServerAnnounce(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.ServerAnnouncePayload)
+	if !ok {
+		return nil, payloadError(PktServerAnnounce, payload)
+	}
+	return BuildServerAnnounce(p.Port), nil
+}
+
+func encodeServerClosed(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.ServerAnnouncePayload)
+	if !ok {
+		return nil, payloadError(PktServerClosed, payload)
+	}
+	return BuildServerClosed(p.Port), nil
+}
+
+// encodeServerStatus writes the player pings sorted by name, since the
+// payload keeps them in a map.
+func encodeServerStatus(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.ServerStatusPayload)
+	if !ok {
+		return nil, payloadError(PktServerStatus, payload)
+	}
+	players := make([]PlayerPing, 0, len(p.PlayerPings))
+	for name, ping := range p.PlayerPings {
+		players = append(players, PlayerPing{Name: name, Ping: ping})
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+	return BuildServerStatus(p.Port, p.Uptime, p.CPUUsage, uint8(p.GamePhase), p.MatchID, players), nil
+}
+
+func encodeLongFrame(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.LongFramePayload)
+	if !ok {
+		return nil, payloadError(PktLongFrame, payload)
+	}
+	return BuildLongFrame(p.Port, p.FrameDuration), nil
+}
+
+func encodeLobbyCreated(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.LobbyCreatedPayload)
+	if !ok {
+		return nil, payloadError(PktLobbyCreated, payload)
+	}
+	return BuildLobbyCreated(p.Port, p.MatchID, p.MapName, p.Mode), nil
+}
+
+func encodeLobbyClosed(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.ServerAnnouncePayload)
+	if !ok {
+		return nil, payloadError(PktLobbyClosed, payload)
+	}
+	return BuildLobbyClosed(p.Port), nil
+}
+
+func encodePlayerConnection(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.PlayerConnectionPayload)
+	if !ok {
+		return nil, payloadError(PktPlayerConnection, payload)
+	}
+	return BuildPlayerConnection(p.Port, p.PlayerName, p.PlayerID, p.Connected), nil
+}
+
+func encodeCowMasterResponse(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.CowMasterResponsePayload)
+	if !ok {
+		return nil, payloadError(PktCowMasterResponse, payload)
+	}
+	return BuildCowMasterResponse(p.Port, p.Success, int32(p.PID)), nil
+}
+
+func encodeReplayStatus(payload interface{}) ([]byte, error) {
+	p, ok := payload.(events.ReplayStatusPayload)
+	if !ok {
+		return nil, payloadError(PktReplayStatus, payload)
+	}
+	return BuildReplayStatus(p.Port, p.MatchID, byte(p.Status)), nil
+}
@@ -14,15 +14,44 @@ import (
 
 // GameManagerParser parses binary packets from the Game Server <-> Manager protocol.
 // This replaces the Python GameManagerParser class from packet_parser.py.
+//
+// Each packet type is handled by a PacketCodec in the parser's registry; the
+// built-in packets (0x40-0x4A) are registered by NewGameManagerParser, and
+// further ones can be added with Codecs().Register.
 type GameManagerParser struct {
 	logger zerolog.Logger
+	codecs *CodecRegistry
 }
 
 // NewGameManagerParser creates a new parser for game-manager protocol.
 func NewGameManagerParser() *GameManagerParser {
-	return &GameManagerParser{
+	p := &GameManagerParser{
 		logger: log.With().Str("component", "gm_parser").Logger(),
+		codecs: NewCodecRegistry(),
+	}
+
+	builtin := []PacketCodec{
+		{PktServerAnnounce, "server_announce", p.parseServerAnnounce, encodeServerAnnounce},
+		{PktServerClosed, "server_closed", p.parseServerClosed, encodeServerClosed},
+		{PktServerStatus, "server_status", p.parseServerStatus, encodeServerStatus},
+		{PktLongFrame, "long_frame", p.parseLongFrame, encodeLongFrame},
+		{PktLobbyCreated, "lobby_created", p.parseLobbyCreated, encodeLobbyCreated},
+		{PktLobbyClosed, "lobby_closed", p.parseLobbyClosed, encodeLobbyClosed},
+		{PktPlayerConnection, "player_connection", p.parsePlayerConnection, encodePlayerConnection},
+		{PktCowMasterResponse, "cowmaster_response", p.parseCowMasterResponse, encodeCowMasterResponse},
+		{PktReplayStatus, "replay_status", p.parseReplayStatus, encodeReplayStatus},
+	}
+	for _, codec := range builtin {
+		if err := p.codecs.Register(codec); err != nil {
+			panic(err) // Duplicate built-in command byte
+		}
 	}
+	return p
+}
+
+// Codecs returns the parser's codec registry.
+func (p *GameManagerParser) Codecs() *CodecRegistry {
+	return p.codecs
 }
 
 // ReadPacket reads a single length-prefixed packet from a reader.
@@ -64,42 +93,10 @@ func WritePacket(w io.Writer, data []byte) error {
 	return nil
 }
 
-// Parse processes a raw packet and returns a structured event.
+// Parse processes a raw packet and returns a structured event. Packets
+// without a codec return an error wrapping ErrUnknownCommand.
 func (p *GameManagerParser) Parse(data []byte) (*events.Event, error) {
-	if len(data) < 1 {
-		return nil, fmt.Errorf("empty packet")
-	}
-
-	cmd := data[0]
-	payload := data[1:]
-	reader := bytes.NewReader(payload)
-
-	switch cmd {
-	case PktServerAnnounce:
-		return p.parseServerAnnounce(reader)
-	case PktServerClosed:
-		return p.parseServerClosed(reader)
-	case PktServerStatus:
-		return p.parseServerStatus(reader)
-	case PktLongFrame:
-		return p.parseLongFrame(reader)
-	case PktLobbyCreated:
-		return p.parseLobbyCreated(reader)
-	case PktLobbyClosed:
-		return p.parseLobbyClosed(reader)
-	case PktPlayerConnection:
-		return p.parsePlayerConnection(reader)
-	case PktCowMasterResponse:
-		return p.parseCowMasterResponse(reader)
-	case PktReplayStatus:
-		return p.parseReplayStatus(reader)
-	default:
-		p.logger.Warn().
-			Uint8("command", cmd).
-			Int("payload_len", len(payload)).
-			Msg("unknown packet command")
-		return nil, fmt.Errorf("unknown command: 0x%02X", cmd)
-	}
+	return p.codecs.Decode(data)
 }
 
 // parseServerAnnounce handles packet 0x40: server hello with port.
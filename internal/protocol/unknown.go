@@ -0,0 +1,136 @@
+package protocol
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// unknownCaptureSize is the number of unknown packets kept.
+	unknownCaptureSize = 64
+	// unknownDumpBytes is how much of an unknown packet is hex-dumped.
+	unknownDumpBytes = 512
+)
+
+// UnknownPacket is a raw capture of a packet without a codec.
+type UnknownPacket struct {
+	Time    time.Time `json:"time"`
+	Port    uint16    `json:"port"`
+	Command string    `json:"command"` // e.g. "0x46"
+	Length  int       `json:"length"`  // Including the command byte
+	Hex     string    `json:"hex"`     // hex.Dump of the first 512 bytes
+}
+
+// UnknownPacketCount is how often a server sent one unknown command.
+type UnknownPacketCount struct {
+	Port     uint16    `json:"port"`
+	Command  string    `json:"command"`
+	Count    uint64    `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// UnknownPackets counts packets without a codec per server port and command,
+// and keeps raw captures of the most recent ones, so new packet types can be
+// mapped instead of being dropped. It is safe for concurrent use.
+type UnknownPackets struct {
+	mu     sync.Mutex
+	counts map[uint16]map[byte]*UnknownPacketCount
+	recent []UnknownPacket // Oldest first
+}
+
+// NewUnknownPackets creates an empty tracker.
+func NewUnknownPackets() *UnknownPackets {
+	return &UnknownPackets{counts: make(map[uint16]map[byte]*UnknownPacketCount)}
+}
+
+// Capture records a raw packet (command byte first) received from the game
+// server on port. The first packet of each command per port is logged with
+// its hex dump.
+func (u *UnknownPackets) Capture(port uint16, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	cmd := data[0]
+	now := time.Now()
+
+	dump := data
+	if len(dump) > unknownDumpBytes {
+		dump = dump[:unknownDumpBytes]
+	}
+	pkt := UnknownPacket{
+		Time:    now,
+		Port:    port,
+		Command: fmt.Sprintf("0x%02X", cmd),
+		Length:  len(data),
+		Hex:     hex.Dump(dump),
+	}
+
+	u.mu.Lock()
+	byCmd, ok := u.counts[port]
+	if !ok {
+		byCmd = make(map[byte]*UnknownPacketCount)
+		u.counts[port] = byCmd
+	}
+	count, seen := byCmd[cmd]
+	if !seen {
+		count = &UnknownPacketCount{Port: port, Command: pkt.Command}
+		byCmd[cmd] = count
+	}
+	count.Count++
+	count.LastSeen = now
+
+	u.recent = append(u.recent, pkt)
+	if len(u.recent) > unknownCaptureSize {
+		u.recent = append(u.recent[:0], u.recent[1:]...)
+	}
+	u.mu.Unlock()
+
+	event := log.Debug()
+	if !seen {
+		event = log.Warn()
+	}
+	event.
+		Str("component", "gm_parser").
+		Uint16("port", port).
+		Str("command", pkt.Command).
+		Int("length", pkt.Length).
+		Str("hex", pkt.Hex).
+		Msg("unknown packet command")
+}
+
+// Counts returns the unknown packet counts, ordered by port and command.
+func (u *UnknownPackets) Counts() []UnknownPacketCount {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	out := []UnknownPacketCount{} // Never nil, for the API
+	for _, byCmd := range u.counts {
+		for _, count := range byCmd {
+			out = append(out, *count)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Port != out[j].Port {
+			return out[i].Port < out[j].Port
+		}
+		return out[i].Command < out[j].Command
+	})
+	return out
+}
+
+// Recent returns up to n of the most recent captures, oldest first. n <= 0
+// returns all of them.
+func (u *UnknownPackets) Recent(n int) []UnknownPacket {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if n <= 0 || n > len(u.recent) {
+		n = len(u.recent)
+	}
+	return append([]UnknownPacket{}, u.recent[len(u.recent)-n:]...)
+}
@@ -0,0 +1,189 @@
+// Package replay fulfils replay requests that players make through the chat
+// server (EventHandleReplayRequest): the match's replay is looked up in the
+// replay directory and long-term storage, uploaded to the master server, and
+// the progress is reported back to the chat server.
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/connector"
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+// uploadedTTL is how long an uploaded replay is answered as UPLOADED
+// without uploading it again.
+const uploadedTTL = 24 * time.Hour
+
+// Uploader handles replay requests. Requests for a match that is already
+// queued or uploading are answered with its current status instead of
+// starting another upload, and at most replay_upload.max_concurrent_uploads
+// replays are uploaded at a time.
+type Uploader struct {
+	mu        sync.Mutex
+	cfg       *config.Config
+	masterSvr *connector.MasterServerConnector
+	chatSvr   *connector.ChatServerConnector
+	ctx       context.Context // Set by Start, cancels uploads on shutdown
+	slots     chan struct{}   // Upload semaphore
+
+	pending  map[uint32]events.ReplayStatus // QUEUED or UPLOADING, by match ID
+	uploaded map[uint32]time.Time           // Recently uploaded, by match ID
+}
+
+// NewUploader creates the uploader and subscribes it to
+// EventHandleReplayRequest.
+func NewUploader(cfg *config.Config, eventBus *events.EventBus, masterSvr *connector.MasterServerConnector,
+	chatSvr *connector.ChatServerConnector) *Uploader {
+
+	concurrent := cfg.GetApplicationData().ReplayUpload.MaxConcurrentUploads
+	if concurrent < 1 {
+		concurrent = 1
+	}
+
+	u := &Uploader{
+		cfg:       cfg,
+		masterSvr: masterSvr,
+		chatSvr:   chatSvr,
+		ctx:       context.Background(),
+		slots:     make(chan struct{}, concurrent),
+		pending:   make(map[uint32]events.ReplayStatus),
+		uploaded:  make(map[uint32]time.Time),
+	}
+	eventBus.Subscribe(events.EventHandleReplayRequest, "replay.handleRequest", u.onReplayRequest)
+	return u
+}
+
+// Start keeps the uploader's context until it is cancelled.
+func (u *Uploader) Start(ctx context.Context) {
+	u.mu.Lock()
+	u.ctx = ctx
+	u.mu.Unlock()
+
+	<-ctx.Done()
+}
+
+func (u *Uploader) onReplayRequest(_ context.Context, event events.Event) error {
+	payload, _ := event.Payload.(map[string]uint32)
+	matchID := payload["match_id"]
+	if matchID == 0 {
+		return fmt.Errorf("replay request without a match ID")
+	}
+	logger := log.With().Uint32("match_id", matchID).Uint32("account_id", payload["account_id"]).Logger()
+
+	u.mu.Lock()
+	for id, at := range u.uploaded {
+		if time.Since(at) > uploadedTTL {
+			delete(u.uploaded, id)
+		}
+	}
+	if status, ok := u.pending[matchID]; ok {
+		u.mu.Unlock()
+		logger.Debug().Str("status", status.String()).Msg("replay already requested")
+		u.sendStatus(matchID, status)
+		return nil
+	}
+	if _, ok := u.uploaded[matchID]; ok {
+		u.mu.Unlock()
+		logger.Debug().Msg("replay already uploaded")
+		u.sendStatus(matchID, events.ReplayStatusUploaded)
+		return nil
+	}
+
+	path, err := u.findReplay(matchID)
+	if err != nil {
+		u.mu.Unlock()
+		logger.Info().Err(err).Msg("requested replay not found")
+		u.sendStatus(matchID, events.ReplayStatusNotFound)
+		return nil
+	}
+
+	u.pending[matchID] = events.ReplayStatusQueued
+	ctx := u.ctx
+	u.mu.Unlock()
+
+	logger.Info().Str("file", path).Msg("replay queued for upload")
+	u.sendStatus(matchID, events.ReplayStatusQueued)
+
+	go u.upload(ctx, matchID, path)
+	return nil
+}
+
+// findReplay returns the path of a match's replay, looking in the replay
+// directory first and then in long-term storage.
+func (u *Uploader) findReplay(matchID uint32) (string, error) {
+	name := fmt.Sprintf("M%d.honreplay", matchID)
+	dirs := []string{filepath.Join(u.cfg.GetHoNData().HomeDirectory, "replays")}
+	if storage := u.cfg.GetApplicationData().LongTermStorage; storage.Enabled && storage.Path != "" {
+		dirs = append(dirs, storage.Path)
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Str("file", path).Msg("failed to check for replay")
+		}
+	}
+	return "", fmt.Errorf("%s is not in %v", name, dirs)
+}
+
+// upload waits for a free upload slot, uploads the replay, and reports the
+// outcome.
+func (u *Uploader) upload(ctx context.Context, matchID uint32, path string) {
+	logger := log.With().Uint32("match_id", matchID).Logger()
+	status := events.ReplayStatusFailed
+	defer func() {
+		u.mu.Lock()
+		delete(u.pending, matchID)
+		if status == events.ReplayStatusUploaded {
+			u.uploaded[matchID] = time.Now()
+		}
+		u.mu.Unlock()
+		u.sendStatus(matchID, status)
+	}()
+
+	select {
+	case u.slots <- struct{}{}:
+		defer func() { <-u.slots }()
+	case <-ctx.Done():
+		return
+	}
+
+	u.mu.Lock()
+	u.pending[matchID] = events.ReplayStatusUploading
+	u.mu.Unlock()
+	u.sendStatus(matchID, events.ReplayStatusUploading)
+
+	started := time.Now()
+	if err := u.masterSvr.UploadReplay(ctx, matchID, path); err != nil {
+		logger.Error().Err(err).Msg("replay upload failed")
+		return
+	}
+
+	status = events.ReplayStatusUploaded
+	logger.Info().Dur("took", time.Since(started)).Msg("replay uploaded")
+}
+
+// sendStatus reports a replay's status to the chat server.
+func (u *Uploader) sendStatus(matchID uint32, status events.ReplayStatus) {
+	if err := u.chatSvr.SendReplayStatus(matchID, byte(status)); err != nil {
+		log.Warn().
+			Err(err).
+			Uint32("match_id", matchID).
+			Str("status", status.String()).
+			Msg("failed to send replay status to chat server")
+	}
+}
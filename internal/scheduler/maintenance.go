@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/events"
+	"github.com/energizer-project/energizer/internal/protocol"
+)
+
+// maintenanceTick is how often the maintenance schedule is evaluated.
+const maintenanceTick = 5 * time.Second
+
+// maintenanceRun tracks one occurrence of a maintenance window, from the
+// first countdown message until the window ends.
+type maintenanceRun struct {
+	window   config.MaintenanceWindow
+	start    time.Time
+	warned   map[int]bool // Countdown messages already sent, by minutes
+	draining bool
+	acted    bool
+}
+
+// runMaintenanceLoop drives the configured maintenance windows. The schedule
+// is re-read on every tick, so windows can be changed at runtime; a window
+// that has already begun draining runs to completion.
+func (s *Scheduler) runMaintenanceLoop(ctx context.Context) {
+	ticker := time.NewTicker(maintenanceTick)
+	defer ticker.Stop()
+
+	var run *maintenanceRun
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run = s.tickMaintenance(ctx, run, time.Now())
+		}
+	}
+}
+
+// tickMaintenance advances the current maintenance run and returns the run to
+// track next (nil when no window is scheduled).
+func (s *Scheduler) tickMaintenance(ctx context.Context, run *maintenanceRun, now time.Time) *maintenanceRun {
+	policy := s.cfg.GetApplicationData().Maintenance
+
+	if run == nil {
+		run = nextMaintenance(policy.Windows, now)
+		if run == nil {
+			return nil
+		}
+		log.Info().
+			Str("window", run.window.Name).
+			Str("action", run.window.Action).
+			Time("start", run.start).
+			Msg("next maintenance window scheduled")
+	}
+
+	// A window that was removed or changed before draining began is rescheduled
+	if !run.draining && now.Before(run.start) {
+		next := nextMaintenance(policy.Windows, now)
+		if next == nil || !next.start.Equal(run.start) || next.window.Action != run.window.Action {
+			return next
+		}
+	}
+
+	// Countdown: only the latest reached warning is sent, so a window scheduled
+	// at short notice does not produce a burst of messages
+	if now.Before(run.start) {
+		due := false
+		for _, m := range policy.WarningMinutes {
+			if !run.warned[m] && !now.Before(run.start.Add(-time.Duration(m)*time.Minute)) {
+				run.warned[m] = true
+				due = true
+			}
+		}
+		if due {
+			minutes := int(math.Ceil(run.start.Sub(now).Minutes()))
+			s.broadcastMaintenance(ctx, policy.WarningTemplate, run, minutes)
+		}
+	}
+
+	// Drain so no new matches start
+	if !run.draining && !now.Before(run.start.Add(-time.Duration(policy.DrainLeadMin)*time.Minute)) {
+		run.draining = true
+		s.manager.BeginMaintenance()
+	}
+
+	// Window start: run the action
+	if !run.acted && !now.Before(run.start) {
+		run.acted = true
+		s.broadcastMaintenance(ctx, policy.StartTemplate, run, 0)
+		if done := s.runMaintenanceAction(ctx, run); done {
+			return nil
+		}
+	}
+
+	// Window end
+	if run.acted && !now.Before(run.start.Add(run.window.Duration())) {
+		log.Info().Str("window", run.window.Name).Msg("maintenance window ended")
+		s.manager.EndMaintenance(context.Background())
+		return nil
+	}
+
+	return run
+}
+
+// runMaintenanceAction performs the window's action. Returns true if the
+// maintenance is already complete.
+func (s *Scheduler) runMaintenanceAction(ctx context.Context, run *maintenanceRun) bool {
+	log.Info().
+		Str("window", run.window.Name).
+		Str("action", run.window.Action).
+		Dur("duration", run.window.Duration()).
+		Msg("maintenance window started")
+
+	switch run.window.Action {
+	case config.MaintenanceRestart:
+		// Drained servers come back right away; busy ones once their match ends
+		s.manager.EndMaintenance(context.Background())
+		return true
+
+	case config.MaintenancePatch:
+		s.eventBus.Emit(ctx, events.Event{
+			Type:   events.EventPatchServer,
+			Source: "scheduler",
+			Payload: map[string]string{
+				"reason": "maintenance",
+				"window": run.window.Name,
+			},
+		})
+	}
+
+	// stop and patch keep servers down until the window ends
+	return false
+}
+
+// broadcastMaintenance sends a maintenance message to idle and lobby servers.
+// Servers in a match are not interrupted.
+func (s *Scheduler) broadcastMaintenance(ctx context.Context, template string, run *maintenanceRun, minutes int) {
+	if template == "" {
+		return
+	}
+
+	msg := strings.NewReplacer(
+		"{name}", run.window.Name,
+		"{action}", run.window.Action,
+		"{minutes}", fmt.Sprintf("%d", minutes),
+		"{start}", run.start.Format("15:04"),
+		"{duration}", fmt.Sprintf("%d", run.window.DurationMin),
+	).Replace(template)
+
+	registry := s.manager.GetConnectionRegistry()
+	sent := registry.SendToAll(ctx, protocol.BuildManagerMessage(msg), func(port uint16) bool {
+		inst, ok := s.manager.GetInstance(port)
+		if !ok {
+			return false
+		}
+		switch inst.State().GetPhase() {
+		case events.GamePhaseIdle, events.GamePhaseInLobby, events.GamePhaseBanning, events.GamePhasePicking:
+			return true
+		}
+		return false
+	})
+
+	log.Info().
+		Str("window", run.window.Name).
+		Int("servers", sent).
+		Str("message", msg).
+		Msg("maintenance message broadcast")
+}
+
+// nextMaintenance returns the earliest upcoming window occurrence.
+func nextMaintenance(windows []config.MaintenanceWindow, now time.Time) *maintenanceRun {
+	var next *maintenanceRun
+	for _, w := range windows {
+		start, err := w.NextStart(now)
+		if err != nil || start.IsZero() {
+			continue
+		}
+		if next == nil || start.Before(next.start) {
+			next = &maintenanceRun{window: w, start: start, warned: make(map[int]bool)}
+		}
+	}
+	return next
+}
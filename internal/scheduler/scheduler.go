@@ -1,5 +1,6 @@
 // Package scheduler implements background task scheduling for Energizer,
-// including replay file cleanup and daily statistics collection.
+// including replay file cleanup, daily statistics collection and
+// maintenance windows.
 package scheduler
 
 import (
@@ -14,19 +15,22 @@ import (
 
 	"github.com/energizer-project/energizer/internal/config"
 	"github.com/energizer-project/energizer/internal/events"
+	"github.com/energizer-project/energizer/internal/server"
 )
 
 // Scheduler manages periodic background tasks.
 type Scheduler struct {
 	cfg      *config.Config
 	eventBus *events.EventBus
+	manager  *server.Manager
 }
 
 // NewScheduler creates a new task scheduler.
-func NewScheduler(cfg *config.Config, eventBus *events.EventBus) *Scheduler {
+func NewScheduler(cfg *config.Config, eventBus *events.EventBus, manager *server.Manager) *Scheduler {
 	return &Scheduler{
 		cfg:      cfg,
 		eventBus: eventBus,
+		manager:  manager,
 	}
 }
 
@@ -42,6 +46,9 @@ func (s *Scheduler) Start(ctx context.Context) {
 	// Stats collection - runs daily
 	go s.runStatsCollectionLoop(ctx)
 
+	// Maintenance windows - evaluated continuously, idle when none are configured
+	go s.runMaintenanceLoop(ctx)
+
 	// Block until context is cancelled
 	<-ctx.Done()
 	log.Info().Msg("scheduler stopped")
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// svrPortPattern extracts the game port from a server's -execute parameters.
+var svrPortPattern = regexp.MustCompile(`Set svr_port (\d+)`)
+
+// AdoptRunningServers re-attaches game servers left running by a previous
+// Energizer run, so that upgrading or restarting the manager does not end
+// in-progress matches. Live processes of the configured executable are matched
+// to instances by the svr_port and svr_login in their command line. Processes
+// that match no instance (or a sleeping one) are killed to free their ports.
+// Returns the number of adopted servers.
+func (m *Manager) AdoptRunningServers() int {
+	honData := m.cfg.GetHoNData()
+	exeName := honData.ExecutableName
+	if exeName == "" {
+		exeName = getServerExecutable()
+	}
+	loginTag := fmt.Sprintf("Set svr_login %s:", honData.Login)
+
+	procs, err := process.Processes()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to list processes for server adoption")
+		return 0
+	}
+
+	adopted, killed := 0, 0
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil || !strings.EqualFold(name, exeName) {
+			continue
+		}
+		pid := int(p.Pid)
+
+		cmdline, err := p.Cmdline()
+		if err != nil {
+			continue
+		}
+
+		inst, ok := m.matchInstance(cmdline, loginTag)
+		if !ok {
+			log.Info().Int("pid", pid).Msg("leftover game server matches no instance, killing")
+			terminateProcessPlatform(pid)
+			killed++
+			continue
+		}
+
+		if err := inst.Adopt(pid); err != nil {
+			// Typically a second process claiming the same port
+			log.Warn().Err(err).Int("pid", pid).Msg("could not adopt game server, killing")
+			terminateProcessPlatform(pid)
+			killed++
+			continue
+		}
+		adopted++
+	}
+
+	if adopted > 0 || killed > 0 {
+		log.Info().
+			Int("adopted", adopted).
+			Int("killed", killed).
+			Msg("leftover game servers processed")
+	}
+
+	if adopted > 0 {
+		m.savePIDFile()
+	}
+	return adopted
+}
+
+// matchInstance finds the awake instance a game server command line belongs to.
+func (m *Manager) matchInstance(cmdline, loginTag string) (*Instance, bool) {
+	if !strings.Contains(cmdline, loginTag) {
+		return nil, false
+	}
+
+	match := svrPortPattern.FindStringSubmatch(cmdline)
+	if match == nil {
+		return nil, false
+	}
+	port, err := strconv.ParseUint(match[1], 10, 16)
+	if err != nil {
+		return nil, false
+	}
+
+	inst, ok := m.GetInstance(uint16(port))
+	if !ok || inst.IsSleeping() {
+		return nil, false
+	}
+	return inst, true
+}
+
+// ownsPID reports whether pid belongs to one of the managed instances.
+func (m *Manager) ownsPID(pid int) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, inst := range m.servers {
+		if inst.IsRunning() && inst.PID() == pid {
+			return true
+		}
+	}
+	return false
+}
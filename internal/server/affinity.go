@@ -0,0 +1,179 @@
+package server
+
+import (
+	"runtime"
+	"sort"
+)
+
+// CPUTopology describes the logical CPUs of the host.
+type CPUTopology struct {
+	Source string       `json:"source"` // "sysfs" or "flat" (no topology information)
+	CPUs   []LogicalCPU `json:"cpus"`
+}
+
+// LogicalCPU is one hardware thread of the host.
+type LogicalCPU struct {
+	ID      int32 `json:"id"`
+	Core    int   `json:"core"`    // Physical core, unique across packages
+	Package int   `json:"package"` // Physical package (socket)
+	Node    int   `json:"node"`    // NUMA node
+	Thread  int   `json:"thread"`  // Position among the core's SMT siblings (0 = primary)
+}
+
+// flatTopology treats every logical CPU as its own core on a single node.
+// Used when the host topology cannot be read or topology awareness is off.
+func flatTopology() CPUTopology {
+	n := runtime.NumCPU()
+	cpus := make([]LogicalCPU, n)
+	for idx := range cpus {
+		cpus[idx] = LogicalCPU{ID: int32(idx), Core: idx}
+	}
+	return CPUTopology{Source: "flat", CPUs: cpus}
+}
+
+// AffinityPlanner assigns game servers to CPUs. Every physical core's primary
+// thread is used before any SMT sibling, so two busy servers only share a
+// physical core once every core is in use. Each server is pinned to a single
+// logical CPU, which keeps it within one NUMA node. Reserved CPUs are never
+// assigned.
+type AffinityPlanner struct {
+	topology       CPUTopology
+	reserved       map[int32]bool
+	serversPerCore int
+
+	// Assignable CPUs in fill order
+	slots []LogicalCPU
+}
+
+// AffinityPlan is a JSON-serializable view of the planner and the current
+// assignment of servers to CPUs.
+type AffinityPlan struct {
+	Source         string               `json:"source"`
+	ServersPerCore int                  `json:"servers_per_core"`
+	NUMANodes      int                  `json:"numa_nodes"`
+	PhysicalCores  int                  `json:"physical_cores"`
+	LogicalCPUs    int                  `json:"logical_cpus"`
+	ReservedCPUs   []int32              `json:"reserved_cpus"`
+	SlotOrder      []int32              `json:"slot_order"`
+	Capacity       int                  `json:"capacity"` // Servers placed before CPUs are oversubscribed
+	Oversubscribed bool                 `json:"oversubscribed"`
+	Assignments    []AffinityAssignment `json:"assignments"`
+	Topology       []LogicalCPU         `json:"topology"`
+}
+
+// AffinityAssignment is the CPU placement of one server.
+type AffinityAssignment struct {
+	ID      int     `json:"id"`
+	Port    uint16  `json:"port"`
+	CPUs    []int32 `json:"cpus"`
+	Node    int     `json:"node"`
+	Sibling bool    `json:"sibling"` // Placed on a secondary SMT thread
+}
+
+// NewAffinityPlanner builds a planner. If topologyAware is false, or the host
+// topology cannot be read, every logical CPU is treated as a separate core.
+func NewAffinityPlanner(topologyAware bool, reserved []int, serversPerCore int) *AffinityPlanner {
+	topology := flatTopology()
+	if topologyAware {
+		if t, err := readCPUTopology(); err == nil && len(t.CPUs) > 0 {
+			topology = t
+		}
+	}
+
+	p := &AffinityPlanner{
+		topology:       topology,
+		reserved:       make(map[int32]bool, len(reserved)),
+		serversPerCore: serversPerCore,
+	}
+	for _, id := range reserved {
+		p.reserved[int32(id)] = true
+	}
+
+	for _, cpu := range topology.CPUs {
+		if !p.reserved[cpu.ID] {
+			p.slots = append(p.slots, cpu)
+		}
+	}
+
+	// Primary threads first, then each further sibling level; within a
+	// level, node by node so consecutive servers stay on the same node
+	sort.SliceStable(p.slots, func(i, j int) bool {
+		a, b := p.slots[i], p.slots[j]
+		if a.Thread != b.Thread {
+			return a.Thread < b.Thread
+		}
+		if a.Node != b.Node {
+			return a.Node < b.Node
+		}
+		if a.Core != b.Core {
+			return a.Core < b.Core
+		}
+		return a.ID < b.ID
+	})
+
+	return p
+}
+
+// CoresFor returns the CPUs for the server at the given 0-based index, or nil
+// if servers should not be pinned. Beyond Capacity, slots are reused.
+func (p *AffinityPlanner) CoresFor(serverIndex int) []int32 {
+	if p.serversPerCore <= 0 || len(p.slots) == 0 {
+		return nil
+	}
+	slot := (serverIndex / p.serversPerCore) % len(p.slots)
+	return []int32{p.slots[slot].ID}
+}
+
+// Capacity returns the number of servers that fit before CPUs are oversubscribed.
+func (p *AffinityPlanner) Capacity() int {
+	if p.serversPerCore <= 0 {
+		return 0
+	}
+	return len(p.slots) * p.serversPerCore
+}
+
+// Plan describes the topology and the given servers' placement.
+func (p *AffinityPlanner) Plan(servers []*Instance) AffinityPlan {
+	byID := make(map[int32]LogicalCPU, len(p.topology.CPUs))
+	nodes := make(map[int]bool)
+	cores := make(map[int]bool)
+	for _, cpu := range p.topology.CPUs {
+		byID[cpu.ID] = cpu
+		nodes[cpu.Node] = true
+		cores[cpu.Core] = true
+	}
+
+	plan := AffinityPlan{
+		Source:         p.topology.Source,
+		ServersPerCore: p.serversPerCore,
+		NUMANodes:      len(nodes),
+		PhysicalCores:  len(cores),
+		LogicalCPUs:    len(p.topology.CPUs),
+		ReservedCPUs:   []int32{},
+		SlotOrder:      make([]int32, len(p.slots)),
+		Capacity:       p.Capacity(),
+		Assignments:    make([]AffinityAssignment, 0, len(servers)),
+		Topology:       p.topology.CPUs,
+	}
+	for id := range p.reserved {
+		plan.ReservedCPUs = append(plan.ReservedCPUs, id)
+	}
+	sort.Slice(plan.ReservedCPUs, func(i, j int) bool { return plan.ReservedCPUs[i] < plan.ReservedCPUs[j] })
+	for idx, cpu := range p.slots {
+		plan.SlotOrder[idx] = cpu.ID
+	}
+
+	sortByPort(servers)
+	for _, inst := range servers {
+		a := AffinityAssignment{ID: inst.ID(), Port: inst.Port(), CPUs: inst.CPUAffinity()}
+		if len(a.CPUs) > 0 {
+			cpu := byID[a.CPUs[0]]
+			a.Node = cpu.Node
+			a.Sibling = cpu.Thread > 0
+		}
+		plan.Assignments = append(plan.Assignments, a)
+	}
+	plan.Oversubscribed = plan.Capacity > 0 && len(servers) > plan.Capacity
+
+	return plan
+}
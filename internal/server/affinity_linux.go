@@ -0,0 +1,103 @@
+//go:build linux
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysCPUDir is where Linux exposes the CPU topology.
+const sysCPUDir = "/sys/devices/system/cpu"
+
+var (
+	cpuDirPattern  = regexp.MustCompile(`^cpu(\d+)$`)
+	nodeDirPattern = regexp.MustCompile(`^node(\d+)$`)
+)
+
+// readCPUTopology reads the online CPUs' core, package and NUMA node from sysfs.
+func readCPUTopology() (CPUTopology, error) {
+	entries, err := os.ReadDir(sysCPUDir)
+	if err != nil {
+		return CPUTopology{}, err
+	}
+
+	type coreKey struct{ pkg, core int }
+	coreIndex := make(map[coreKey]int)
+	var cpus []LogicalCPU
+
+	for _, entry := range entries {
+		m := cpuDirPattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(m[1])
+		dir := filepath.Join(sysCPUDir, entry.Name())
+
+		// cpu0 usually has no "online" file and cannot be taken offline
+		if online, err := readSysInt(filepath.Join(dir, "online")); err == nil && online == 0 {
+			continue
+		}
+
+		pkg, err := readSysInt(filepath.Join(dir, "topology", "physical_package_id"))
+		if err != nil {
+			pkg = 0
+		}
+		core, err := readSysInt(filepath.Join(dir, "topology", "core_id"))
+		if err != nil {
+			core = id
+		}
+
+		key := coreKey{pkg, core}
+		idx, ok := coreIndex[key]
+		if !ok {
+			idx = len(coreIndex)
+			coreIndex[key] = idx
+		}
+
+		cpus = append(cpus, LogicalCPU{
+			ID:      int32(id),
+			Core:    idx,
+			Package: pkg,
+			Node:    cpuNode(dir),
+		})
+	}
+
+	// Number SMT siblings by logical CPU ID within each physical core
+	sort.Slice(cpus, func(i, j int) bool { return cpus[i].ID < cpus[j].ID })
+	threads := make(map[int]int)
+	for idx := range cpus {
+		cpus[idx].Thread = threads[cpus[idx].Core]
+		threads[cpus[idx].Core]++
+	}
+
+	return CPUTopology{Source: "sysfs", CPUs: cpus}, nil
+}
+
+// cpuNode returns the NUMA node of a CPU from its nodeN link, or 0.
+func cpuNode(dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	for _, entry := range entries {
+		if m := nodeDirPattern.FindStringSubmatch(entry.Name()); m != nil {
+			node, _ := strconv.Atoi(m[1])
+			return node
+		}
+	}
+	return 0
+}
+
+// readSysInt reads a single integer from a sysfs file.
+func readSysInt(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package server
+
+import "fmt"
+
+// readCPUTopology is not implemented on Windows; the planner falls back to
+// a flat topology.
+func readCPUTopology() (CPUTopology, error) {
+	return CPUTopology{}, fmt.Errorf("CPU topology detection not supported on Windows")
+}
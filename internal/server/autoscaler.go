@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+// maxScaleDecisions is the number of scaling decisions kept for the API.
+const maxScaleDecisions = 100
+
+// Autoscaler keeps a pool of warm READY instances in front of demand.
+// When occupancy rises it wakes sleeping servers, starts stopped ones, and
+// finally grows the pool, up to the host's CPU capacity. When demand falls,
+// surplus idle servers are put to sleep one at a time after a cooldown.
+type Autoscaler struct {
+	mu      sync.Mutex
+	cfg     *config.Config
+	manager *Manager
+
+	lastScaleUp   time.Time
+	lastScaleDown time.Time
+	surplusSince  time.Time // When READY capacity first exceeded the warm target
+
+	status    AutoscalerStatus
+	decisions []ScaleDecision
+}
+
+// ScaleDecision records a single action taken by the autoscaler.
+type ScaleDecision struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"` // "wake", "start", "add", "sleep"
+	Port     uint16    `json:"port,omitempty"`
+	Reason   string    `json:"reason"`
+	Active   int       `json:"active"`
+	Ready    int       `json:"ready"`
+	Occupied int       `json:"occupied"`
+}
+
+// AutoscalerStatus is a JSON-serializable view of the autoscaler's targets
+// and the fleet counts seen at the last evaluation.
+type AutoscalerStatus struct {
+	Enabled       bool            `json:"enabled"`
+	WarmTarget    int             `json:"warm_target"`
+	MinInstances  int             `json:"min_instances"`
+	MaxInstances  int             `json:"max_instances"`
+	DesiredActive int             `json:"desired_active"`
+	Active        int             `json:"active"`
+	Ready         int             `json:"ready"`
+	Starting      int             `json:"starting"`
+	Occupied      int             `json:"occupied"`
+	LastEvaluated time.Time       `json:"last_evaluated"`
+	LastScaleUp   time.Time       `json:"last_scale_up"`
+	LastScaleDown time.Time       `json:"last_scale_down"`
+	Decisions     []ScaleDecision `json:"decisions"`
+}
+
+// NewAutoscaler creates an autoscaler for the manager's instance pool.
+func NewAutoscaler(cfg *config.Config, manager *Manager) *Autoscaler {
+	return &Autoscaler{
+		cfg:     cfg,
+		manager: manager,
+	}
+}
+
+// Start runs the scaling loop until the context is cancelled. The enabled
+// flag is re-read on every tick so the autoscaler can be toggled at runtime.
+func (a *Autoscaler) Start(ctx context.Context) {
+	interval := time.Duration(a.cfg.GetApplicationData().Autoscale.CheckIntervalSec) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Info().Dur("interval", interval).Msg("autoscaler started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("autoscaler stopped")
+			return
+		case <-ticker.C:
+			a.evaluate(ctx)
+		}
+	}
+}
+
+// Status returns the current targets, fleet counts, and decision log.
+func (a *Autoscaler) Status() AutoscalerStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	status := a.status
+	status.Enabled = a.cfg.GetApplicationData().Autoscale.Enabled
+	status.LastScaleUp = a.lastScaleUp
+	status.LastScaleDown = a.lastScaleDown
+	status.Decisions = make([]ScaleDecision, len(a.decisions))
+	copy(status.Decisions, a.decisions)
+	return status
+}
+
+// evaluate compares READY capacity with the warm target and scales by one step.
+func (a *Autoscaler) evaluate(ctx context.Context) {
+	policy := a.cfg.GetApplicationData().Autoscale
+	maxInstances := a.manager.MaxInstances()
+
+	var active, ready, starting int
+	var idleReady, sleeping, stopped []*Instance
+	for _, inst := range a.manager.GetAllInstances() {
+		if !inst.IsEnabled() {
+			continue // Disabled or quarantined servers are left alone
+		}
+		switch {
+		case inst.IsRunning():
+			active++
+			if inst.IsSleeping() || inst.IsDraining() {
+				continue // On its way out of rotation
+			}
+			state := inst.State()
+			switch state.GetStatus() {
+			case events.GameStatusStarting:
+				starting++
+			case events.GameStatusReady:
+				if state.GetPhase() == events.GamePhaseIdle {
+					ready++
+					idleReady = append(idleReady, inst)
+				}
+			}
+		case inst.IsSleeping():
+			sleeping = append(sleeping, inst)
+		default:
+			stopped = append(stopped, inst)
+		}
+	}
+	occupied := a.manager.GetOccupiedCount()
+
+	desired := occupied + policy.WarmInstances
+	if desired < policy.MinInstances {
+		desired = policy.MinInstances
+	}
+	if desired > maxInstances {
+		desired = maxInstances
+	}
+
+	a.mu.Lock()
+	a.status = AutoscalerStatus{
+		WarmTarget:    policy.WarmInstances,
+		MinInstances:  policy.MinInstances,
+		MaxInstances:  maxInstances,
+		DesiredActive: desired,
+		Active:        active,
+		Ready:         ready,
+		Starting:      starting,
+		Occupied:      occupied,
+		LastEvaluated: time.Now(),
+	}
+	a.mu.Unlock()
+
+	if !policy.Enabled || a.manager.InMaintenance() {
+		return
+	}
+
+	counts := ScaleDecision{Active: active, Ready: ready, Occupied: occupied}
+
+	// Scale up: servers still starting count toward the warm target
+	if warm := ready + starting; warm < policy.WarmInstances && active < desired {
+		need := policy.WarmInstances - warm
+		if room := desired - active; need > room {
+			need = room
+		}
+		a.scaleUp(ctx, need, sleeping, stopped, counts)
+		return
+	}
+
+	// Scale down: surplus must persist for the cooldown, and never right after a scale-up
+	if ready > policy.WarmInstances && active > policy.MinInstances && len(idleReady) > 0 {
+		cooldown := time.Duration(policy.ScaleDownCooldownSec) * time.Second
+
+		a.mu.Lock()
+		if a.surplusSince.IsZero() {
+			a.surplusSince = time.Now()
+		}
+		due := time.Since(a.surplusSince) >= cooldown && time.Since(a.lastScaleUp) >= cooldown
+		a.mu.Unlock()
+
+		if due {
+			a.scaleDown(idleReady, counts)
+		}
+		return
+	}
+
+	a.mu.Lock()
+	a.surplusSince = time.Time{}
+	a.mu.Unlock()
+}
+
+// scaleUp brings need servers online, preferring sleeping servers, then
+// stopped ones, and only then growing the pool.
+func (a *Autoscaler) scaleUp(ctx context.Context, need int, sleeping, stopped []*Instance, counts ScaleDecision) {
+	sortByPort(sleeping)
+	sortByPort(stopped)
+	reason := fmt.Sprintf("ready+starting below warm target with %d occupied", counts.Occupied)
+
+	for _, inst := range sleeping {
+		if need == 0 {
+			break
+		}
+		if err := a.manager.WakeServer(context.Background(), inst.Port()); err != nil {
+			log.Warn().Err(err).Uint16("port", inst.Port()).Msg("autoscaler: failed to wake server")
+			continue
+		}
+		a.record("wake", inst.Port(), reason, counts)
+		need--
+	}
+
+	for _, inst := range stopped {
+		if need == 0 {
+			break
+		}
+		// Use background context — the server must outlive the autoscaler tick.
+		if err := inst.Start(context.Background()); err != nil {
+			log.Warn().Err(err).Uint16("port", inst.Port()).Msg("autoscaler: failed to start server")
+			continue
+		}
+		a.record("start", inst.Port(), reason, counts)
+		need--
+	}
+
+	if need > 0 {
+		if err := a.manager.AddServers(context.Background(), need); err != nil {
+			log.Warn().Err(err).Int("count", need).Msg("autoscaler: failed to add servers")
+		} else {
+			a.record("add", 0, fmt.Sprintf("pool exhausted, added %d server(s)", need), counts)
+		}
+	}
+
+	a.mu.Lock()
+	a.lastScaleUp = time.Now()
+	a.surplusSince = time.Time{}
+	a.mu.Unlock()
+}
+
+// scaleDown puts the idle READY server with the highest port to sleep.
+func (a *Autoscaler) scaleDown(idleReady []*Instance, counts ScaleDecision) {
+	sortByPort(idleReady)
+	inst := idleReady[len(idleReady)-1]
+
+	if err := a.manager.SleepServer(inst.Port()); err != nil {
+		log.Warn().Err(err).Uint16("port", inst.Port()).Msg("autoscaler: failed to sleep server")
+		return
+	}
+	a.record("sleep", inst.Port(), "ready above warm target for the cooldown period", counts)
+
+	a.mu.Lock()
+	a.lastScaleDown = time.Now()
+	a.surplusSince = time.Time{}
+	a.mu.Unlock()
+}
+
+// record appends a decision to the bounded decision log.
+func (a *Autoscaler) record(action string, port uint16, reason string, counts ScaleDecision) {
+	decision := counts
+	decision.Time = time.Now()
+	decision.Action = action
+	decision.Port = port
+	decision.Reason = reason
+
+	log.Info().
+		Str("action", action).
+		Uint16("port", port).
+		Str("reason", reason).
+		Int("active", counts.Active).
+		Int("ready", counts.Ready).
+		Int("occupied", counts.Occupied).
+		Msg("autoscaler decision")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.decisions = append(a.decisions, decision)
+	if len(a.decisions) > maxScaleDecisions {
+		a.decisions = a.decisions[len(a.decisions)-maxScaleDecisions:]
+	}
+}
+
+// sortByPort orders instances by ascending port.
+func sortByPort(instances []*Instance) {
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].Port() < instances[j].Port()
+	})
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/events"
+	"github.com/energizer-project/energizer/internal/protocol"
+	"github.com/energizer-project/energizer/internal/util"
+)
+
+// ReplayCaptureArg is the subcommand that replays a manager-port capture
+// (see application_data.capture) against an offline manager.
+const ReplayCaptureArg = "replay-capture"
+
+// maxReplayServers limits the servers created for a replay, which covers
+// every port between the lowest and highest captured one.
+const maxReplayServers = 1000
+
+// RunReplayCapture feeds the packets of a capture file through the
+// game-manager parser and the EventBus of a manager with one server per
+// captured port, then prints each server's state timeline. The game servers
+// are not started; the manager runs in a temporary directory so nothing on
+// the host is read or written. It only returns by exiting.
+func RunReplayCapture(args []string) {
+	fs := flag.NewFlagSet(ReplayCaptureArg, flag.ContinueOnError)
+	speed := fs.Float64("speed", 1, "playback speed: 1 = real time, 10 = ten times faster, 0 = no delays")
+	port := fs.Uint("port", 0, "only replay the packets of this game server port")
+	logLevel := fs.String("log-level", "info", "log level of the replayed manager")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: energizer %s [flags] <capture file>\n", ReplayCaptureArg)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if fs.NArg() != 1 || *speed < 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := replayCapture(fs.Arg(0), *speed, uint16(*port), *logLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", ReplayCaptureArg, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func replayCapture(path string, speed float64, onlyPort uint16, logLevel string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	ports, err := capturedPorts(path, onlyPort)
+	if err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return fmt.Errorf("%s has no packets to replay", path)
+	}
+	if span := int(ports[len(ports)-1]-ports[0]) + 1; span > maxReplayServers {
+		return fmt.Errorf("captured ports %d-%d are too far apart, replay one server with -port", ports[0], ports[len(ports)-1])
+	}
+
+	// The manager reads and writes config/ and logs/ in the working directory
+	workDir, err := os.MkdirTemp("", "energizer-replay-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+	if err := os.Chdir(workDir); err != nil {
+		return err
+	}
+
+	logCfg := util.DefaultLogConfig()
+	logCfg.Level = logLevel
+	if err := util.InitLogger(logCfg); err != nil {
+		return err
+	}
+
+	// One server per port from the lowest to the highest captured port
+	cfg := config.DefaultConfig()
+	cfg.HoNData.StartingGamePort = int(ports[0])
+	cfg.HoNData.TotalServers = int(ports[len(ports)-1]-ports[0]) + 1
+	cfg.HoNData.AdoptServers = false
+
+	bus := events.NewEventBus()
+	mgr, err := NewManager(cfg, bus)
+	if err != nil {
+		return err
+	}
+	defer bus.Stop()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	replayed, err := replayPackets(ctx, path, mgr, bus, speed, onlyPort)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nreplayed %d packets\n", replayed)
+	for _, port := range ports {
+		inst, ok := mgr.GetInstance(port)
+		if !ok {
+			continue
+		}
+		printTimeline(port, inst.State().Timeline(0))
+	}
+	return nil
+}
+
+// capturedPorts returns the sorted game server ports found in a capture.
+func capturedPorts(path string, onlyPort uint16) ([]uint16, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	seen := make(map[uint16]bool)
+	reader := protocol.NewCaptureReader(f)
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if onlyPort == 0 || rec.Port == onlyPort {
+			seen[rec.Port] = true
+		}
+	}
+
+	ports := make([]uint16, 0, len(seen))
+	for port := range seen {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports, nil
+}
+
+// replayPackets emits the events of a capture's incoming packets in order,
+// waiting between packets as long as the capture did (divided by speed).
+// Outgoing packets are only printed. It returns the number of packets
+// replayed.
+func replayPackets(ctx context.Context, path string, mgr *Manager, bus *events.EventBus,
+	speed float64, onlyPort uint16) (int, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	parser := protocol.NewGameManagerParser()
+	reader := protocol.NewCaptureReader(f)
+	var last time.Time
+	replayed := 0
+
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return replayed, nil
+		}
+		if err != nil {
+			return replayed, err
+		}
+		if (onlyPort != 0 && rec.Port != onlyPort) || len(rec.Data) == 0 {
+			continue
+		}
+
+		if speed > 0 && !last.IsZero() && rec.Time.After(last) {
+			select {
+			case <-time.After(time.Duration(float64(rec.Time.Sub(last)) / speed)):
+			case <-ctx.Done():
+				fmt.Println("interrupted")
+				return replayed, nil
+			}
+		}
+		last = rec.Time
+		replayed++
+
+		line := fmt.Sprintf("%s  %5d  %-3s  0x%02X", rec.Time.Format("2006-01-02 15:04:05.000"), rec.Port, rec.Direction, rec.Data[0])
+		if rec.Direction == protocol.DirectionOut {
+			fmt.Println(line)
+			continue
+		}
+
+		event, err := parser.Parse(rec.Data)
+		if err != nil {
+			fmt.Printf("%s  %v\n", line, err)
+			continue
+		}
+		fmt.Printf("%s  %s\n", line, event.Type)
+
+		// A connection starts with the announce, which the manager only
+		// expects from a server it has started
+		if rec.Data[0] == protocol.PktServerAnnounce {
+			if inst, ok := mgr.GetInstance(rec.Port); ok {
+				switch inst.State().GetStatus() {
+				case events.GameStatusQueued, events.GameStatusStopped:
+					inst.State().SetStatus(events.GameStatusStarting, energizerCause("capture:connect"))
+				}
+			}
+		}
+
+		if err := bus.EmitSync(ctx, *event); err != nil {
+			log.Debug().Err(err).Str("event", string(event.Type)).Msg("replayed event handler failed")
+		}
+	}
+}
+
+// printTimeline prints the state transitions of one replayed server.
+func printTimeline(port uint16, timeline []Transition) {
+	illegal := 0
+	for _, t := range timeline {
+		if t.Illegal {
+			illegal++
+		}
+	}
+	fmt.Printf("\nport %d: %d transitions, %d illegal\n", port, len(timeline), illegal)
+
+	for _, t := range timeline {
+		mark := ""
+		switch {
+		case t.Rejected:
+			mark = "  REJECTED"
+		case t.Illegal:
+			mark = "  ILLEGAL"
+		}
+		fmt.Printf("  %s  %-6s  %-13s -> %-13s  %s (%s)%s\n",
+			t.Time.Format("15:04:05.000"), t.Field, t.From, t.To, t.Source, t.Actor, mark)
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/energizer-project/energizer/internal/config"
+)
+
+// CgroupLimits describes the cgroup v2 group of one game server and the
+// limits applied to it. Zero values leave a limit unset.
+type CgroupLimits struct {
+	Root          string // cgroup v2 mount point
+	Slice         string // Parent group, relative to Root
+	Name          string // Server group, below Slice
+	MemoryMax     int64  // Bytes
+	CPUWeight     int
+	CPUMaxPercent int // Percentage of one CPU
+	PidsMax       int
+}
+
+// Path returns the absolute path of the server's group.
+func (l *CgroupLimits) Path() string {
+	return filepath.Join(l.Root, l.Slice, l.Name)
+}
+
+// CgroupStats is the resource usage of a game server's cgroup.
+type CgroupStats struct {
+	Path            string  `json:"path"`
+	MemoryCurrentMB float64 `json:"memory_current_mb"`
+	MemoryPeakMB    float64 `json:"memory_peak_mb"` // 0 on kernels without memory.peak
+	MemoryMaxMB     float64 `json:"memory_max_mb"`  // 0 if unlimited
+	SwapCurrentMB   float64 `json:"swap_current_mb"`
+	OOMKills        int     `json:"oom_kills"`
+	CPUUsageSec     float64 `json:"cpu_usage_sec"`
+	CPUThrottledSec float64 `json:"cpu_throttled_sec"`
+	PidsCurrent     int     `json:"pids_current"`
+	PidsMax         int     `json:"pids_max"` // 0 if unlimited
+}
+
+// cgroupLimitsFor returns the cgroup of the server on the given port, or nil
+// if cgroups are disabled or unsupported on this platform.
+func cgroupLimitsFor(cfg config.CgroupConfig, port uint16) *CgroupLimits {
+	if !cfg.Enabled || runtime.GOOS != "linux" {
+		return nil
+	}
+	return &CgroupLimits{
+		Root:          cfg.Root,
+		Slice:         cfg.Slice,
+		Name:          fmt.Sprintf("server-%d", port),
+		MemoryMax:     int64(cfg.MemoryMaxMB) * 1024 * 1024,
+		CPUWeight:     cfg.CPUWeight,
+		CPUMaxPercent: cfg.CPUMaxPercent,
+		PidsMax:       cfg.PidsMax,
+	}
+}
+
+// enterCgroup moves the started process into its cgroup. If the cgroup cannot
+// be set up, the server keeps running without limits. Must be called with
+// pm.mu held.
+func (pm *ProcessManager) enterCgroup() {
+	pm.cgroupPath = ""
+	if pm.cgroup == nil {
+		return
+	}
+
+	path := pm.cgroup.Path()
+	if err := joinCgroup(pm.cgroup, pm.pid); err != nil {
+		pm.logger.Warn().
+			Err(err).
+			Str("cgroup", path).
+			Msg("cgroup unavailable, game server runs without resource limits")
+		return
+	}
+
+	pm.cgroupPath = path
+	pm.logger.Info().
+		Int("pid", pm.pid).
+		Str("cgroup", path).
+		Msg("game server placed in cgroup")
+}
+
+// leaveCgroup removes the cgroup of an exited process. Must be called with
+// pm.mu held.
+func (pm *ProcessManager) leaveCgroup() {
+	if pm.cgroupPath == "" {
+		return
+	}
+	// Fails harmlessly if leftover child processes still populate the group;
+	// the next start reuses it
+	if err := removeCgroup(pm.cgroupPath); err != nil {
+		pm.logger.Debug().Err(err).Str("cgroup", pm.cgroupPath).Msg("failed to remove cgroup")
+	}
+	pm.cgroupPath = ""
+}
+
+// CgroupStats returns the resource usage of the process's cgroup, or nil if
+// it does not run in one.
+func (pm *ProcessManager) CgroupStats() *CgroupStats {
+	pm.mu.Lock()
+	path := pm.cgroupPath
+	pm.mu.Unlock()
+
+	if path == "" {
+		return nil
+	}
+	stats, err := readCgroupStats(path)
+	if err != nil {
+		return nil
+	}
+	return stats
+}
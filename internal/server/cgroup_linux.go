@@ -0,0 +1,192 @@
+//go:build linux
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cpuMaxPeriod is the cpu.max period in microseconds (kernel default).
+const cpuMaxPeriod = 100000
+
+// joinCgroup creates the server's group below the Energizer slice, applies
+// its limits and moves pid into it. The process briefly runs outside the
+// group between exec and this call; the game server does not fork, so
+// nothing escapes the limits.
+func joinCgroup(limits *CgroupLimits, pid int) error {
+	if _, err := os.Stat(filepath.Join(limits.Root, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("no cgroup v2 hierarchy at %s: %w", limits.Root, err)
+	}
+
+	path := limits.Path()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return fmt.Errorf("failed to create cgroup: %w", err)
+	}
+
+	// Controllers must be enabled in every ancestor for the limit files
+	// to appear in the server's group
+	var controllers []string
+	if limits.MemoryMax > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if limits.CPUWeight > 0 || limits.CPUMaxPercent > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if limits.PidsMax > 0 {
+		controllers = append(controllers, "pids")
+	}
+	if len(controllers) > 0 {
+		dir := limits.Root
+		for _, part := range strings.Split(filepath.Clean(limits.Slice), string(filepath.Separator)) {
+			if err := enableControllers(dir, controllers); err != nil {
+				return err
+			}
+			dir = filepath.Join(dir, part)
+		}
+		if err := enableControllers(dir, controllers); err != nil {
+			return err
+		}
+	}
+
+	writes := []struct {
+		file, value string
+		set         bool
+	}{
+		{"memory.max", strconv.FormatInt(limits.MemoryMax, 10), limits.MemoryMax > 0},
+		// Without swap headroom a leaking server is OOM-killed at the limit
+		// instead of pushing the host into swap
+		{"memory.swap.max", "0", limits.MemoryMax > 0},
+		{"cpu.weight", strconv.Itoa(limits.CPUWeight), limits.CPUWeight > 0},
+		{"cpu.max", fmt.Sprintf("%d %d", limits.CPUMaxPercent*cpuMaxPeriod/100, cpuMaxPeriod), limits.CPUMaxPercent > 0},
+		{"pids.max", strconv.Itoa(limits.PidsMax), limits.PidsMax > 0},
+	}
+	for _, w := range writes {
+		if !w.set {
+			continue
+		}
+		err := os.WriteFile(filepath.Join(path, w.file), []byte(w.value), 0)
+		if err != nil && !(w.file == "memory.swap.max" && os.IsNotExist(err)) {
+			return fmt.Errorf("failed to set %s: %w", w.file, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(path, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0); err != nil {
+		return fmt.Errorf("failed to move process into cgroup: %w", err)
+	}
+	return nil
+}
+
+// enableControllers enables the given controllers for the children of dir,
+// writing only those not already enabled so that an unprivileged user can
+// use a delegated subtree.
+func enableControllers(dir string, controllers []string) error {
+	file := filepath.Join(dir, "cgroup.subtree_control")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	enabled := make(map[string]bool)
+	for _, c := range strings.Fields(string(data)) {
+		enabled[c] = true
+	}
+
+	var missing []string
+	for _, c := range controllers {
+		if !enabled[c] {
+			missing = append(missing, "+"+c)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if err := os.WriteFile(file, []byte(strings.Join(missing, " ")), 0); err != nil {
+		return fmt.Errorf("failed to enable %s in %s: %w", strings.Join(missing, " "), dir, err)
+	}
+	return nil
+}
+
+// removeCgroup removes an empty cgroup.
+func removeCgroup(path string) error {
+	return os.Remove(path)
+}
+
+// readCgroupStats reads the usage counters of a cgroup. Files of controllers
+// that are not enabled are skipped.
+func readCgroupStats(path string) (*CgroupStats, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	const mb = 1024 * 1024
+	stats := &CgroupStats{Path: path}
+
+	if v, ok := readCgroupValue(path, "memory.current"); ok {
+		stats.MemoryCurrentMB = float64(v) / mb
+	}
+	if v, ok := readCgroupValue(path, "memory.peak"); ok {
+		stats.MemoryPeakMB = float64(v) / mb
+	}
+	if v, ok := readCgroupValue(path, "memory.max"); ok {
+		stats.MemoryMaxMB = float64(v) / mb
+	}
+	if v, ok := readCgroupValue(path, "memory.swap.current"); ok {
+		stats.SwapCurrentMB = float64(v) / mb
+	}
+	if v, ok := readCgroupKeyed(path, "memory.events")["oom_kill"]; ok {
+		stats.OOMKills = int(v)
+	}
+
+	cpu := readCgroupKeyed(path, "cpu.stat")
+	stats.CPUUsageSec = float64(cpu["usage_usec"]) / 1e6
+	stats.CPUThrottledSec = float64(cpu["throttled_usec"]) / 1e6
+
+	if v, ok := readCgroupValue(path, "pids.current"); ok {
+		stats.PidsCurrent = int(v)
+	}
+	if v, ok := readCgroupValue(path, "pids.max"); ok {
+		stats.PidsMax = int(v)
+	}
+
+	return stats, nil
+}
+
+// readCgroupValue reads a single-value cgroup file. "max" (unlimited) and
+// missing files report false.
+func readCgroupValue(dir, file string) (int64, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// readCgroupKeyed reads a flat-keyed cgroup file ("key value" per line).
+func readCgroupKeyed(dir, file string) map[string]int64 {
+	values := make(map[string]int64)
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values
+}
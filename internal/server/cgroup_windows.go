@@ -0,0 +1,20 @@
+//go:build windows
+
+package server
+
+import "fmt"
+
+// joinCgroup is not supported on Windows.
+func joinCgroup(limits *CgroupLimits, pid int) error {
+	return fmt.Errorf("cgroups not supported on Windows")
+}
+
+// removeCgroup is a no-op on Windows.
+func removeCgroup(path string) error {
+	return nil
+}
+
+// readCgroupStats is not supported on Windows.
+func readCgroupStats(path string) (*CgroupStats, error) {
+	return nil, fmt.Errorf("cgroups not supported on Windows")
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/db"
+)
+
+// CommandPolicy decides which raw console commands may be sent to game
+// servers (see config.RemoteCommandConfig).
+type CommandPolicy struct {
+	enabled bool
+	allow   []commandPattern
+	deny    []commandPattern
+}
+
+// commandPattern is a configured pattern and its anchored, case-insensitive
+// compiled form, in which "." also matches newlines.
+type commandPattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// CommandRequest is a raw console command for one game server.
+type CommandRequest struct {
+	Port    uint16
+	Command string
+	User    string // Who sent the command, for the audit trail
+	Source  string // "api", "cli", ...
+}
+
+// CommandRejectedError is returned when the command policy refuses a command.
+type CommandRejectedError struct {
+	Reason string
+}
+
+func (e *CommandRejectedError) Error() string {
+	return "command rejected: " + e.Reason
+}
+
+// NewCommandPolicy compiles the patterns in cfg.
+func NewCommandPolicy(cfg config.RemoteCommandConfig) (*CommandPolicy, error) {
+	p := &CommandPolicy{enabled: cfg.Enabled}
+
+	compile := func(patterns []string) ([]commandPattern, error) {
+		var out []commandPattern
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(`(?is)^(?:` + pattern + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("invalid command pattern %q: %w", pattern, err)
+			}
+			out = append(out, commandPattern{pattern: pattern, re: re})
+		}
+		return out, nil
+	}
+
+	var err error
+	if p.allow, err = compile(cfg.Allow); err != nil {
+		return nil, err
+	}
+	if p.deny, err = compile(cfg.Deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Check returns a *CommandRejectedError if the command may not be sent.
+// The game console runs every command of a line separated by ";" and every
+// line, so those separators are refused: the patterns could otherwise be
+// bypassed by chaining a denied command after an allowed one.
+func (p *CommandPolicy) Check(command string) error {
+	if !p.enabled {
+		return &CommandRejectedError{Reason: "remote commands are disabled"}
+	}
+	if strings.ContainsAny(command, ";\r\n") {
+		return &CommandRejectedError{Reason: "command must be a single command without ';' or line breaks"}
+	}
+	for _, deny := range p.deny {
+		if deny.re.MatchString(command) {
+			return &CommandRejectedError{Reason: fmt.Sprintf("command matches deny pattern %q", deny.pattern)}
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, allow := range p.allow {
+		if allow.re.MatchString(command) {
+			return nil
+		}
+	}
+	return &CommandRejectedError{Reason: "command matches no allow pattern"}
+}
+
+// SetCommandAudit sets the database that console commands are recorded in.
+// Without it, commands are only logged.
+func (m *Manager) SetCommandAudit(audit *db.AuditDatabase) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commandAudit = audit
+}
+
+// SendCommand sends a raw console command to a game server if the command
+// policy allows it. Every invocation, allowed or not, is logged and recorded
+// in the audit trail.
+func (m *Manager) SendCommand(req CommandRequest) error {
+	req.Command = strings.TrimSpace(req.Command)
+
+	err := m.sendCommand(req)
+
+	entry := db.CommandAudit{
+		Time:    time.Now(),
+		User:    req.User,
+		Source:  req.Source,
+		Port:    req.Port,
+		Command: req.Command,
+		Allowed: true,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		if _, rejected := err.(*CommandRejectedError); rejected {
+			entry.Allowed = false
+		}
+	}
+
+	logEvent := log.Info()
+	if err != nil {
+		logEvent = log.Warn().Err(err)
+	}
+	logEvent.
+		Uint16("port", req.Port).
+		Str("user", req.User).
+		Str("source", req.Source).
+		Str("command", req.Command).
+		Msg("console command")
+
+	m.mu.RLock()
+	audit := m.commandAudit
+	m.mu.RUnlock()
+	if audit != nil {
+		if auditErr := audit.RecordCommand(&entry); auditErr != nil {
+			log.Error().Err(auditErr).Msg("failed to record console command in audit trail")
+		}
+	}
+
+	return err
+}
+
+func (m *Manager) sendCommand(req CommandRequest) error {
+	if req.Command == "" {
+		return &CommandRejectedError{Reason: "empty command"}
+	}
+
+	policy, err := NewCommandPolicy(m.cfg.GetApplicationData().RemoteCommands)
+	if err != nil {
+		return err
+	}
+	if err := policy.Check(req.Command); err != nil {
+		return err
+	}
+
+	conn, ok := m.connRegistry.Get(req.Port)
+	if !ok {
+		return fmt.Errorf("no connection for port %d", req.Port)
+	}
+	return conn.SendCommand(req.Command)
+}
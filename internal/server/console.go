@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/util"
+)
+
+// Console output streams.
+const (
+	ConsoleStdout = "stdout"
+	ConsoleStderr = "stderr"
+)
+
+// consoleSubscriberBuffer is how many lines a slow subscriber may fall behind
+// before lines are dropped for it.
+const consoleSubscriberBuffer = 256
+
+// ConsoleLine is one line of game server console output.
+type ConsoleLine struct {
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream"` // "stdout" or "stderr"
+	Text   string    `json:"text"`
+}
+
+// Console collects a game server's console output across restarts. Lines are
+// appended to a log file, the most recent ones are kept in memory, and every
+// line is passed to live subscribers.
+type Console struct {
+	mu   sync.Mutex
+	file io.WriteCloser // nil if the log file could not be opened
+
+	// Ring buffer of the most recent lines
+	lines []ConsoleLine
+	next  int
+	count int
+
+	subscribers map[chan ConsoleLine]struct{}
+}
+
+// NewConsole creates a console keeping bufferLines lines in memory and
+// writing to file, which may be nil.
+func NewConsole(file io.WriteCloser, bufferLines int) *Console {
+	if bufferLines < 1 {
+		bufferLines = 1
+	}
+	return &Console{
+		file:        file,
+		lines:       make([]ConsoleLine, bufferLines),
+		subscribers: make(map[chan ConsoleLine]struct{}),
+	}
+}
+
+// newInstanceConsole creates the console of the server on the given port,
+// logging to <directory>/servers/server-<port>.log. Console output is only
+// captured on Linux; on Windows each server has its own console window.
+func newInstanceConsole(cfg config.LoggingConfig, port uint16) *Console {
+	var file io.WriteCloser
+	if runtime.GOOS == "linux" {
+		rf, err := util.NewRotatingFile(consoleLogPath(cfg, port), cfg.MaxSizeMB, cfg.MaxBackups)
+		if err != nil {
+			log.Warn().Err(err).Uint16("port", port).Msg("failed to open console log, keeping console output in memory only")
+		} else {
+			file = rf
+		}
+	}
+	return NewConsole(file, cfg.ConsoleBufferLines)
+}
+
+// consoleLogPath returns the console log file of the server on the given port.
+func consoleLogPath(cfg config.LoggingConfig, port uint16) string {
+	return filepath.Join(cfg.Directory, "servers", fmt.Sprintf("server-%d.log", port))
+}
+
+// Capture reads lines from r until it is closed and records them under
+// stream. It is run in its own goroutine for each output pipe.
+func (c *Console) Capture(r io.ReadCloser, stream string) {
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		c.Append(stream, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		log.Debug().Err(err).Str("stream", stream).Msg("console capture stopped")
+	}
+}
+
+// Append records one line.
+func (c *Console) Append(stream, text string) {
+	line := ConsoleLine{Time: time.Now(), Stream: stream, Text: text}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lines[c.next] = line
+	c.next = (c.next + 1) % len(c.lines)
+	if c.count < len(c.lines) {
+		c.count++
+	}
+
+	if c.file != nil {
+		fmt.Fprintf(c.file, "%s [%s] %s\n", line.Time.Format(time.RFC3339Nano), stream, text)
+	}
+
+	for ch := range c.subscribers {
+		select {
+		case ch <- line:
+		default:
+			// Drop rather than block the game server's output
+		}
+	}
+}
+
+// Lines returns up to n of the most recent lines, oldest first. n <= 0
+// returns all buffered lines.
+func (c *Console) Lines(n int) []ConsoleLine {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.linesLocked(n)
+}
+
+func (c *Console) linesLocked(n int) []ConsoleLine {
+	if n <= 0 || n > c.count {
+		n = c.count
+	}
+	out := make([]ConsoleLine, n)
+	start := (c.next - n + len(c.lines)) % len(c.lines)
+	for idx := range out {
+		out[idx] = c.lines[(start+idx)%len(c.lines)]
+	}
+	return out
+}
+
+// Subscribe returns up to backlog of the most recent lines and a channel
+// receiving every line appended afterwards, with no gap between the two.
+// The returned function unsubscribes and closes the channel.
+func (c *Console) Subscribe(backlog int) ([]ConsoleLine, <-chan ConsoleLine, func()) {
+	ch := make(chan ConsoleLine, consoleSubscriberBuffer)
+
+	c.mu.Lock()
+	lines := c.linesLocked(backlog)
+	c.subscribers[ch] = struct{}{}
+	c.mu.Unlock()
+
+	var once sync.Once
+	return lines, ch, func() {
+		once.Do(func() {
+			c.mu.Lock()
+			delete(c.subscribers, ch)
+			c.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Close closes the log file. The in-memory lines remain available.
+func (c *Console) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
@@ -3,7 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
-	"os/exec"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
@@ -12,6 +12,37 @@ import (
 
 	"github.com/energizer-project/energizer/internal/config"
 	"github.com/energizer-project/energizer/internal/events"
+	"github.com/energizer-project/energizer/internal/network"
+	"github.com/energizer-project/energizer/internal/util"
+)
+
+// CowMasterPort is the port the CowMaster announces itself with (0x40) when it
+// connects to the manager port. It has no game port of its own.
+const CowMasterPort uint16 = 0
+
+// cowMasterForkTimeout is how long a fork request waits for the CowMaster's
+// 0x49 response before the server is started with a normal exec instead.
+const cowMasterForkTimeout = 5 * time.Second
+
+// cowMasterMaxTimeouts is how many fork requests in a row may time out before
+// the CowMaster is considered unable to fork, so that servers are exec'd
+// straight away instead of each waiting cowMasterForkTimeout first.
+const cowMasterMaxTimeouts = 3
+
+// poolForkPort is the placeholder port children of the warm pool are forked
+// for; they get their real port when taken from the pool. It differs from
+// CowMasterPort so that responses for pool forks cannot be confused with
+// anything the CowMaster sends about itself, and is above any game port in
+// use (proxy ports are game ports + 10000).
+const poolForkPort uint16 = 65535
+
+// CowMaster console commands. They are sent like any other console command,
+// as 0x50 packets over the CowMaster's manager-port connection, and the
+// CowMaster answers each with a 0x49 response for the same port carrying
+// the PID of the child.
+const (
+	cowMasterForkCommand   = "fork %d"      // Fork a child for a game port
+	cowMasterAssignCommand = "assign %d %d" // Move pooled child <pid> to a game port
 )
 
 // CowMaster manages a pre-loaded "master" game server process on Linux.
@@ -19,24 +50,45 @@ import (
 // copy-on-write (CoW) fork to create new server instances, resulting in
 // near-instant startup and significantly reduced RAM usage.
 //
+// Fork requests are console commands sent over the CowMaster's manager-port
+// connection and answered with a 0x49 response for the same port, carrying
+// the PID of the forked server.
+//
+// With man_cowmaster_pool_size set, the CowMaster also keeps a warm pool of
+// idle children forked for a placeholder port. Starting a server then only
+// assigns a port to one of them, and the pool is refilled in the background.
+//
 // This is a Linux-only feature.
 type CowMaster struct {
 	mu sync.Mutex
 
-	cfg      *config.Config
-	eventBus *events.EventBus
+	cfg          *config.Config
+	eventBus     *events.EventBus
+	connRegistry *network.ConnectionRegistry
 
 	// Process state
 	process  *ProcessManager
 	ready    bool
 	lastFork time.Time
+	timeouts int // Fork requests in a row that were not answered
+
+	// Fork requests waiting for their 0x49 response, by game port
+	pending map[uint16]chan events.CowMasterResponsePayload
+
+	// Warm pool of idle pre-forked children (PIDs, oldest first)
+	pool   []int
+	refill chan struct{}
 }
 
-// NewCowMaster creates a new CowMaster instance.
-func NewCowMaster(cfg *config.Config, eventBus *events.EventBus) *CowMaster {
+// NewCowMaster creates a new CowMaster instance. Fork requests are sent over
+// the CowMaster's connection in connRegistry.
+func NewCowMaster(cfg *config.Config, eventBus *events.EventBus, connRegistry *network.ConnectionRegistry) *CowMaster {
 	return &CowMaster{
-		cfg:      cfg,
-		eventBus: eventBus,
+		cfg:          cfg,
+		eventBus:     eventBus,
+		connRegistry: connRegistry,
+		pending:      make(map[uint16]chan events.CowMasterResponsePayload),
+		refill:       make(chan struct{}, 1),
 	}
 }
 
@@ -45,7 +97,7 @@ func (cm *CowMaster) IsSupported() bool {
 	return runtime.GOOS == "linux"
 }
 
-// Start launches the CowMaster process.
+// Start launches the CowMaster process and restarts it if it dies.
 func (cm *CowMaster) Start(ctx context.Context) error {
 	if !cm.IsSupported() {
 		return fmt.Errorf("CowMaster is only supported on Linux")
@@ -55,6 +107,18 @@ func (cm *CowMaster) Start(ctx context.Context) error {
 		return fmt.Errorf("CowMaster is disabled in configuration")
 	}
 
+	if err := cm.launch(ctx); err != nil {
+		return err
+	}
+
+	// Monitor process health and keep the warm pool filled
+	go cm.monitor(ctx)
+	go cm.poolLoop(ctx)
+	return nil
+}
+
+// launch starts the CowMaster process.
+func (cm *CowMaster) launch(ctx context.Context) error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
@@ -88,7 +152,10 @@ func (cm *CowMaster) Start(ctx context.Context) error {
 			"-register", fmt.Sprintf("127.0.0.1:%d", honData.ManagerPort),
 		},
 		WorkDir: honData.InstallDirectory,
-		Port:    0, // CowMaster doesn't use a game port
+		Port:    CowMasterPort,
+		// Forked servers inherit the CowMaster's stdout and stderr, so their
+		// console output is appended to this file too
+		ConsoleFile: filepath.Join(cm.cfg.GetApplicationData().Logging.Directory, "servers", "cowmaster.log"),
 	}
 
 	cm.process = NewProcessManager(procCfg)
@@ -100,12 +167,7 @@ func (cm *CowMaster) Start(ctx context.Context) error {
 	}
 
 	cm.ready = true
-
-	// Subscribe to fork requests
-	cm.eventBus.Subscribe(events.EventForkFromCowMaster, "cowmaster.fork", cm.handleForkRequest)
-
-	// Monitor process health
-	go cm.monitor(ctx)
+	cm.timeouts = 0
 
 	log.Info().Int("pid", cm.process.PID()).Msg("CowMaster started")
 	return nil
@@ -117,6 +179,7 @@ func (cm *CowMaster) Stop() error {
 	defer cm.mu.Unlock()
 
 	cm.ready = false
+	cm.drainPoolLocked()
 
 	if cm.process != nil {
 		return cm.process.Stop()
@@ -124,38 +187,149 @@ func (cm *CowMaster) Stop() error {
 	return nil
 }
 
-// IsReady returns whether the CowMaster is ready to fork.
-func (cm *CowMaster) IsReady() bool {
+// Relaunch replaces the CowMaster process, e.g. after the install it runs
+// from has changed. Servers already forked from it keep running.
+func (cm *CowMaster) Relaunch(ctx context.Context) error {
 	cm.mu.Lock()
-	defer cm.mu.Unlock()
-	return cm.ready && cm.process != nil && cm.process.IsRunning()
+	cm.ready = false
+	cm.drainPoolLocked()
+	proc := cm.process
+	cm.mu.Unlock()
+
+	if proc != nil {
+		if err := proc.Stop(); err != nil {
+			log.Warn().Err(err).Msg("failed to stop CowMaster for relaunch")
+		}
+	}
+	if err := cm.launch(ctx); err != nil {
+		return err
+	}
+	cm.triggerRefill()
+	return nil
 }
 
-// Fork requests the CowMaster to fork a new game server instance.
-func (cm *CowMaster) Fork(port uint16) error {
+// IsReady returns whether the CowMaster is running and connected to the
+// manager, so that it can fork.
+func (cm *CowMaster) IsReady() bool {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
+	return cm.isReadyLocked()
+}
 
-	if !cm.ready || cm.process == nil || !cm.process.IsRunning() {
-		return fmt.Errorf("CowMaster is not ready")
+func (cm *CowMaster) isReadyLocked() bool {
+	if !cm.ready || cm.process == nil || !cm.process.IsRunning() || cm.timeouts >= cowMasterMaxTimeouts {
+		return false
 	}
+	_, connected := cm.connRegistry.Get(CowMasterPort)
+	return connected
+}
 
-	// Send fork command via the manager port TCP connection
-	// The CowMaster process listens for fork commands on the same protocol
-	log.Info().Uint16("port", port).Msg("requesting CowMaster fork")
+// WaitReady waits up to timeout for the CowMaster to connect to the manager.
+func (cm *CowMaster) WaitReady(ctx context.Context, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !cm.IsReady() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline:
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// Fork returns the PID of a game server for port: an idle child from the warm
+// pool if there is one, or a newly forked one. It fails if the CowMaster is
+// not ready, refuses the fork, or does not answer within cowMasterForkTimeout.
+func (cm *CowMaster) Fork(port uint16) (int, error) {
+	if pid, ok := cm.takeFromPool(port); ok {
+		return pid, nil
+	}
+	return cm.request(port, fmt.Sprintf(cowMasterForkCommand, port))
+}
+
+// request sends a console command to the CowMaster and waits for the 0x49
+// response for port, returning the PID it reports.
+func (cm *CowMaster) request(port uint16, command string) (int, error) {
+	cm.mu.Lock()
+	if !cm.isReadyLocked() {
+		cm.mu.Unlock()
+		return 0, fmt.Errorf("CowMaster is not ready")
+	}
+	if _, busy := cm.pending[port]; busy {
+		cm.mu.Unlock()
+		return 0, fmt.Errorf("fork for port %d already in progress", port)
+	}
+	conn, _ := cm.connRegistry.Get(CowMasterPort)
+	respCh := make(chan events.CowMasterResponsePayload, 1)
+	cm.pending[port] = respCh
 	cm.lastFork = time.Now()
+	cm.mu.Unlock()
 
-	return nil
+	defer func() {
+		cm.mu.Lock()
+		delete(cm.pending, port)
+		cm.mu.Unlock()
+	}()
+
+	log.Info().Uint16("port", port).Str("command", command).Msg("requesting CowMaster fork")
+
+	if err := conn.SendCommand(command); err != nil {
+		return 0, fmt.Errorf("failed to send fork request: %w", err)
+	}
+
+	select {
+	case resp := <-respCh:
+		cm.mu.Lock()
+		cm.timeouts = 0
+		cm.mu.Unlock()
+		if !resp.Success {
+			return 0, fmt.Errorf("CowMaster failed to fork server on port %d", port)
+		}
+		if resp.PID <= 0 {
+			return 0, fmt.Errorf("CowMaster reported invalid pid %d for port %d", resp.PID, port)
+		}
+		return resp.PID, nil
+	case <-time.After(cowMasterForkTimeout):
+		cm.mu.Lock()
+		cm.timeouts++
+		if cm.timeouts == cowMasterMaxTimeouts {
+			log.Error().
+				Int("timeouts", cm.timeouts).
+				Msg("CowMaster does not answer fork requests, launching servers normally until it is relaunched")
+		}
+		cm.mu.Unlock()
+		return 0, fmt.Errorf("timed out after %s waiting for CowMaster fork response", cowMasterForkTimeout)
+	}
 }
 
-// handleForkRequest handles fork request events.
-func (cm *CowMaster) handleForkRequest(ctx context.Context, event events.Event) error {
-	payload, ok := event.Payload.(events.ServerCommandPayload)
-	if !ok {
-		return nil
+// HandleResponse delivers a 0x49 response to the fork request waiting for
+// its port. A successful fork nobody is waiting for any more (the request
+// timed out and the server was exec'd instead) is killed so that two servers
+// do not compete for the port.
+func (cm *CowMaster) HandleResponse(resp events.CowMasterResponsePayload) {
+	cm.mu.Lock()
+	respCh, ok := cm.pending[resp.Port]
+	delete(cm.pending, resp.Port)
+	cm.mu.Unlock()
+
+	if ok {
+		respCh <- resp
+		return
 	}
 
-	return cm.Fork(payload.Port)
+	log.Warn().
+		Uint16("port", resp.Port).
+		Bool("success", resp.Success).
+		Int("pid", resp.PID).
+		Msg("unexpected CowMaster fork response")
+	if resp.Success && resp.PID > 0 {
+		terminateProcessPlatform(resp.PID)
+	}
 }
 
 // monitor watches the CowMaster process and restarts it if it dies.
@@ -172,12 +346,13 @@ func (cm *CowMaster) monitor(ctx context.Context) {
 			if cm.ready && cm.process != nil && !cm.process.IsRunning() {
 				log.Warn().Msg("CowMaster process died, restarting...")
 				cm.ready = false
+				cm.drainPoolLocked()
 				cm.mu.Unlock()
 
 				// Brief delay before restart
 				time.Sleep(5 * time.Second)
 
-				if err := cm.Start(ctx); err != nil {
+				if err := cm.launch(ctx); err != nil {
 					log.Error().Err(err).Msg("failed to restart CowMaster")
 				}
 			} else {
@@ -187,49 +362,160 @@ func (cm *CowMaster) monitor(ctx context.Context) {
 	}
 }
 
-// GetMemoryUsage returns the memory usage of the CowMaster process.
-func (cm *CowMaster) GetMemoryUsage() (float64, error) {
+// PoolSize returns the number of idle children in the warm pool.
+func (cm *CowMaster) PoolSize() int {
 	cm.mu.Lock()
-	proc := cm.process
-	cm.mu.Unlock()
+	defer cm.mu.Unlock()
+	return len(cm.pool)
+}
 
-	if proc == nil {
-		return 0, fmt.Errorf("CowMaster not running")
+// PID returns the PID of the CowMaster process, or 0 if it is not running.
+func (cm *CowMaster) PID() int {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.process == nil || !cm.process.IsRunning() {
+		return 0
 	}
+	return cm.process.PID()
+}
 
-	return proc.GetMemoryMB()
+// poolPIDs returns the PIDs of the idle children in the warm pool.
+func (cm *CowMaster) poolPIDs() []int {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return append([]int(nil), cm.pool...)
 }
 
-// ForkWithExec is a fallback that uses exec instead of CoW fork.
-// Used when the CowMaster process isn't available.
-func ForkWithExec(cfg *config.Config, port uint16) (*exec.Cmd, error) {
-	honData := cfg.GetHoNData()
+// takeFromPool assigns port to an idle child from the warm pool and returns
+// its PID. Children that died or do not confirm the assignment are dropped.
+func (cm *CowMaster) takeFromPool(port uint16) (int, bool) {
+	for {
+		cm.mu.Lock()
+		if len(cm.pool) == 0 {
+			cm.mu.Unlock()
+			return 0, false
+		}
+		pid := cm.pool[0]
+		cm.pool = cm.pool[1:]
+		cm.mu.Unlock()
 
-	exeName := honData.ExecutableName
-	if exeName == "" {
-		exeName = getServerExecutable()
+		cm.triggerRefill()
+
+		if !util.IsProcessRunning(pid) {
+			log.Warn().Int("pid", pid).Msg("pre-forked CowMaster child is gone, dropping it")
+			continue
+		}
+
+		got, err := cm.request(port, fmt.Sprintf(cowMasterAssignCommand, pid, port))
+		if err != nil || got != pid {
+			log.Warn().
+				Err(err).
+				Int("pid", pid).
+				Uint16("port", port).
+				Msg("pre-forked CowMaster child did not take its port, dropping it")
+			terminateProcessPlatform(pid)
+			return 0, false
+		}
+
+		log.Info().Int("pid", pid).Uint16("port", port).Msg("game server taken from CowMaster warm pool")
+		return pid, true
 	}
+}
 
-	masterURL := honData.MasterServerURL
-	if masterURL == "" {
-		masterURL = "api.kongor.net"
+// triggerRefill wakes poolLoop without blocking.
+func (cm *CowMaster) triggerRefill() {
+	select {
+	case cm.refill <- struct{}{}:
+	default:
+	}
+}
+
+// poolLoop keeps the warm pool at man_cowmaster_pool_size, topping it up
+// whenever a child is taken and periodically after failures.
+func (cm *CowMaster) poolLoop(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		cm.fillPool()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-cm.refill:
+		case <-ticker.C:
+		}
 	}
+}
+
+// fillPool forks idle children until the pool is full. Pool forks use the
+// same 0x49 correlation as server forks, keyed by poolForkPort, so they run
+// one at a time.
+func (cm *CowMaster) fillPool() {
+	for {
+		size := cm.cfg.GetHoNData().CowMasterPoolSize
+
+		cm.mu.Lock()
+		// Shrink if the configured size went down
+		for len(cm.pool) > size {
+			last := cm.pool[len(cm.pool)-1]
+			cm.pool = cm.pool[:len(cm.pool)-1]
+			terminateProcessPlatform(last)
+		}
+		full := len(cm.pool) >= size
+		ready := cm.isReadyLocked()
+		cm.mu.Unlock()
+
+		if full || !ready {
+			return
+		}
+
+		pid, err := cm.request(poolForkPort, fmt.Sprintf(cowMasterForkCommand, poolForkPort))
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to pre-fork CowMaster child for the warm pool")
+			return
+		}
 
-	cmd := exec.Command(
-		fmt.Sprintf("%s/%s", honData.InstallDirectory, exeName),
-		"-dedicated",
-		"-mod game;KONGOR",
-		"-noconfig",
-		"-execute", fmt.Sprintf("\"Set svr_port %d;Set svr_login %s:;Set svr_password %s\"",
-			port, honData.Login, honData.Password),
-		"-masterserver", masterURL,
-		"-register", fmt.Sprintf("127.0.0.1:%d", honData.ManagerPort),
-	)
-	cmd.Dir = honData.InstallDirectory
+		cm.mu.Lock()
+		cm.pool = append(cm.pool, pid)
+		idle := len(cm.pool)
+		cm.mu.Unlock()
 
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to fork server on port %d: %w", port, err)
+		log.Debug().Int("pid", pid).Int("idle", idle).Msg("CowMaster child added to warm pool")
 	}
+}
+
+// drainPoolLocked kills the idle children in the warm pool. Caller must hold
+// cm.mu.
+func (cm *CowMaster) drainPoolLocked() {
+	for _, pid := range cm.pool {
+		terminateProcessPlatform(pid)
+	}
+	cm.pool = nil
+}
 
-	return cmd, nil
+// SetCowMaster makes the manager's servers fork from cm when they start.
+func (m *Manager) SetCowMaster(cm *CowMaster) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cowMaster = cm
+	for _, inst := range m.servers {
+		inst.mu.Lock()
+		inst.cowMaster = cm
+		inst.mu.Unlock()
+	}
+}
+
+// GetMemoryUsage returns the memory usage of the CowMaster process.
+func (cm *CowMaster) GetMemoryUsage() (float64, error) {
+	cm.mu.Lock()
+	proc := cm.process
+	cm.mu.Unlock()
+
+	if proc == nil {
+		return 0, fmt.Errorf("CowMaster not running")
+	}
+
+	return proc.GetMemoryMB()
 }
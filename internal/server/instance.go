@@ -2,8 +2,9 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
+	"net"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -19,11 +20,7 @@ import (
 )
 
 const (
-	// MinRestartInterval is the minimum time between automatic restarts.
-	MinRestartInterval = 24 * time.Hour
-	// MaxRestartInterval is the maximum time between automatic restarts.
-	MaxRestartInterval = 48 * time.Hour
-	// IdleKickDelay is the time to wait before kicking idle players after game ends.
+	// IdleKickDelay is the default time to wait before kicking idle players after game ends.
 	IdleKickDelay = 60 * time.Second
 	// LagWarningThreshold is the number of lag events before warning.
 	LagWarningThreshold = 10
@@ -31,6 +28,29 @@ const (
 	LagCriticalThreshold = 30
 )
 
+// DrainAction is what a draining instance does once its current match ends.
+type DrainAction int
+
+const (
+	// DrainRestart restarts the server process once it is idle.
+	DrainRestart DrainAction = iota
+	// DrainStop stops the server process once it is idle.
+	DrainStop
+	// DrainSleep puts the server to sleep once it is idle.
+	DrainSleep
+)
+
+// String returns the string representation of DrainAction.
+func (a DrainAction) String() string {
+	switch a {
+	case DrainStop:
+		return "stop"
+	case DrainSleep:
+		return "sleep"
+	}
+	return "restart"
+}
+
 // Instance represents a single HoN game server instance.
 // It manages the server's process, tracks its state, handles events,
 // and implements auto-restart, lag monitoring, and idle player management.
@@ -46,15 +66,62 @@ type Instance struct {
 	cpuAffinity []int32
 
 	// State
-	state   *GameState
-	process *ProcessManager
-	enabled bool
-
-	// Auto-restart
-	nextRestart time.Time
+	state     *GameState
+	process   *ProcessManager
+	console   *Console
+	cowMaster *CowMaster // Forks the process when ready (nil: always exec)
+	forked    bool       // The running process was forked from the CowMaster
+	forking   bool       // Start is waiting for a CowMaster fork, without i.mu
+	enabled   bool
+
+	// Set when the configuration changed in a way the running process only
+	// picks up when restarted (see Manager.ReconcileConfig)
+	configStale bool
+
+	// Runs the candidate install during a blue/green rollout (see Rollout)
+	candidate bool
+
+	// Periodic restart (see RestartPolicy)
+	restartPolicy *RestartPolicy
+	matchesPlayed int     // Matches completed since the process started
+	memBaselineMB float64 // RSS when first seen READY
+	memLastMB     float64 // RSS at the last restart check
+
+	// Crash handling
+	stopRequested bool        // Set by Stop() so a racing exit is not treated as a crash
+	crashTimes    []time.Time // Crashes within the quarantine window
+	quarantined   bool
+	restartTimer  *time.Timer // Pending backoff restart, if any
+
+	// Drain mode: finish the current match, then restart or stop
+	draining    bool
+	drainActing bool // The drain action is in progress
+	drainAction DrainAction
+	drainDone   chan struct{}
+	drainErr    error // Why the current drain was not clean, if it was not
+	lastDrain   error // Result of the last completed drain
+
+	// Sleep: process stopped and game port held until woken
+	sleeping       bool
+	sleepRequested bool // Sleeping, or waiting for the current match to end
+	portHold       net.PacketConn
 
 	// Proxy for DDoS protection (forwards proxy ports -> game ports)
 	proxy *network.GameProxy
+
+	// Set after Adopt until the first status packet restores the game state
+	resuming bool
+
+	// Idle/AFK kick tracking
+	pingTrack  map[string]pingSample // Last ping change per player (from 0x42)
+	idleWarned map[string]time.Time  // Players warned about an upcoming kick
+}
+
+// pingSample records when a player's reported ping last changed.
+// A ping that stays frozen (or at zero) means the client has stopped sending.
+type pingSample struct {
+	ping      uint16
+	changedAt time.Time
 }
 
 // InstanceConfig holds configuration for creating a new server instance.
@@ -62,6 +129,7 @@ type InstanceConfig struct {
 	ID          int // 1-indexed server instance ID
 	Port        uint16
 	CPUAffinity []int32
+	CowMaster   *CowMaster
 }
 
 // NewInstance creates a new game server instance.
@@ -72,9 +140,6 @@ func NewInstance(cfg *config.Config, eventBus *events.EventBus, instCfg Instance
 		Uint16("port", instCfg.Port).
 		Logger()
 
-	// Calculate next auto-restart time (random between 24-48 hours)
-	restartInterval := MinRestartInterval + time.Duration(rand.Int63n(int64(MaxRestartInterval-MinRestartInterval)))
-
 	inst := &Instance{
 		id:          instCfg.ID,
 		port:        instCfg.Port,
@@ -82,10 +147,14 @@ func NewInstance(cfg *config.Config, eventBus *events.EventBus, instCfg Instance
 		cfg:         cfg,
 		eventBus:    eventBus,
 		cpuAffinity: instCfg.CPUAffinity,
-		state:       NewGameState(),
+		cowMaster:   instCfg.CowMaster,
+		state:       NewGameState(logger),
 		enabled:     true,
-		nextRestart: time.Now().Add(restartInterval),
+		pingTrack:   make(map[string]pingSample),
+		idleWarned:  make(map[string]time.Time),
 	}
+	inst.resetRestartPolicy()
+	inst.console = newInstanceConsole(cfg.GetApplicationData().Logging, instCfg.Port)
 
 	// Create process manager
 	procCfg := inst.buildProcessConfig()
@@ -97,18 +166,31 @@ func NewInstance(cfg *config.Config, eventBus *events.EventBus, instCfg Instance
 // Start launches the game server process.
 // If proxy is enabled, the proxy is started first so it is ready to accept
 // connections by the time the game server registers with the master server.
+// A fork from the CowMaster can take seconds, so i.mu is released while it
+// runs.
 func (i *Instance) Start(ctx context.Context) error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
+	if i.forking {
+		return fmt.Errorf("server on port %d is already starting", i.port)
+	}
+
 	if !i.enabled {
 		return fmt.Errorf("server on port %d is disabled", i.port)
 	}
 
+	if i.sleeping {
+		return fmt.Errorf("server on port %d is sleeping", i.port)
+	}
+
 	if i.process.IsRunning() {
 		return fmt.Errorf("server on port %d is already running", i.port)
 	}
 
+	i.cancelCrashRestart()
+	i.stopRequested = false
+
 	// Start proxy if enabled (before game server so ports are ready)
 	honData := i.cfg.GetHoNData()
 	if honData.EnableProxy {
@@ -118,13 +200,42 @@ func (i *Instance) Start(ctx context.Context) error {
 		}
 	}
 
-	i.state.SetStatus(events.GameStatusStarting)
+	cause := causeFrom(ctx, energizerCause("start"))
+	i.state.SetStatus(events.GameStatusStarting, cause)
 	i.state.StartedAt = time.Now()
+	i.resetRestartPolicy()
 
 	i.logger.Info().Msg("starting game server")
 
-	if err := i.process.Start(ctx); err != nil {
-		i.state.SetStatus(events.GameStatusStopped)
+	// Pick up configuration changed since the last start
+	if err := i.process.Reconfigure(i.buildProcessConfig()); err != nil {
+		i.logger.Warn().Err(err).Msg("failed to refresh process settings")
+	}
+	i.configStale = false
+	i.forked = false
+
+	var err error
+	if cm := i.forkSource(); cm != nil {
+		i.forking = true
+		i.mu.Unlock()
+		pid, forkErr := cm.Fork(i.port)
+		i.mu.Lock()
+		i.forking = false
+
+		if i.stopRequested {
+			// Stopped while the fork was in progress
+			if forkErr == nil {
+				terminateProcessPlatform(pid)
+			}
+			return fmt.Errorf("server on port %d was stopped while starting", i.port)
+		}
+		err = i.startForked(ctx, pid, forkErr)
+	} else {
+		err = i.process.Start(ctx)
+	}
+
+	if err != nil {
+		i.state.SetStatus(events.GameStatusStopped, cause)
 		// Stop proxy if game server failed to start
 		if i.proxy != nil {
 			i.proxy.Stop()
@@ -136,6 +247,98 @@ func (i *Instance) Start(ctx context.Context) error {
 	return nil
 }
 
+// forkSource returns the CowMaster to fork the server from, or nil to exec
+// it. Caller must hold i.mu.
+func (i *Instance) forkSource() *CowMaster {
+	switch {
+	case i.cowMaster == nil:
+		return nil
+	case i.candidate:
+		// The CowMaster runs the current install
+		return nil
+	case sandboxOptionsFor(i.cfg.GetApplicationData().Sandbox, i.port) != nil:
+		// A fork inherits the CowMaster's user, home and namespaces
+		return nil
+	case !i.cowMaster.IsReady():
+		return nil
+	}
+	return i.cowMaster
+}
+
+// startForked adopts the process the CowMaster forked, falling back to a
+// normal exec if the fork failed. Caller must hold i.mu.
+func (i *Instance) startForked(ctx context.Context, pid int, forkErr error) error {
+	if forkErr == nil {
+		// The fork is the CowMaster's child, so it is managed like an
+		// adopted process
+		if forkErr = i.process.Adopt(pid); forkErr == nil {
+			i.forked = true
+			go i.process.setCPUAffinity()
+			i.logger.Info().Int("pid", pid).Msg("game server forked from CowMaster")
+			return nil
+		}
+		terminateProcessPlatform(pid)
+	}
+	i.logger.Warn().Err(forkErr).Msg("CowMaster fork failed, falling back to exec")
+
+	return i.process.Start(ctx)
+}
+
+// ConfigureForked sends a server forked from the CowMaster its per-instance
+// settings (buildExecuteParams) when it connects. An exec'd server gets them
+// on its command line, but a fork starts with the CowMaster's settings. It
+// does nothing for a server that was not forked.
+func (i *Instance) ConfigureForked(conn *network.Connection) {
+	i.mu.RLock()
+	forked := i.forked
+	params := i.buildExecuteParams()
+	i.mu.RUnlock()
+
+	if !forked {
+		return
+	}
+	if err := conn.SendCommand(params); err != nil {
+		i.logger.Error().Err(err).Msg("failed to send settings to forked game server")
+		return
+	}
+	i.logger.Debug().Msg("sent settings to forked game server")
+}
+
+// Adopt attaches the instance to a game server process left running by a
+// previous Energizer run instead of starting a new one. The instance waits in
+// STARTING until the server reconnects; the next status packet (0x42) then
+// restores its phase, match, and players.
+func (i *Instance) Adopt(pid int) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.process.IsRunning() {
+		return fmt.Errorf("server on port %d is already running", i.port)
+	}
+
+	if err := i.process.Adopt(pid); err != nil {
+		return fmt.Errorf("failed to adopt server on port %d: %w", i.port, err)
+	}
+	i.forked = false
+
+	i.cancelCrashRestart()
+	i.stopRequested = false
+	i.resuming = true
+	i.state.SetStatus(events.GameStatusStarting, energizerCause("adopt"))
+	i.state.StartedAt = i.process.StartedAt()
+	i.resetRestartPolicy()
+
+	// The proxy lived in the previous Energizer process; bring it back
+	if i.cfg.GetHoNData().EnableProxy {
+		if err := i.startProxy(context.Background()); err != nil {
+			i.logger.Error().Err(err).Msg("failed to start proxy for adopted server")
+		}
+	}
+
+	i.logger.Info().Int("pid", pid).Msg("adopted running game server, waiting for it to reconnect")
+	return nil
+}
+
 // startProxy creates and starts a GameProxy for this instance.
 // Proxy ports use the +10000 convention: gamePort+10000 and voicePort+10000.
 func (i *Instance) startProxy(ctx context.Context) error {
@@ -166,11 +369,20 @@ func (i *Instance) startProxy(ctx context.Context) error {
 
 // Stop gracefully stops the game server.
 func (i *Instance) Stop() error {
+	return i.StopContext(context.Background())
+}
+
+// StopContext is Stop, attributing the transition to the cause attached to
+// ctx (see WithCause).
+func (i *Instance) StopContext(ctx context.Context) error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
 	i.logger.Info().Msg("stopping game server")
-	i.state.SetStatus(events.GameStatusStopped)
+	i.state.SetStatus(events.GameStatusStopped, causeFrom(ctx, energizerCause("stop")))
+	i.stopRequested = true
+	i.cancelCrashRestart()
+	i.releasePort()
 
 	if err := i.process.Stop(); err != nil {
 		i.logger.Error().Err(err).Msg("failed to stop gracefully, killing")
@@ -188,21 +400,18 @@ func (i *Instance) Stop() error {
 
 // Restart stops and restarts the game server.
 func (i *Instance) Restart(ctx context.Context) error {
-	if err := i.Stop(); err != nil {
+	cause := causeFrom(ctx, energizerCause("restart"))
+	ctx = WithCause(ctx, cause)
+
+	if err := i.StopContext(ctx); err != nil {
 		i.logger.Warn().Err(err).Msg("error during stop before restart")
 	}
 
 	// Brief delay between stop and start
 	time.Sleep(2 * time.Second)
 
-	// Reset state
-	i.state.Reset()
-
-	// Recalculate next auto-restart
-	restartInterval := MinRestartInterval + time.Duration(rand.Int63n(int64(MaxRestartInterval-MinRestartInterval)))
-	i.mu.Lock()
-	i.nextRestart = time.Now().Add(restartInterval)
-	i.mu.Unlock()
+	// Reset state (Start also resets the restart policy)
+	i.state.Reset(cause)
 
 	return i.Start(ctx)
 }
@@ -212,6 +421,11 @@ func (i *Instance) Enable() {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	i.enabled = true
+	if i.quarantined {
+		i.quarantined = false
+		i.crashTimes = nil
+		i.logger.Info().Msg("server released from crash quarantine")
+	}
 	i.logger.Info().Msg("server enabled")
 }
 
@@ -220,6 +434,7 @@ func (i *Instance) Disable() {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	i.enabled = false
+	i.cancelCrashRestart()
 	i.logger.Info().Msg("server disabled")
 }
 
@@ -240,11 +455,21 @@ func (i *Instance) Port() uint16 {
 	return i.port
 }
 
+// CPUAffinity returns the CPUs the server is pinned to (nil if unpinned).
+func (i *Instance) CPUAffinity() []int32 {
+	return i.cpuAffinity
+}
+
 // State returns the current game state.
 func (i *Instance) State() *GameState {
 	return i.state
 }
 
+// Console returns the captured console output of the game server.
+func (i *Instance) Console() *Console {
+	return i.console
+}
+
 // PID returns the process ID.
 func (i *Instance) PID() int {
 	return i.process.PID()
@@ -258,6 +483,7 @@ func (i *Instance) Uptime() time.Duration {
 // HandleStatusUpdate processes a server status telemetry packet (0x42).
 func (i *Instance) HandleStatusUpdate(payload events.ServerStatusPayload) {
 	oldPhase := i.state.GetPhase()
+	cause := PacketCause("0x42")
 
 	i.state.UpdateTelemetry(
 		payload.Uptime,
@@ -266,27 +492,59 @@ func (i *Instance) HandleStatusUpdate(payload events.ServerStatusPayload) {
 		payload.GamePhase,
 		payload.MatchID,
 		payload.PlayerPings,
+		cause,
 	)
 
 	newPhase := payload.GamePhase
+	i.trackPings(payload.PlayerPings)
 
 	// React to phase transitions
 	if oldPhase != newPhase {
 		i.onPhaseTransition(oldPhase, newPhase)
 	}
 
+	// An adopted server may already be mid-match when it reconnects
+	i.mu.Lock()
+	resuming := i.resuming
+	i.resuming = false
+	i.mu.Unlock()
+	if resuming && newPhase != events.GamePhaseIdle {
+		i.state.SetStatus(events.GameStatusOccupied, cause)
+		i.logger.Info().
+			Str("phase", newPhase.String()).
+			Uint32("match_id", payload.MatchID).
+			Msg("adopted server resumed mid-match")
+		return
+	}
+
 	// Set server as ready if it was starting
-	if i.state.GetStatus() == events.GameStatusStarting {
-		i.state.SetStatus(events.GameStatusReady)
-		i.logger.Info().Msg("server is now READY")
+	if i.state.GetStatus() == events.GameStatusStarting || resuming {
+		if i.state.SetStatus(events.GameStatusReady, cause) == nil {
+			i.logger.Info().Msg("server is now READY")
+		}
 	}
 }
 
 // HandleLobbyCreated processes a lobby created event (0x44).
 func (i *Instance) HandleLobbyCreated(payload events.LobbyCreatedPayload) {
+	cause := PacketCause("0x44")
+	if err := i.state.SetStatus(events.GameStatusOccupied, cause); err != nil {
+		return // Rejected and logged by the state machine
+	}
 	i.state.SetMatchInfo(payload.MatchID, payload.MapName, payload.Mode)
-	i.state.SetPhase(events.GamePhaseInLobby)
-	i.state.SetStatus(events.GameStatusOccupied)
+	i.state.SetPhase(events.GamePhaseInLobby, cause)
+
+	i.mu.Lock()
+	if i.draining && !i.drainActing {
+		// The drain could not keep the match off this server; it still waits
+		// for the match to finish, but reports the failure to its caller
+		i.drainErr = errors.Join(i.drainErr,
+			fmt.Errorf("match %d started on port %d while it was draining", payload.MatchID, i.port))
+		i.logger.Error().
+			Uint32("match_id", payload.MatchID).
+			Msg("lobby created on draining server, drain will wait for it to finish")
+	}
+	i.mu.Unlock()
 
 	i.logger.Info().
 		Uint32("match_id", payload.MatchID).
@@ -297,14 +555,26 @@ func (i *Instance) HandleLobbyCreated(payload events.LobbyCreatedPayload) {
 
 // HandleLobbyClosed processes a lobby closed event (0x45).
 func (i *Instance) HandleLobbyClosed() {
-	i.state.SetPhase(events.GamePhaseIdle)
+	cause := PacketCause("0x45")
+	i.state.SetPhase(events.GamePhaseIdle, cause)
 	i.state.SetMatchInfo(0, "", "")
 
+	i.mu.Lock()
+	i.matchesPlayed++
+	i.mu.Unlock()
+
 	i.logger.Info().Msg("lobby closed")
 
+	// A draining server stays OCCUPIED until its drain action takes it down,
+	// so it is never offered for a new match
+	if i.IsDraining() {
+		go i.finishDrain()
+		return
+	}
+
 	// Check if server should return to ready
 	if i.state.GetStatus() == events.GameStatusOccupied {
-		i.state.SetStatus(events.GameStatusReady)
+		i.state.SetStatus(events.GameStatusReady, cause)
 	}
 }
 
@@ -356,36 +626,402 @@ func (i *Instance) HandleLongFrame(payload events.LongFramePayload) {
 	}
 }
 
-// NeedsRestart checks if the server is due for a periodic restart.
-func (i *Instance) NeedsRestart() bool {
+// HandleCrash reacts to an unexpected process exit. The server is restarted
+// after an exponential backoff, or quarantined (disabled) if it has crashed
+// too often within the configured window.
+func (i *Instance) HandleCrash(payload events.ServerCrashedPayload) {
+	policy := i.cfg.GetApplicationData().CrashPolicy
+
+	i.mu.Lock()
+	if i.stopRequested {
+		// Stop() raced with the exit; nothing to recover.
+		i.mu.Unlock()
+		return
+	}
+
+	i.state.SetStatus(events.GameStatusStopped, energizerCause("crash"))
+	if i.proxy != nil {
+		i.proxy.Stop()
+		i.proxy = nil
+	}
+
+	// Record the crash, forgetting those that fell out of the window
+	now := time.Now()
+	window := time.Duration(policy.QuarantineWindowSec) * time.Second
+	recent := i.crashTimes[:0]
+	for _, t := range i.crashTimes {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	i.crashTimes = append(recent, now)
+	crashes := len(i.crashTimes)
+
+	i.cancelCrashRestart()
+	quarantine := policy.QuarantineCrashes > 0 && crashes >= policy.QuarantineCrashes
+	var delay time.Duration
+	switch {
+	case quarantine:
+		i.enabled = false
+		i.quarantined = true
+	case policy.AutoRestart && i.enabled:
+		delay = crashBackoff(policy, crashes)
+		i.restartTimer = time.AfterFunc(delay, i.restartAfterCrash)
+	}
+	i.mu.Unlock()
+
+	i.logger.Error().
+		Int("pid", payload.PID).
+		Int("exit_code", payload.ExitCode).
+		Str("signal", payload.Signal).
+		Dur("uptime", payload.Uptime).
+		Int("recent_crashes", crashes).
+		Dur("restart_in", delay).
+		Msg("game server crashed")
+
+	if i.IsDraining() {
+		go i.finishDrain()
+	}
+
+	if quarantine {
+		i.logger.Error().
+			Int("crashes", crashes).
+			Int("window_sec", policy.QuarantineWindowSec).
+			Msg("server quarantined after repeated crashes")
+
+		i.eventBus.Emit(context.Background(), events.Event{
+			Type:   events.EventNotifyDiscordAdmin,
+			Source: fmt.Sprintf("game_server:%d", i.port),
+			Payload: events.NotifyDiscordPayload{
+				Title: "Server Quarantined",
+				Message: fmt.Sprintf("Server on port %d crashed %d times within %ds and has been disabled",
+					i.port, crashes, policy.QuarantineWindowSec),
+				Level: "error",
+			},
+		})
+	}
+}
+
+// restartAfterCrash is invoked by the backoff timer to bring a crashed server back.
+// A failed start is treated as another crash so it backs off and eventually quarantines.
+func (i *Instance) restartAfterCrash() {
+	i.mu.Lock()
+	i.restartTimer = nil
+	skip := !i.enabled || i.stopRequested
+	i.mu.Unlock()
+
+	if skip || i.process.IsRunning() {
+		return
+	}
+
+	i.logger.Info().Msg("restarting game server after crash")
+	cause := energizerCause("crash_policy:restart")
+	i.state.Reset(cause)
+	if err := i.Start(WithCause(context.Background(), cause)); err != nil {
+		i.logger.Error().Err(err).Msg("failed to restart crashed server")
+		i.HandleCrash(events.ServerCrashedPayload{Port: i.port, ExitCode: -1})
+	}
+}
+
+// cancelCrashRestart stops a pending backoff restart. Caller must hold i.mu.
+func (i *Instance) cancelCrashRestart() {
+	if i.restartTimer != nil {
+		i.restartTimer.Stop()
+		i.restartTimer = nil
+	}
+}
+
+// onProcessExit is the ProcessManager exit callback. Requested exits are
+// ignored; anything else is published as a crash.
+func (i *Instance) onProcessExit(exit ProcessExit) {
+	if exit.Requested {
+		return
+	}
+
+	i.eventBus.Emit(context.Background(), events.Event{
+		Type:   events.EventServerCrashed,
+		Source: fmt.Sprintf("game_server:%d", i.port),
+		Payload: events.ServerCrashedPayload{
+			Port:     i.port,
+			PID:      exit.PID,
+			ExitCode: exit.ExitCode,
+			Signal:   exit.Signal,
+			Uptime:   exit.Uptime,
+		},
+	})
+}
+
+// IsQuarantined returns whether the server was disabled by the crash-loop guard.
+func (i *Instance) IsQuarantined() bool {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
+	return i.quarantined
+}
+
+// crashBackoff returns the restart delay after the nth crash in the window,
+// doubling from the initial backoff up to the configured maximum.
+func crashBackoff(policy config.CrashPolicyConfig, n int) time.Duration {
+	delay := time.Duration(policy.InitialBackoffSec) * time.Second
+	maxDelay := time.Duration(policy.MaxBackoffSec) * time.Second
+	for k := 1; k < n && delay < maxDelay; k++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// NeedsRestart checks if the server is due for a periodic restart under the
+// configured restart policy. It also samples the server's memory usage, taking
+// the first sample after the server is READY as the memory growth baseline.
+func (i *Instance) NeedsRestart() bool {
+	memMB, err := i.process.GetMemoryMB()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err == nil {
+		i.memLastMB = memMB
+		if i.memBaselineMB == 0 && i.state.GetStatus() == events.GameStatusReady {
+			i.memBaselineMB = memMB
+			i.logger.Debug().Float64("memory_mb", memMB).Msg("memory baseline measured")
+		}
+	}
 
 	// Only restart if server is idle (no active match)
 	if i.state.GetPhase() != events.GamePhaseIdle {
 		return false
 	}
 
-	return time.Now().After(i.nextRestart)
+	decision := i.restartDecision()
+	if decision.Due {
+		i.logger.Info().Str("rule", decision.Rule).Msg("restart policy triggered")
+	}
+	return decision.Due
+}
+
+// resetRestartPolicy rebuilds the restart policy from config for a newly
+// started process. Caller must hold i.mu (or own i exclusively).
+func (i *Instance) resetRestartPolicy() {
+	i.restartPolicy = NewRestartPolicy(i.cfg.GetApplicationData().RestartPolicy)
+	i.matchesPlayed = 0
+	i.memBaselineMB = 0
+	i.memLastMB = 0
 }
 
-// CheckIdlePlayers checks for and kicks idle players after game ends.
-func (i *Instance) CheckIdlePlayers(registry interface{}) {
-	if i.state.GetPhase() != events.GamePhaseGameEnded {
+// reloadRestartPolicy rebuilds the restart policy after a configuration
+// change, keeping the match count and memory samples of the running process.
+func (i *Instance) reloadRestartPolicy() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.restartPolicy = NewRestartPolicy(i.cfg.GetApplicationData().RestartPolicy)
+}
+
+// markConfigStale flags a running server for restart once it is idle, so
+// that it picks up changed process settings. It returns false if the server
+// is not running and will pick them up on its next start anyway.
+func (i *Instance) markConfigStale() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !i.process.IsRunning() {
+		return false
+	}
+	i.configStale = true
+	return true
+}
+
+// ConfigStale returns whether the server waits for a restart to pick up
+// configuration changes.
+func (i *Instance) ConfigStale() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.configStale
+}
+
+// OnCandidate returns whether the server runs (or will next start from) the
+// candidate install of a rollout.
+func (i *Instance) OnCandidate() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.candidate
+}
+
+// setCandidate moves the server between the current and candidate installs.
+// The change takes effect on the next start.
+func (i *Instance) setCandidate(candidate bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.candidate = candidate
+}
+
+// restartDecision evaluates the restart policy. Caller must hold i.mu.
+func (i *Instance) restartDecision() RestartDecision {
+	if !i.process.IsRunning() {
+		return RestartDecision{}
+	}
+	if i.configStale {
+		return RestartDecision{Due: true, Rule: "config_changed"}
+	}
+	return i.restartPolicy.Evaluate(RestartStats{
+		Now:        time.Now(),
+		StartedAt:  i.state.StartedAt,
+		Matches:    i.matchesPlayed,
+		MemoryMB:   i.memLastMB,
+		BaselineMB: i.memBaselineMB,
+	})
+}
+
+// CheckIdlePlayers kicks players who linger after the game has ended, and
+// players who are AFK in the lobby (their reported ping has stopped changing).
+// Each player is warned in-game at least warning_sec before being kicked.
+func (i *Instance) CheckIdlePlayers(registry *network.ConnectionRegistry) {
+	policy := i.cfg.GetApplicationData().IdleKick
+	if !policy.Enabled {
 		return
 	}
 
-	// Check if enough time has passed since game ended
-	phaseChangedAt := i.state.PhaseChangedAt
-	if time.Since(phaseChangedAt) < IdleKickDelay {
+	conn, ok := registry.Get(i.port)
+	if !ok {
 		return
 	}
 
 	players := i.state.GetPlayers()
-	if len(players) > 0 {
+	phase := i.state.GetPhase()
+	phaseChangedAt := i.state.PhaseChangedAt
+
+	// Work out since when each player has been idle, and for how long that is allowed
+	idleSince := make(map[string]time.Time)
+	var limit time.Duration
+	switch phase {
+	case events.GamePhaseGameEnded:
+		limit = time.Duration(policy.PostGameDelaySec) * time.Second
+		if limit <= 0 {
+			limit = IdleKickDelay
+		}
+		for name := range players {
+			idleSince[name] = phaseChangedAt
+		}
+
+	case events.GamePhaseInLobby, events.GamePhaseBanning, events.GamePhasePicking:
+		if policy.LobbyAFKSec <= 0 {
+			return
+		}
+		limit = time.Duration(policy.LobbyAFKSec) * time.Second
+		i.mu.RLock()
+		for name, sample := range i.pingTrack {
+			if _, ok := players[name]; !ok {
+				continue
+			}
+			since := sample.changedAt
+			if since.Before(phaseChangedAt) {
+				since = phaseChangedAt
+			}
+			idleSince[name] = since
+		}
+		i.mu.RUnlock()
+
+	default:
+		i.mu.Lock()
+		i.idleWarned = make(map[string]time.Time)
+		i.mu.Unlock()
+		return
+	}
+
+	exempt := make(map[string]bool, len(policy.ExemptPlayers))
+	for _, name := range policy.ExemptPlayers {
+		exempt[strings.ToLower(name)] = true
+	}
+	warning := time.Duration(policy.WarningSec) * time.Second
+	now := time.Now()
+
+	// Decide under the lock, then message and kick without it so a stalled
+	// connection does not block the instance
+	var warn, kick []string
+	i.mu.Lock()
+	for name, since := range idleSince {
+		if exempt[strings.ToLower(name)] {
+			continue
+		}
+
+		kickAt := since.Add(limit)
+		warnedAt, warned := i.idleWarned[name]
+
+		if !warned {
+			if !now.Before(kickAt.Add(-warning)) {
+				warn = append(warn, name)
+			}
+			continue
+		}
+
+		// Always give the full warning period, even if the check ran late
+		if earliest := warnedAt.Add(warning); kickAt.Before(earliest) {
+			kickAt = earliest
+		}
+		if !now.Before(kickAt) {
+			kick = append(kick, name)
+		}
+	}
+
+	// Forget warnings for players who left or became active again
+	for name := range i.idleWarned {
+		if _, ok := idleSince[name]; !ok {
+			delete(i.idleWarned, name)
+		}
+	}
+	i.mu.Unlock()
+
+	for _, name := range warn {
+		msg := strings.NewReplacer(
+			"{player}", name,
+			"{seconds}", fmt.Sprintf("%d", policy.WarningSec),
+		).Replace(policy.WarningMessage)
+		if err := conn.SendMessage(msg); err != nil {
+			i.logger.Warn().Err(err).Str("player", name).Msg("failed to send idle warning")
+			continue
+		}
+		i.mu.Lock()
+		i.idleWarned[name] = now
+		i.mu.Unlock()
+		i.logger.Info().Str("player", name).Str("phase", phase.String()).Msg("warned idle player")
+	}
+
+	for _, name := range kick {
+		if err := conn.KickPlayer(players[name].ID, policy.KickReason); err != nil {
+			i.logger.Warn().Err(err).Str("player", name).Msg("failed to kick idle player")
+			continue
+		}
+		i.mu.Lock()
+		delete(i.idleWarned, name)
+		i.mu.Unlock()
 		i.logger.Info().
-			Int("idle_players", len(players)).
-			Msg("kicking idle players after game end")
+			Str("player", name).
+			Uint32("player_id", players[name].ID).
+			Str("phase", phase.String()).
+			Dur("idle", now.Sub(idleSince[name])).
+			Msg("kicked idle player")
+	}
+}
+
+// trackPings records when each player's reported ping last changed.
+// Zero pings do not count as activity.
+func (i *Instance) trackPings(pings map[string]uint16) {
+	now := time.Now()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for name, ping := range pings {
+		prev, ok := i.pingTrack[name]
+		if !ok || (ping != 0 && ping != prev.ping) {
+			i.pingTrack[name] = pingSample{ping: ping, changedAt: now}
+			delete(i.idleWarned, name)
+		}
+	}
+	for name := range i.pingTrack {
+		if _, ok := pings[name]; !ok {
+			delete(i.pingTrack, name)
+		}
 	}
 }
 
@@ -415,6 +1051,227 @@ func (i *Instance) onPhaseTransition(oldPhase, newPhase events.GamePhase) {
 		// Clear match-related state
 		i.state.SetMatchInfo(0, "", "")
 		i.state.ClearLagEvents()
+
+		if i.IsDraining() {
+			go i.finishDrain()
+		}
+	}
+}
+
+// Drain puts the server into drain mode. The current match (if any) is allowed
+// to finish; the server is restarted or stopped according to action as soon as
+// the game phase returns to idle, and stays OCCUPIED until then, so it never
+// becomes available for a new lobby. The returned channel is closed once the
+// action has completed; DrainError then reports whether the drain was clean.
+// Draining an already draining server returns the existing channel.
+func (i *Instance) Drain(action DrainAction) <-chan struct{} {
+	i.mu.Lock()
+	if i.draining {
+		done := i.drainDone
+		i.mu.Unlock()
+		return done
+	}
+	i.draining = true
+	i.drainAction = action
+	i.drainDone = make(chan struct{})
+	done := i.drainDone
+	i.drainErr = nil
+	i.state.SetHoldOccupied(true)
+	i.mu.Unlock()
+
+	idle := i.state.GetPhase() == events.GamePhaseIdle &&
+		i.state.GetStatus() != events.GameStatusOccupied
+
+	i.logger.Info().
+		Str("action", action.String()).
+		Bool("idle", idle).
+		Msg("server draining")
+
+	if idle || !i.process.IsRunning() {
+		go i.finishDrain()
+	}
+	return done
+}
+
+// IsDraining returns whether the server is in drain mode.
+func (i *Instance) IsDraining() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.draining
+}
+
+// DrainError returns why the last completed drain was not clean: a match
+// started on the server while it was draining, or the drain action failed.
+// It is nil if the drain was clean or none has completed yet.
+func (i *Instance) DrainError() error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.lastDrain
+}
+
+// finishDrain performs the pending drain action and leaves drain mode.
+// It is safe to call more than once; only the first call acts.
+func (i *Instance) finishDrain() {
+	i.mu.Lock()
+	if !i.draining || i.drainActing {
+		i.mu.Unlock()
+		return
+	}
+	i.drainActing = true
+	action := i.drainAction
+	i.mu.Unlock()
+
+	i.logger.Info().Str("action", action.String()).Msg("server drained, applying drain action")
+
+	ctx := WithCause(context.Background(), energizerCause("drain:"+action.String()))
+	var err error
+	switch action {
+	case DrainStop:
+		err = i.StopContext(ctx)
+	case DrainRestart:
+		if i.process.IsRunning() {
+			err = i.Restart(ctx)
+		}
+	case DrainSleep:
+		err = i.enterSleep(ctx)
+	}
+	if err != nil {
+		i.logger.Error().Err(err).Str("action", action.String()).Msg("drain action failed")
+	}
+
+	i.mu.Lock()
+	if err != nil {
+		i.drainErr = errors.Join(i.drainErr, fmt.Errorf("drain action %s on port %d: %w", action, i.port, err))
+	}
+	done := i.drainDone
+	i.draining = false
+	i.drainActing = false
+	i.drainDone = nil
+	i.lastDrain = i.drainErr
+	i.drainErr = nil
+	i.state.SetHoldOccupied(false)
+	sleepPending := i.sleepRequested && !i.sleeping && action != DrainSleep
+	i.mu.Unlock()
+
+	close(done)
+
+	// Sleep was requested while another drain action was already running
+	if sleepPending {
+		i.Drain(DrainSleep)
+	}
+}
+
+// cancelDrain leaves drain mode without acting. Caller must hold i.mu.
+func (i *Instance) cancelDrain() {
+	if !i.draining || i.drainActing {
+		return
+	}
+	i.draining = false
+	close(i.drainDone)
+	i.drainDone = nil
+	i.drainErr = nil
+	i.state.SetHoldOccupied(false)
+	i.logger.Info().Msg("drain cancelled")
+}
+
+// Sleep takes the server out of rotation: the process is stopped and the game
+// port is held so nothing else claims it. If a match is in progress, the sleep
+// is deferred until it ends.
+func (i *Instance) Sleep() {
+	i.mu.Lock()
+	if i.sleepRequested {
+		i.mu.Unlock()
+		return
+	}
+	i.sleepRequested = true
+	if i.draining && !i.drainActing {
+		// Replace the pending drain action; the match still finishes first
+		i.drainAction = DrainSleep
+		i.mu.Unlock()
+		return
+	}
+	i.mu.Unlock()
+
+	i.Drain(DrainSleep)
+}
+
+// Wake brings a sleeping server back into rotation, or cancels a sleep that
+// is still waiting for a match to end.
+func (i *Instance) Wake(ctx context.Context) error {
+	i.mu.Lock()
+	i.sleepRequested = false
+	if i.drainAction == DrainSleep {
+		i.cancelDrain()
+	}
+	wasSleeping := i.sleeping
+	i.sleeping = false
+	i.releasePort()
+	i.mu.Unlock()
+
+	if !wasSleeping {
+		return nil
+	}
+
+	i.logger.Info().Msg("waking server")
+	cause := causeFrom(ctx, energizerCause("wake"))
+	i.state.Reset(cause)
+	return i.Start(WithCause(ctx, cause))
+}
+
+// IsSleeping returns whether the server is asleep or waiting to go to sleep.
+func (i *Instance) IsSleeping() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.sleepRequested
+}
+
+// enterSleep stops the process and marks the server as sleeping.
+func (i *Instance) enterSleep(ctx context.Context) error {
+	if err := i.StopContext(ctx); err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	if !i.sleepRequested {
+		// Woken while the process was stopping
+		i.mu.Unlock()
+		return i.Start(ctx)
+	}
+	i.markSleeping(causeFrom(ctx, energizerCause("sleep")))
+	i.mu.Unlock()
+
+	i.logger.Info().Msg("server is now SLEEPING")
+	return nil
+}
+
+// markSleeping records the sleeping state without touching the process.
+// Also used to restore persisted sleep state at startup. Caller must hold i.mu.
+func (i *Instance) markSleeping(cause Cause) {
+	i.sleepRequested = true
+	i.sleeping = true
+	i.state.SetStatus(events.GameStatusSleeping, cause)
+	i.reservePort()
+}
+
+// reservePort binds the game port while the server sleeps so that no other
+// process claims it. Failure is logged but not fatal. Caller must hold i.mu.
+func (i *Instance) reservePort() {
+	if i.portHold != nil {
+		return
+	}
+	pc, err := net.ListenPacket("udp", fmt.Sprintf(":%d", i.port))
+	if err != nil {
+		i.logger.Warn().Err(err).Msg("failed to reserve game port while sleeping")
+		return
+	}
+	i.portHold = pc
+}
+
+// releasePort frees a port held by reservePort. Caller must hold i.mu.
+func (i *Instance) releasePort() {
+	if i.portHold != nil {
+		i.portHold.Close()
+		i.portHold = nil
 	}
 }
 
@@ -430,8 +1287,9 @@ func (i *Instance) buildProcessConfig() ProcessConfig {
 	if exeName == "" {
 		exeName = getServerExecutable()
 	}
-	executable := filepath.Join(honData.InstallDirectory, exeName)
-	workDir := honData.InstallDirectory
+	installDir := i.installDirectory(honData)
+	executable := filepath.Join(installDir, exeName)
+	workDir := installDir
 
 	// Build command-line arguments (HoNfigurator-Central format)
 	args := i.buildServerArgs()
@@ -443,17 +1301,17 @@ func (i *Instance) buildProcessConfig() ProcessConfig {
 	if runtime.GOOS == "windows" {
 		artefactsDir := honData.ArtefactsDirectory
 		if artefactsDir == "" {
-			artefactsDir = honData.InstallDirectory
+			artefactsDir = installDir
 		}
 		homeDir := honData.HomeDirectory
 		if homeDir == "" {
-			homeDir = honData.InstallDirectory
+			homeDir = installDir
 		}
 		envVars["APPDATA"] = artefactsDir
 		envVars["USERPROFILE"] = homeDir
 	}
 
-	return ProcessConfig{
+	procCfg := ProcessConfig{
 		Executable:   executable,
 		Args:         args,
 		WorkDir:      workDir,
@@ -461,7 +1319,32 @@ func (i *Instance) buildProcessConfig() ProcessConfig {
 		CPUAffinity:  i.cpuAffinity,
 		HighPriority: false,
 		EnvVars:      envVars,
+		OnExit:       i.onProcessExit,
+		Cgroup:       cgroupLimitsFor(i.cfg.GetApplicationData().Cgroups, i.port),
+		Sandbox:      sandboxOptionsFor(i.cfg.GetApplicationData().Sandbox, i.port),
+		Console:      i.console,
 	}
+	if honData.AdoptServers {
+		// A pipe back to Energizer would break when Energizer restarts and
+		// the adopted server next writes to it, so the server appends to the
+		// console log itself
+		procCfg.ConsoleFile = consoleLogPath(i.cfg.GetApplicationData().Logging, i.port)
+	}
+
+	// In simulation mode the same process settings run a fake game server
+	simulationProcessConfig(&procCfg, honData)
+
+	return procCfg
+}
+
+// installDirectory returns the HoN install this server runs: the candidate
+// install while it takes part in a rollout, the current one otherwise.
+// Caller must hold i.mu (or own i exclusively).
+func (i *Instance) installDirectory(honData config.HoNData) string {
+	if i.candidate && honData.CandidateInstallDirectory != "" {
+		return honData.CandidateInstallDirectory
+	}
+	return honData.InstallDirectory
 }
 
 // buildServerArgs constructs the command-line arguments for the game server.
@@ -641,20 +1524,43 @@ func (i *Instance) GetInfo() InstanceInfo {
 	defer i.mu.RUnlock()
 
 	snapshot := i.state.Snapshot()
+	restart := i.restartDecision()
+	var nextRestart *time.Time
+	if !restart.At.IsZero() {
+		nextRestart = &restart.At
+	}
 
 	honData := i.cfg.GetHoNData()
 	serverName := fmt.Sprintf("%s %d", honData.Name, i.id)
+	_, forked, memory := i.memoryLocked()
+
+	install, version := "current", honData.ServerVersion
+	if i.candidate {
+		install, version = "candidate", honData.CandidateVersion
+	}
 
 	return InstanceInfo{
-		ID:          i.id,
-		ServerName:  serverName,
-		Port:        i.port,
-		Enabled:     i.enabled,
-		Running:     i.process.IsRunning(),
-		PID:         i.process.PID(),
-		Uptime:      i.process.Uptime().String(),
-		State:       snapshot,
-		NextRestart: i.nextRestart,
+		ID:            i.id,
+		ServerName:    serverName,
+		Port:          i.port,
+		Enabled:       i.enabled,
+		Running:       i.process.IsRunning(),
+		PID:           i.process.PID(),
+		Uptime:        i.process.Uptime().String(),
+		State:         snapshot,
+		NextRestart:   nextRestart,
+		RestartRule:   restart.Rule,
+		RestartDue:    restart.Due,
+		MatchesPlayed: i.matchesPlayed,
+		Quarantined:   i.quarantined,
+		RecentCrashes: len(i.crashTimes),
+		Draining:      i.draining,
+		ConfigStale:   i.configStale,
+		Cgroup:        i.process.CgroupStats(),
+		Forked:        forked,
+		Memory:        memory,
+		Install:       install,
+		Version:       version,
 	}
 }
 
@@ -668,5 +1574,22 @@ type InstanceInfo struct {
 	PID         int               `json:"pid"`
 	Uptime      string            `json:"uptime"`
 	State       GameStateSnapshot `json:"state"`
-	NextRestart time.Time         `json:"next_restart"`
+	NextRestart *time.Time        `json:"next_restart,omitempty"` // Unset if no time-based rule applies
+	RestartRule string            `json:"restart_rule"`           // Rule expected to trigger the next restart
+	RestartDue  bool              `json:"restart_due"`            // Waiting for the server to become idle
+
+	MatchesPlayed int `json:"matches_played"`
+
+	Quarantined   bool `json:"quarantined"`
+	RecentCrashes int  `json:"recent_crashes"`
+	Draining      bool `json:"draining"`
+	ConfigStale   bool `json:"config_stale"` // Restarts once idle to apply configuration changes
+
+	Cgroup *CgroupStats `json:"cgroup,omitempty"` // Set while running in a cgroup (Linux only)
+
+	Forked bool           `json:"forked"`           // Forked from the CowMaster
+	Memory *ProcessMemory `json:"memory,omitempty"` // PSS/USS while running (Linux only)
+
+	Install string `json:"install"` // "current" or "candidate" (see Rollout)
+	Version string `json:"version"` // svr_version of that install (empty: auto)
 }
@@ -3,9 +3,11 @@ package server
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,11 +17,15 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/db"
 	"github.com/energizer-project/energizer/internal/events"
 	"github.com/energizer-project/energizer/internal/network"
 )
 
-const pidFileName = "energizer_servers.pid"
+const (
+	pidFileName   = "energizer_servers.pid"
+	sleepFileName = "energizer_sleeping_servers"
+)
 
 // Manager is the central orchestrator for all game server instances.
 // It replaces the Python GameServerManager (~1335 lines) and manages
@@ -40,6 +46,34 @@ type Manager struct {
 	// Startup semaphore to limit concurrent server starts
 	startSemaphore chan struct{}
 
+	// Assigns CPUs to servers by index
+	affinity *AffinityPlanner
+
+	// Set while a rolling restart is in progress
+	rollingRestart bool
+
+	// Set during a maintenance window; the servers it took down are
+	// started again when it ends
+	maintenance        bool
+	maintenanceServers []*Instance
+
+	// Audit trail of console commands (nil: log only)
+	commandAudit *db.AuditDatabase
+
+	// Pre-loaded master process servers are forked from (nil: exec only)
+	cowMaster *CowMaster
+
+	// Servers stopping once their match ends, after svr_total was lowered
+	removing map[uint16]bool
+
+	// Configuration reload (see ReconcileConfig)
+	reloadMu     sync.Mutex
+	bootHoN      config.HoNData         // As loaded at startup
+	bootApp      config.ApplicationData // As loaded at startup
+	appliedHoN   config.HoNData         // As of the last reconcile
+	appliedApp   config.ApplicationData // As of the last reconcile
+	staleChanges []config.FieldChange   // idle_restart changes not yet on every server
+
 	// Server version info
 	honVersion     string
 	managerVersion string
@@ -57,10 +91,14 @@ func NewManager(cfg *config.Config, eventBus *events.EventBus) (*Manager, error)
 		cfg:            cfg,
 		eventBus:       eventBus,
 		servers:        make(map[uint16]*Instance),
+		removing:       make(map[uint16]bool),
 		connRegistry:   network.NewConnectionRegistry(),
 		startSemaphore: make(chan struct{}, maxConcurrent),
 		managerVersion: "1.0.0",
+		bootHoN:        cfg.GetHoNData(),
+		bootApp:        cfg.GetApplicationData(),
 	}
+	mgr.appliedHoN, mgr.appliedApp = mgr.bootHoN, mgr.bootApp
 
 	log.Info().Int("max_concurrent_starts", maxConcurrent).Msg("server startup concurrency configured")
 
@@ -68,8 +106,20 @@ func NewManager(cfg *config.Config, eventBus *events.EventBus) (*Manager, error)
 	mgr.subscribeEvents()
 
 	// Pre-create server instances
+	affinityCfg := cfg.GetApplicationData().CPUAffinity
+	mgr.affinity = NewAffinityPlanner(affinityCfg.TopologyAware, affinityCfg.ReservedCPUs, cfg.GetHoNData().ServersPerCore)
+	if capacity := mgr.affinity.Capacity(); capacity > 0 && cfg.GetHoNData().TotalServers > capacity {
+		log.Warn().
+			Int("servers", cfg.GetHoNData().TotalServers).
+			Int("capacity", capacity).
+			Msg("more servers than CPU slots, some servers will share CPUs beyond svr_total_per_core")
+	}
+
 	mgr.initializeServers()
 
+	// Servers put to sleep before the last shutdown stay asleep
+	mgr.loadSleepFile()
+
 	return mgr, nil
 }
 
@@ -80,6 +130,7 @@ func (m *Manager) subscribeEvents() {
 	// Server lifecycle events
 	bus.Subscribe(events.EventServerAnnounce, "manager.serverAnnounce", m.onServerAnnounce)
 	bus.Subscribe(events.EventServerClosed, "manager.serverClosed", m.onServerClosed)
+	bus.Subscribe(events.EventServerCrashed, "manager.serverCrashed", m.onServerCrashed)
 	bus.Subscribe(events.EventServerStatus, "manager.serverStatus", m.onServerStatus)
 	bus.Subscribe(events.EventLobbyCreated, "manager.lobbyCreated", m.onLobbyCreated)
 	bus.Subscribe(events.EventLobbyClosed, "manager.lobbyClosed", m.onLobbyClosed)
@@ -93,6 +144,7 @@ func (m *Manager) subscribeEvents() {
 	bus.Subscribe(events.EventWakeServer, "manager.wakeServer", m.onCmdWakeServer)
 	bus.Subscribe(events.EventSleepServer, "manager.sleepServer", m.onCmdSleepServer)
 	bus.Subscribe(events.EventMessageServer, "manager.messageServer", m.onCmdMessageServer)
+	bus.Subscribe(events.EventCommandServer, "manager.commandServer", m.onCmdCommandServer)
 
 	// Config events
 	bus.Subscribe(events.EventConfigChanged, "manager.configChanged", m.onConfigChanged)
@@ -117,12 +169,13 @@ func (m *Manager) initializeServers() {
 	for i := 0; i < totalServers; i++ {
 		serverID := i + 1 // 1-indexed, matching HoNfigurator-Central's svr_slave
 		port := startPort + uint16(i)
-		affinity := calculateCPUAffinity(i, honData.ServersPerCore)
+		affinity := m.affinity.CoresFor(i)
 
 		inst := NewInstance(m.cfg, m.eventBus, InstanceConfig{
 			ID:          serverID,
 			Port:        port,
 			CPUAffinity: affinity,
+			CowMaster:   m.cowMaster,
 		})
 
 		m.servers[port] = inst
@@ -137,6 +190,10 @@ func (m *Manager) StartAll(ctx context.Context) error {
 	m.mu.RLock()
 	servers := make([]*Instance, 0, len(m.servers))
 	for _, inst := range m.servers {
+		// Skip sleeping servers and those re-adopted from a previous run
+		if inst.IsSleeping() || inst.IsRunning() {
+			continue
+		}
 		servers = append(servers, inst)
 	}
 	m.mu.RUnlock()
@@ -261,6 +318,170 @@ func (m *Manager) waitForBatchReady(ctx context.Context, batch []*Instance, time
 	}
 }
 
+// RollingRestart restarts every running, enabled server while keeping at most
+// maxUnavailable of them out of service at a time. Each server is drained first,
+// so in-progress matches finish before its process is restarted, and the next
+// server is not touched until the restarted one reports READY (or times out).
+func (m *Manager) RollingRestart(ctx context.Context, maxUnavailable int) error {
+	if maxUnavailable < 1 {
+		return fmt.Errorf("max unavailable must be at least 1")
+	}
+
+	m.mu.Lock()
+	if m.rollingRestart {
+		m.mu.Unlock()
+		return fmt.Errorf("rolling restart already in progress")
+	}
+	m.rollingRestart = true
+	servers := make([]*Instance, 0, len(m.servers))
+	for _, inst := range m.servers {
+		if inst.IsEnabled() && inst.IsRunning() {
+			servers = append(servers, inst)
+		}
+	}
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.rollingRestart = false
+		m.mu.Unlock()
+	}()
+
+	// Idle servers first so capacity is cycled before busy ones finish their matches
+	sort.Slice(servers, func(i, j int) bool {
+		iIdle := servers[i].State().GetPhase() == events.GamePhaseIdle
+		jIdle := servers[j].State().GetPhase() == events.GamePhaseIdle
+		if iIdle != jIdle {
+			return iIdle
+		}
+		return servers[i].Port() < servers[j].Port()
+	})
+
+	log.Info().
+		Int("count", len(servers)).
+		Int("max_unavailable", maxUnavailable).
+		Msg("starting rolling restart")
+
+	slots := make(chan struct{}, maxUnavailable)
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var drainErrs []error
+	for _, inst := range servers {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		inst := inst
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			select {
+			case <-inst.Drain(DrainRestart):
+			case <-ctx.Done():
+				return
+			}
+			if err := inst.DrainError(); err != nil {
+				log.Error().Err(err).Uint16("port", inst.Port()).Msg("rolling restart: server was not drained cleanly")
+				errMu.Lock()
+				drainErrs = append(drainErrs, err)
+				errMu.Unlock()
+			}
+			m.waitForBatchReady(ctx, []*Instance{inst}, 120*time.Second)
+			log.Info().Uint16("port", inst.Port()).Msg("rolling restart: server cycled")
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.savePIDFile()
+	if len(drainErrs) > 0 {
+		return fmt.Errorf("rolling restart: %d of %d servers were not drained cleanly: %w",
+			len(drainErrs), len(servers), errors.Join(drainErrs...))
+	}
+	log.Info().Int("count", len(servers)).Msg("rolling restart complete")
+	return nil
+}
+
+// IsRollingRestart returns whether a rolling restart is in progress.
+func (m *Manager) IsRollingRestart() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.rollingRestart
+}
+
+// BeginMaintenance drains every running server with DrainStop, so that no
+// new matches start: idle servers stop right away and busy ones stop once
+// their match ends. Calling it again while in maintenance has no effect.
+func (m *Manager) BeginMaintenance() {
+	m.mu.Lock()
+	if m.maintenance {
+		m.mu.Unlock()
+		return
+	}
+	m.maintenance = true
+	m.maintenanceServers = m.maintenanceServers[:0]
+	for _, inst := range m.servers {
+		if inst.IsEnabled() && inst.IsRunning() && !inst.IsSleeping() {
+			m.maintenanceServers = append(m.maintenanceServers, inst)
+		}
+	}
+	servers := m.maintenanceServers
+	m.mu.Unlock()
+
+	log.Info().Int("servers", len(servers)).Msg("maintenance started, draining servers")
+
+	for _, inst := range servers {
+		inst.Drain(DrainStop)
+	}
+}
+
+// EndMaintenance starts the servers taken down by BeginMaintenance again.
+// Servers still finishing a match are started as soon as they have stopped.
+func (m *Manager) EndMaintenance(ctx context.Context) {
+	m.mu.Lock()
+	if !m.maintenance {
+		m.mu.Unlock()
+		return
+	}
+	m.maintenance = false
+	servers := m.maintenanceServers
+	m.maintenanceServers = nil
+	m.mu.Unlock()
+
+	log.Info().Int("servers", len(servers)).Msg("maintenance finished, starting servers")
+
+	sortByPort(servers)
+	for _, inst := range servers {
+		go func(inst *Instance) {
+			if inst.IsDraining() {
+				<-inst.Drain(DrainStop) // Already draining: returns the pending drain
+			}
+
+			m.startSemaphore <- struct{}{}
+			defer func() { <-m.startSemaphore }()
+
+			if err := inst.Start(ctx); err != nil {
+				log.Warn().Err(err).Uint16("port", inst.Port()).Msg("failed to start server after maintenance")
+			}
+		}(inst)
+	}
+}
+
+// InMaintenance returns whether a maintenance window is in progress.
+func (m *Manager) InMaintenance() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.maintenance
+}
+
 // CleanupLeftoverServers kills game servers from a previous run using the PID file.
 // This should be called BEFORE starting new servers.
 func (m *Manager) CleanupLeftoverServers() {
@@ -282,6 +503,10 @@ func (m *Manager) CleanupLeftoverServers() {
 		if err != nil {
 			continue
 		}
+		// Leave servers re-adopted by AdoptRunningServers alone
+		if m.ownsPID(pid) {
+			continue
+		}
 		// Try to kill the process
 		terminateProcessPlatform(pid)
 		killed++
@@ -318,6 +543,93 @@ func (m *Manager) savePIDFile() {
 	os.WriteFile(pidFile, []byte(strings.Join(lines, "\n")+"\n"), 0644)
 }
 
+// SleepServer takes a server out of rotation until it is woken. A server in
+// a match goes to sleep once the match ends. The sleep survives manager restarts.
+func (m *Manager) SleepServer(port uint16) error {
+	inst, ok := m.GetInstance(port)
+	if !ok {
+		return fmt.Errorf("server not found on port %d", port)
+	}
+
+	inst.Sleep()
+	m.saveSleepFile()
+	log.Info().Uint16("port", port).Msg("server sleep requested")
+	return nil
+}
+
+// WakeServer brings a sleeping server back into rotation.
+func (m *Manager) WakeServer(ctx context.Context, port uint16) error {
+	inst, ok := m.GetInstance(port)
+	if !ok {
+		return fmt.Errorf("server not found on port %d", port)
+	}
+
+	err := inst.Wake(ctx)
+	m.saveSleepFile()
+	if err != nil {
+		return err
+	}
+	log.Info().Uint16("port", port).Msg("server woken up")
+	return nil
+}
+
+// saveSleepFile persists the ports of sleeping servers.
+func (m *Manager) saveSleepFile() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sleepFile := filepath.Join("config", sleepFileName)
+	var lines []string
+	lines = append(lines, "# Energizer sleeping server ports - do not edit")
+	for port, inst := range m.servers {
+		if inst.IsSleeping() {
+			lines = append(lines, strconv.Itoa(int(port)))
+		}
+	}
+
+	if len(lines) <= 1 {
+		os.Remove(sleepFile)
+		return
+	}
+
+	if err := os.WriteFile(sleepFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		log.Warn().Err(err).Msg("failed to save sleeping servers")
+	}
+}
+
+// loadSleepFile restores the sleeping state saved by saveSleepFile.
+func (m *Manager) loadSleepFile() {
+	sleepFile := filepath.Join("config", sleepFileName)
+	f, err := os.Open(sleepFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	restored := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		port, err := strconv.ParseUint(line, 10, 16)
+		if err != nil {
+			continue
+		}
+		if inst, ok := m.servers[uint16(port)]; ok {
+			inst.mu.Lock()
+			inst.markSleeping(energizerCause("sleep_file"))
+			inst.mu.Unlock()
+			restored++
+		}
+	}
+
+	if restored > 0 {
+		log.Info().Int("count", restored).Msg("restored sleeping servers")
+	}
+}
+
 // RemovePIDFile removes the PID file (called during clean shutdown).
 func (m *Manager) RemovePIDFile() {
 	pidFile := filepath.Join("config", pidFileName)
@@ -337,7 +649,7 @@ func (m *Manager) StopAll() {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err := inst.Stop(); err != nil {
+			if err := inst.StopContext(WithCause(context.Background(), energizerCause("stop_all"))); err != nil {
 				log.Error().Err(err).Uint16("port", inst.Port()).Msg("failed to stop server")
 			}
 		}()
@@ -402,6 +714,16 @@ func (m *Manager) GetTotalServers() int {
 	return len(m.servers)
 }
 
+// MaxInstances returns how many servers this host can run: logical CPU cores
+// times svr_total_per_core.
+func (m *Manager) MaxInstances() int {
+	perCore := m.cfg.GetHoNData().ServersPerCore
+	if perCore < 1 {
+		perCore = 1
+	}
+	return runtime.NumCPU() * perCore
+}
+
 // GetRunningCount returns the number of currently running servers.
 func (m *Manager) GetRunningCount() int {
 	m.mu.RLock()
@@ -458,7 +780,12 @@ func (m *Manager) onServerAnnounce(ctx context.Context, event events.Event) erro
 	}
 
 	if inst, ok := m.GetInstance(payload.Port); ok {
-		inst.State().SetStatus(events.GameStatusReady)
+		if conn, ok := m.connRegistry.Get(payload.Port); ok {
+			inst.ConfigureForked(conn)
+		}
+		if err := inst.State().SetStatus(events.GameStatusReady, PacketCause("0x40")); err != nil {
+			return nil // Logged by the state machine
+		}
 		log.Info().Uint16("port", payload.Port).Msg("server announced and registered")
 	}
 	return nil
@@ -470,13 +797,25 @@ func (m *Manager) onServerClosed(ctx context.Context, event events.Event) error
 		return nil
 	}
 
-	if inst, ok := m.GetInstance(payload.Port); ok {
-		inst.State().SetStatus(events.GameStatusStopped)
+	if inst, ok := m.GetInstance(payload.Port); ok && !inst.IsSleeping() {
+		inst.State().SetStatus(events.GameStatusStopped, Cause{Source: "connection:closed", Actor: ActorGameServer})
 		log.Info().Uint16("port", payload.Port).Msg("server closed")
 	}
 	return nil
 }
 
+func (m *Manager) onServerCrashed(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerCrashedPayload)
+	if !ok {
+		return nil
+	}
+
+	if inst, ok := m.GetInstance(payload.Port); ok {
+		inst.HandleCrash(payload)
+	}
+	return nil
+}
+
 func (m *Manager) onServerStatus(ctx context.Context, event events.Event) error {
 	payload, ok := event.Payload.(events.ServerStatusPayload)
 	if !ok {
@@ -543,7 +882,20 @@ func (m *Manager) onReplayStatus(ctx context.Context, event events.Event) error
 }
 
 func (m *Manager) onCowMasterResponse(ctx context.Context, event events.Event) error {
-	// Handle CowMaster fork responses
+	payload, ok := event.Payload.(events.CowMasterResponsePayload)
+	if !ok {
+		return nil
+	}
+
+	m.mu.RLock()
+	cm := m.cowMaster
+	m.mu.RUnlock()
+
+	if cm == nil {
+		log.Warn().Uint16("port", payload.Port).Msg("CowMaster response received but no CowMaster is configured")
+		return nil
+	}
+	cm.HandleResponse(payload)
 	return nil
 }
 
@@ -554,7 +906,7 @@ func (m *Manager) onCmdShutdownServer(ctx context.Context, event events.Event) e
 	}
 
 	if inst, ok := m.GetInstance(payload.Port); ok {
-		return inst.Stop()
+		return inst.StopContext(WithCause(ctx, energizerCause("command:shutdown_server")))
 	}
 	return fmt.Errorf("server not found on port %d", payload.Port)
 }
@@ -565,13 +917,8 @@ func (m *Manager) onCmdWakeServer(ctx context.Context, event events.Event) error
 		return nil
 	}
 
-	if inst, ok := m.GetInstance(payload.Port); ok {
-		if inst.State().GetStatus() == events.GameStatusSleeping {
-			inst.State().SetStatus(events.GameStatusReady)
-			log.Info().Uint16("port", payload.Port).Msg("server woken up")
-		}
-	}
-	return nil
+	// Use background context — the woken server must outlive the caller.
+	return m.WakeServer(context.Background(), payload.Port)
 }
 
 func (m *Manager) onCmdSleepServer(ctx context.Context, event events.Event) error {
@@ -580,11 +927,7 @@ func (m *Manager) onCmdSleepServer(ctx context.Context, event events.Event) erro
 		return nil
 	}
 
-	if inst, ok := m.GetInstance(payload.Port); ok {
-		inst.State().SetStatus(events.GameStatusSleeping)
-		log.Info().Uint16("port", payload.Port).Msg("server put to sleep")
-	}
-	return nil
+	return m.SleepServer(payload.Port)
 }
 
 func (m *Manager) onCmdMessageServer(ctx context.Context, event events.Event) error {
@@ -604,25 +947,55 @@ func (m *Manager) onCmdMessageServer(ctx context.Context, event events.Event) er
 	return nil
 }
 
+// onCmdCommandServer sends a console command requested through the event bus.
+// The event source is recorded as the user.
+func (m *Manager) onCmdCommandServer(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerCommandPayload)
+	if !ok || len(payload.Args) == 0 {
+		return nil
+	}
+
+	return m.SendCommand(CommandRequest{
+		Port:    payload.Port,
+		Command: strings.Join(payload.Args, " "),
+		User:    event.Source,
+		Source:  event.Source,
+	})
+}
+
+// onConfigChanged applies a saved configuration change to the running fleet.
 func (m *Manager) onConfigChanged(ctx context.Context, event events.Event) error {
-	log.Info().Msg("configuration changed, reloading...")
-	// Re-read config and update servers as needed
+	// The API reconciles synchronously before emitting, to report the result
+	if event.Source == "api" {
+		return nil
+	}
+	m.ReconcileConfig()
 	return nil
 }
 
 func (m *Manager) onShutdown(ctx context.Context, event events.Event) error {
+	if m.cfg.GetHoNData().AdoptServers {
+		// Servers keep running and are re-adopted by the next Energizer run
+		log.Info().
+			Int("running", m.GetRunningCount()).
+			Msg("shutdown event received, leaving servers running for re-adoption")
+		m.connRegistry.CloseAll()
+		return nil
+	}
+
 	log.Info().Msg("shutdown event received, stopping all servers")
 	m.StopAll()
 	return nil
 }
 
-// calculateCPUAffinity assigns CPU cores to a server based on its index.
-func calculateCPUAffinity(serverIndex int, serversPerCore int) []int32 {
-	if serversPerCore <= 0 {
-		return nil
+// AffinityPlan returns the host CPU topology and the placement of every server.
+func (m *Manager) AffinityPlan() AffinityPlan {
+	instances := m.GetAllInstances()
+	servers := make([]*Instance, 0, len(instances))
+	for _, inst := range instances {
+		servers = append(servers, inst)
 	}
-	coreIndex := int32(serverIndex / serversPerCore)
-	return []int32{coreIndex}
+	return m.affinity.Plan(servers)
 }
 
 // AddServers dynamically adds new server instances.
@@ -630,6 +1003,18 @@ func (m *Manager) AddServers(ctx context.Context, count int) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.addInstancesLocked(ctx, count)
+
+	// Persist new total to config so it survives restart
+	m.persistTotalLocked()
+
+	log.Info().Int("count", count).Msg("added new servers")
+	return nil
+}
+
+// addInstancesLocked creates and starts count instances on the ports after
+// the highest one in use, returning their ports. Caller must hold m.mu.
+func (m *Manager) addInstancesLocked(ctx context.Context, count int) []uint16 {
 	honData := m.cfg.GetHoNData()
 
 	// Find the highest current port
@@ -640,19 +1025,23 @@ func (m *Manager) AddServers(ctx context.Context, count int) error {
 		}
 	}
 
+	serverCount := len(m.servers)
+	ports := make([]uint16, 0, count)
 	for i := 0; i < count; i++ {
 		port := maxPort + uint16(i)
-		serverIdx := len(m.servers) + i
+		serverIdx := serverCount + i
 		serverID := serverIdx + 1
-		affinity := calculateCPUAffinity(serverIdx, honData.ServersPerCore)
+		affinity := m.affinity.CoresFor(serverIdx)
 
 		inst := NewInstance(m.cfg, m.eventBus, InstanceConfig{
 			ID:          serverID,
 			Port:        port,
 			CPUAffinity: affinity,
+			CowMaster:   m.cowMaster,
 		})
 
 		m.servers[port] = inst
+		ports = append(ports, port)
 
 		go func(inst *Instance) {
 			if err := inst.Start(ctx); err != nil {
@@ -660,16 +1049,7 @@ func (m *Manager) AddServers(ctx context.Context, count int) error {
 			}
 		}(inst)
 	}
-
-	// Persist new total to config so it survives restart
-	honData.TotalServers = len(m.servers)
-	m.cfg.SetHoNData(honData)
-	if err := m.cfg.Save(); err != nil {
-		log.Warn().Err(err).Msg("failed to save config after adding servers")
-	}
-
-	log.Info().Int("count", count).Msg("added new servers")
-	return nil
+	return ports
 }
 
 // RemoveServers removes server instances (stops and removes from pool).
@@ -678,20 +1058,36 @@ func (m *Manager) RemoveServers(ports []uint16) error {
 	defer m.mu.Unlock()
 
 	for _, port := range ports {
-		if inst, ok := m.servers[port]; ok {
-			inst.Stop()
-			delete(m.servers, port)
-			log.Info().Uint16("port", port).Msg("server removed from pool")
-		}
+		m.removeInstanceLocked(port)
 	}
 
 	// Persist new total to config so it survives restart
+	m.persistTotalLocked()
+
+	return nil
+}
+
+// removeInstanceLocked stops the server on port and drops it from the pool.
+// Caller must hold m.mu.
+func (m *Manager) removeInstanceLocked(port uint16) {
+	inst, ok := m.servers[port]
+	if !ok {
+		return
+	}
+	inst.StopContext(WithCause(context.Background(), energizerCause("remove_server")))
+	inst.Console().Close()
+	delete(m.servers, port)
+	delete(m.removing, port)
+	log.Info().Uint16("port", port).Msg("server removed from pool")
+}
+
+// persistTotalLocked saves the current pool size as svr_total.
+// Caller must hold m.mu.
+func (m *Manager) persistTotalLocked() {
 	honData := m.cfg.GetHoNData()
-	honData.TotalServers = len(m.servers)
+	honData.TotalServers = len(m.servers) - len(m.removing)
 	m.cfg.SetHoNData(honData)
 	if err := m.cfg.Save(); err != nil {
-		log.Warn().Err(err).Msg("failed to save config after removing servers")
+		log.Warn().Err(err).Msg("failed to save config after changing the server count")
 	}
-
-	return nil
 }
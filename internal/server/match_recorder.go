@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/db"
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+// MatchRecorder persists the lifecycle of every match to the match history
+// database. A match is opened on EventLobbyCreated, follows the server's phase
+// transitions, player connections and lag events, and is closed on
+// EventLobbyClosed. GameState only holds the current match and is reset when
+// the lobby closes, so this is the only record that outlives it.
+type MatchRecorder struct {
+	mu      sync.Mutex
+	matches *db.MatchDatabase
+
+	// Matches in progress, by server port
+	active map[uint16]*db.Match
+}
+
+// NewMatchRecorder creates a recorder and subscribes it to the match events.
+// Matches left in progress by a previous run are resumed, so that matches on
+// adopted servers are completed normally.
+func NewMatchRecorder(eventBus *events.EventBus, matches *db.MatchDatabase) *MatchRecorder {
+	mr := &MatchRecorder{
+		matches: matches,
+		active:  make(map[uint16]*db.Match),
+	}
+
+	open, err := matches.OpenMatches()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to load in-progress matches")
+	}
+	for i := range open {
+		m := open[i]
+		mr.active[m.Port] = &m
+	}
+
+	eventBus.Subscribe(events.EventLobbyCreated, "match_recorder.lobbyCreated", mr.onLobbyCreated)
+	eventBus.Subscribe(events.EventLobbyClosed, "match_recorder.lobbyClosed", mr.onLobbyClosed)
+	eventBus.Subscribe(events.EventServerStatus, "match_recorder.serverStatus", mr.onServerStatus)
+	eventBus.Subscribe(events.EventPlayerConnection, "match_recorder.playerConnection", mr.onPlayerConnection)
+	eventBus.Subscribe(events.EventLongFrame, "match_recorder.longFrame", mr.onLongFrame)
+	eventBus.Subscribe(events.EventServerCrashed, "match_recorder.serverCrashed", mr.onServerCrashed)
+
+	return mr
+}
+
+func (mr *MatchRecorder) onLobbyCreated(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.LobbyCreatedPayload)
+	if !ok {
+		return nil
+	}
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	now := time.Now()
+
+	// A match still open on this port never saw its lobby close
+	if prev, ok := mr.active[payload.Port]; ok {
+		if prev.MatchID == payload.MatchID {
+			return nil // Duplicate announcement of the same lobby
+		}
+		mr.finish(prev, db.MatchStatusAborted, now)
+	}
+
+	if payload.MatchID == 0 {
+		log.Debug().Uint16("port", payload.Port).Msg("lobby created without match ID, not recording")
+		return nil
+	}
+
+	m := &db.Match{
+		MatchID:   payload.MatchID,
+		Port:      payload.Port,
+		MapName:   payload.MapName,
+		Mode:      payload.Mode,
+		Status:    db.MatchStatusInProgress,
+		StartedAt: now,
+		Phases: []db.MatchPhase{
+			{Phase: events.GamePhaseInLobby.String(), EnteredAt: now},
+		},
+		Players: []db.MatchPlayer{},
+	}
+	mr.active[payload.Port] = m
+	mr.save(m)
+
+	return nil
+}
+
+func (mr *MatchRecorder) onLobbyClosed(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerAnnouncePayload)
+	if !ok {
+		return nil
+	}
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if m, ok := mr.active[payload.Port]; ok {
+		mr.finish(m, db.MatchStatusCompleted, time.Now())
+	}
+	return nil
+}
+
+func (mr *MatchRecorder) onServerCrashed(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerCrashedPayload)
+	if !ok {
+		return nil
+	}
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if m, ok := mr.active[payload.Port]; ok {
+		mr.finish(m, db.MatchStatusAborted, time.Now())
+	}
+	return nil
+}
+
+func (mr *MatchRecorder) onServerStatus(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerStatusPayload)
+	if !ok {
+		return nil
+	}
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	m, ok := mr.active[payload.Port]
+	if !ok || payload.GamePhase == events.GamePhaseIdle {
+		return nil // The lobby close closes the match, not the idle status
+	}
+
+	phase := payload.GamePhase.String()
+	if n := len(m.Phases); n > 0 && m.Phases[n-1].Phase == phase {
+		return nil
+	}
+
+	now := time.Now()
+	closePhase(m, now)
+	m.Phases = append(m.Phases, db.MatchPhase{Phase: phase, EnteredAt: now})
+	mr.save(m)
+
+	return nil
+}
+
+func (mr *MatchRecorder) onPlayerConnection(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.PlayerConnectionPayload)
+	if !ok {
+		return nil
+	}
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	m, ok := mr.active[payload.Port]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	if payload.Connected {
+		m.Players = append(m.Players, db.MatchPlayer{
+			PlayerID: payload.PlayerID,
+			Name:     payload.PlayerName,
+			JoinedAt: now,
+		})
+	} else {
+		// Close the player's latest open connection
+		for i := len(m.Players) - 1; i >= 0; i-- {
+			p := &m.Players[i]
+			if p.LeftAt == nil && (p.PlayerID == payload.PlayerID || p.Name == payload.PlayerName) {
+				p.LeftAt = &now
+				break
+			}
+		}
+	}
+	mr.save(m)
+
+	return nil
+}
+
+// onLongFrame only updates the in-memory totals; they are written with the
+// next phase change or at the end of the match.
+func (mr *MatchRecorder) onLongFrame(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.LongFramePayload)
+	if !ok {
+		return nil
+	}
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	m, ok := mr.active[payload.Port]
+	if !ok {
+		return nil
+	}
+
+	m.LagEvents++
+	m.LagTotalMs += uint64(payload.FrameDuration)
+	if payload.FrameDuration > m.LagMaxMs {
+		m.LagMaxMs = payload.FrameDuration
+	}
+	return nil
+}
+
+// finish closes a match with the given status. Caller must hold mr.mu.
+func (mr *MatchRecorder) finish(m *db.Match, status string, now time.Time) {
+	closePhase(m, now)
+	for i := range m.Players {
+		if m.Players[i].LeftAt == nil {
+			m.Players[i].LeftAt = &now
+		}
+	}
+
+	m.Status = status
+	m.EndedAt = &now
+	m.DurationSec = int64(now.Sub(m.StartedAt).Seconds())
+	mr.save(m)
+	delete(mr.active, m.Port)
+
+	log.Info().
+		Uint32("match_id", m.MatchID).
+		Uint16("port", m.Port).
+		Str("status", status).
+		Int64("duration_sec", m.DurationSec).
+		Int("players", len(m.Players)).
+		Msg("match recorded")
+}
+
+// save writes a match to the database. Caller must hold mr.mu.
+func (mr *MatchRecorder) save(m *db.Match) {
+	if err := mr.matches.SaveMatch(m); err != nil {
+		log.Warn().Err(err).Uint32("match_id", m.MatchID).Msg("failed to save match history")
+	}
+}
+
+// closePhase records the duration of the match's current phase.
+func closePhase(m *db.Match, now time.Time) {
+	if n := len(m.Phases); n > 0 && m.Phases[n-1].DurationMs == 0 {
+		m.Phases[n-1].DurationMs = now.Sub(m.Phases[n-1].EnteredAt).Milliseconds()
+	}
+}
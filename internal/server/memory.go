@@ -0,0 +1,116 @@
+package server
+
+// ProcessMemory is the memory footprint of one process, read from
+// /proc/<pid>/smaps_rollup (Linux only).
+type ProcessMemory struct {
+	RSSMB    float64 `json:"rss_mb"`    // Resident, counting shared pages in full
+	PSSMB    float64 `json:"pss_mb"`    // Proportional: shared pages split between their users
+	USSMB    float64 `json:"uss_mb"`    // Unique: pages only this process maps
+	SharedMB float64 `json:"shared_mb"` // Resident pages shared with other processes
+	SwapMB   float64 `json:"swap_mb"`
+}
+
+// ServerMemory is the memory footprint of one game server.
+type ServerMemory struct {
+	Port   uint16        `json:"port"`
+	PID    int           `json:"pid"`
+	Forked bool          `json:"forked"` // Forked from the CowMaster
+	Memory ProcessMemory `json:"memory"`
+}
+
+// MemoryReport shows how much memory the game servers use and how much of it
+// they share with the CowMaster.
+type MemoryReport struct {
+	Servers    []ServerMemory `json:"servers"`
+	TotalRSSMB float64        `json:"total_rss_mb"`
+	TotalPSSMB float64        `json:"total_pss_mb"`
+	TotalUSSMB float64        `json:"total_uss_mb"`
+
+	CowMasterPID    int            `json:"cowmaster_pid,omitempty"`
+	CowMaster       *ProcessMemory `json:"cowmaster,omitempty"`
+	PoolChildren    int            `json:"pool_children"`
+	PoolPSSMB       float64        `json:"pool_pss_mb"`
+	SharedWithCowMB float64        `json:"shared_with_cowmaster_mb"` // Pages forked servers share instead of owning
+	SavedMB         float64        `json:"saved_mb"`                 // RSS minus PSS across the CowMaster, its pool, and forked servers
+}
+
+// MemoryReport returns the memory footprint of the running game servers. It
+// is empty on platforms without /proc/<pid>/smaps_rollup.
+func (m *Manager) MemoryReport() MemoryReport {
+	m.mu.RLock()
+	instances := make([]*Instance, 0, len(m.servers))
+	for _, inst := range m.servers {
+		instances = append(instances, inst)
+	}
+	cm := m.cowMaster
+	m.mu.RUnlock()
+
+	report := MemoryReport{Servers: []ServerMemory{}}
+	var forkedRSS, forkedPSS float64
+
+	for _, inst := range instances {
+		pid, forked, mem := inst.memory()
+		if mem == nil {
+			continue
+		}
+		report.Servers = append(report.Servers, ServerMemory{
+			Port:   inst.Port(),
+			PID:    pid,
+			Forked: forked,
+			Memory: *mem,
+		})
+		report.TotalRSSMB += mem.RSSMB
+		report.TotalPSSMB += mem.PSSMB
+		report.TotalUSSMB += mem.USSMB
+		if forked {
+			report.SharedWithCowMB += mem.SharedMB
+			forkedRSS += mem.RSSMB
+			forkedPSS += mem.PSSMB
+		}
+	}
+
+	if cm == nil {
+		return report
+	}
+
+	if pid := cm.PID(); pid > 0 {
+		if mem, err := readProcessMemory(pid); err == nil {
+			report.CowMasterPID = pid
+			report.CowMaster = mem
+			forkedRSS += mem.RSSMB
+			forkedPSS += mem.PSSMB
+		}
+	}
+	for _, pid := range cm.poolPIDs() {
+		if mem, err := readProcessMemory(pid); err == nil {
+			report.PoolChildren++
+			report.PoolPSSMB += mem.PSSMB
+			forkedRSS += mem.RSSMB
+			forkedPSS += mem.PSSMB
+		}
+	}
+	report.SavedMB = forkedRSS - forkedPSS
+
+	return report
+}
+
+// memory returns the PID and memory footprint of the running server, or a
+// nil footprint if it is not running or cannot be read.
+func (i *Instance) memory() (int, bool, *ProcessMemory) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.memoryLocked()
+}
+
+// memoryLocked is memory for callers holding i.mu.
+func (i *Instance) memoryLocked() (int, bool, *ProcessMemory) {
+	if !i.process.IsRunning() {
+		return 0, false, nil
+	}
+	pid := i.process.PID()
+	mem, err := readProcessMemory(pid)
+	if err != nil {
+		return pid, i.forked, nil
+	}
+	return pid, i.forked, mem
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readProcessMemory reads the memory footprint of pid from
+// /proc/<pid>/smaps_rollup (Linux 4.14+).
+func readProcessMemory(pid int) (*ProcessMemory, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/smaps_rollup", pid))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Values are in kB
+	fields := make(map[string]float64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 || !strings.HasSuffix(parts[0], ":") {
+			continue
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			continue
+		}
+		fields[strings.TrimSuffix(parts[0], ":")] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["Pss"]; !ok {
+		return nil, fmt.Errorf("no Pss in smaps_rollup of pid %d", pid)
+	}
+
+	const kbPerMB = 1024
+	return &ProcessMemory{
+		RSSMB:    fields["Rss"] / kbPerMB,
+		PSSMB:    fields["Pss"] / kbPerMB,
+		USSMB:    (fields["Private_Clean"] + fields["Private_Dirty"]) / kbPerMB,
+		SharedMB: (fields["Shared_Clean"] + fields["Shared_Dirty"]) / kbPerMB,
+		SwapMB:   fields["Swap"] / kbPerMB,
+	}, nil
+}
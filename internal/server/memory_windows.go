@@ -0,0 +1,10 @@
+//go:build windows
+
+package server
+
+import "fmt"
+
+// readProcessMemory is not supported on Windows.
+func readProcessMemory(pid int) (*ProcessMemory, error) {
+	return nil, fmt.Errorf("PSS/USS not supported on Windows")
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+// HaltForPatch takes the fleet down so the install can be patched: it starts
+// maintenance (unless a maintenance window already did), waits until every
+// server it drained has stopped, and stops the CowMaster, which holds the
+// game binary open. Busy servers stop once their match ends, so this can take
+// as long as a match. It returns the servers it stopped.
+func (m *Manager) HaltForPatch(ctx context.Context) ([]*Instance, error) {
+	m.BeginMaintenance()
+
+	m.mu.RLock()
+	servers := append([]*Instance(nil), m.maintenanceServers...)
+	m.mu.RUnlock()
+
+	log.Info().Int("servers", len(servers)).Msg("waiting for servers to stop before patching")
+
+	for _, inst := range servers {
+		select {
+		case <-inst.Drain(DrainStop): // Already draining: returns the pending drain
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if m.cowMaster != nil {
+		if err := m.cowMaster.Stop(); err != nil {
+			log.Warn().Err(err).Msg("failed to stop CowMaster before patching")
+		}
+	}
+	return servers, nil
+}
+
+// ResumeAfterPatch relaunches the CowMaster, ends maintenance, starts servers
+// (normally the ones returned by HaltForPatch), and waits up to timeout for
+// them to report READY. It returns the ports of the servers that did not.
+func (m *Manager) ResumeAfterPatch(ctx context.Context, servers []*Instance, timeout time.Duration) []uint16 {
+	if m.cowMaster != nil {
+		if err := m.cowMaster.Relaunch(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to relaunch CowMaster after patching, servers will be exec'd")
+		}
+	}
+
+	// EndMaintenance starts the servers maintenance took down; the others
+	// (e.g. ones that crashed on a bad patch before a rollback) are started
+	// here
+	m.mu.RLock()
+	inMaintenance := make(map[*Instance]bool, len(m.maintenanceServers))
+	for _, inst := range m.maintenanceServers {
+		inMaintenance[inst] = true
+	}
+	m.mu.RUnlock()
+	m.EndMaintenance(ctx)
+
+	for _, inst := range servers {
+		if inMaintenance[inst] || inst.IsRunning() {
+			continue
+		}
+		go func(inst *Instance) {
+			m.startSemaphore <- struct{}{}
+			defer func() { <-m.startSemaphore }()
+
+			if err := inst.Start(ctx); err != nil {
+				log.Warn().Err(err).Uint16("port", inst.Port()).Msg("failed to start server after patching")
+			}
+		}(inst)
+	}
+
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		var notReady []uint16
+		for _, inst := range servers {
+			status := inst.State().GetStatus()
+			if status != events.GameStatusReady && status != events.GameStatusOccupied {
+				notReady = append(notReady, inst.Port())
+			}
+		}
+		if len(notReady) == 0 {
+			m.savePIDFile()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return notReady
+		case <-deadline:
+			sort.Slice(notReady, func(i, j int) bool { return notReady[i] < notReady[j] })
+			return notReady
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetInstalledVersion records that the install now runs version: svr_version
+// is saved, and the change is treated as already in effect rather than
+// waiting for a manager restart.
+func (m *Manager) SetInstalledVersion(version string) error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	honData := m.cfg.GetHoNData()
+	honData.ServerVersion = version
+	m.cfg.SetHoNData(honData)
+	m.versionAppliedLocked(version)
+
+	if err := m.cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save svr_version %s: %w", version, err)
+	}
+	return nil
+}
+
+// versionAppliedLocked marks svr_version = version as in effect, so it is
+// not reported as waiting for a manager restart. Caller must hold m.reloadMu.
+func (m *Manager) versionAppliedLocked(version string) {
+	m.appliedHoN.ServerVersion = version
+	m.bootHoN.ServerVersion = version
+	m.SetHoNVersion(version)
+}
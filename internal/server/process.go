@@ -31,6 +31,17 @@ type ProcessManager struct {
 	exitCode  int
 	exitErr   error
 
+	// Exit tracking: stopRequested distinguishes Stop()/Kill() from crashes,
+	// exited is closed by monitor() once cmd.Wait() returns, or by
+	// monitorDirect() once it sees the process gone.
+	onExit        func(ProcessExit)
+	stopRequested bool
+	exited        chan struct{}
+
+	// adopted is set for a process started by a previous Energizer run.
+	// It is not our child, so it is polled by monitorDirect and stopped by PID.
+	adopted bool
+
 	// Configuration
 	executable   string
 	args         []string
@@ -39,6 +50,20 @@ type ProcessManager struct {
 	highPriority bool
 	envVars      map[string]string
 
+	// cgroup is the configured cgroup (Linux only, nil if disabled);
+	// cgroupPath is set while the process actually runs in it.
+	cgroup     *CgroupLimits
+	cgroupPath string
+
+	// sandbox isolates the process (Linux only, nil if disabled)
+	sandbox *SandboxOptions
+
+	// console receives stdout/stderr (Linux only, discarded if nil)
+	console *Console
+	// consoleFile, if set, receives stdout/stderr instead of console
+	// (Linux only)
+	consoleFile string
+
 	// Platform-specific: Windows process handle from CreateProcessW
 	// Used for reliable TerminateProcess during shutdown.
 	// On Linux this is unused (zero value).
@@ -54,6 +79,20 @@ type ProcessConfig struct {
 	CPUAffinity  []int32
 	HighPriority bool
 	EnvVars      map[string]string // Environment variable overrides (USERPROFILE, APPDATA, etc.)
+	OnExit       func(ProcessExit) // Called once when the process exits, after state is updated
+	Cgroup       *CgroupLimits     // cgroup v2 group and limits (Linux only, nil to disable)
+	Sandbox      *SandboxOptions   // User, home and namespace isolation (Linux only, nil to disable)
+	Console      *Console          // Receives stdout/stderr (Linux only, nil to discard)
+	ConsoleFile  string            // Appended stdout/stderr instead of Console, outliving Energizer (Linux only)
+}
+
+// ProcessExit describes how a game server process terminated.
+type ProcessExit struct {
+	PID       int
+	ExitCode  int
+	Signal    string // Terminating signal name (Linux only, empty otherwise)
+	Uptime    time.Duration
+	Requested bool // true when the exit followed Stop() or Kill()
 }
 
 // NewProcessManager creates a new process manager for a game server.
@@ -66,6 +105,11 @@ func NewProcessManager(cfg ProcessConfig) *ProcessManager {
 		cpuAffinity:  cfg.CPUAffinity,
 		highPriority: cfg.HighPriority,
 		envVars:      cfg.EnvVars,
+		onExit:       cfg.OnExit,
+		cgroup:       cfg.Cgroup,
+		sandbox:      cfg.Sandbox,
+		console:      cfg.Console,
+		consoleFile:  cfg.ConsoleFile,
 		logger: log.With().
 			Str("component", "process").
 			Uint16("port", cfg.Port).
@@ -73,6 +117,30 @@ func NewProcessManager(cfg ProcessConfig) *ProcessManager {
 	}
 }
 
+// Reconfigure replaces the launch settings used by the next Start. It fails
+// while a process is running.
+func (pm *ProcessManager) Reconfigure(cfg ProcessConfig) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.running {
+		return fmt.Errorf("process running (pid: %d)", pm.pid)
+	}
+
+	pm.executable = cfg.Executable
+	pm.args = cfg.Args
+	pm.workDir = cfg.WorkDir
+	pm.cpuAffinity = cfg.CPUAffinity
+	pm.highPriority = cfg.HighPriority
+	pm.envVars = cfg.EnvVars
+	pm.onExit = cfg.OnExit
+	pm.cgroup = cfg.Cgroup
+	pm.sandbox = cfg.Sandbox
+	pm.console = cfg.Console
+	pm.consoleFile = cfg.ConsoleFile
+	return nil
+}
+
 // Start launches the game server process.
 // On Windows: uses CreateProcessW directly (bypassing Go's exec wrapper) to ensure
 // the command line is passed exactly as Python's subprocess.Popen would.
@@ -91,9 +159,55 @@ func (pm *ProcessManager) Start(ctx context.Context) error {
 		Str("workdir", pm.workDir).
 		Msg("starting game server process")
 
+	pm.stopRequested = false
+	pm.adopted = false
 	return pm.startPlatform(ctx)
 }
 
+// Adopt attaches the manager to an already running game server process,
+// e.g. one left behind by a previous Energizer run.
+func (pm *ProcessManager) Adopt(pid int) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.running {
+		return fmt.Errorf("process already running (pid: %d)", pm.pid)
+	}
+
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to attach to pid %d: %w", pid, err)
+	}
+
+	startedAt := time.Now()
+	if ms, err := p.CreateTime(); err == nil {
+		startedAt = time.UnixMilli(ms)
+	}
+
+	pm.cmd = nil
+	pm.proc = p
+	pm.pid = pid
+	pm.processHandle = 0
+	pm.running = true
+	pm.adopted = true
+	pm.startedAt = startedAt
+	pm.exitCode = -1
+	pm.exitErr = nil
+	pm.stopRequested = false
+
+	pm.logger.Info().
+		Int("pid", pid).
+		Time("started_at", startedAt).
+		Msg("adopted running game server process")
+
+	pm.enterCgroup()
+
+	pm.exited = make(chan struct{})
+	go pm.monitorDirect(context.Background(), pm.exited)
+
+	return nil
+}
+
 // monitorDirect monitors a directly-created Windows process using gopsutil.
 // This replaces monitor() for processes started without exec.Cmd.
 //
@@ -101,7 +215,7 @@ func (pm *ProcessManager) Start(ctx context.Context) error {
 // polling but does NOT terminate the game server — explicit Stop() or Kill()
 // should be used for that. This prevents HTTP-request-scoped contexts from
 // accidentally killing the game server as soon as the request completes.
-func (pm *ProcessManager) monitorDirect(_ context.Context) {
+func (pm *ProcessManager) monitorDirect(_ context.Context, exited chan struct{}) {
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -118,14 +232,28 @@ func (pm *ProcessManager) monitorDirect(_ context.Context) {
 
 		running, err := proc.IsRunning()
 		if err != nil || !running {
+			close(exited)
+
 			pm.mu.Lock()
 			pm.running = false
 			pm.exitCode = -1 // Unknown exit code for direct process
+			pm.leaveCgroup()
+			exit := ProcessExit{
+				PID:       pid,
+				ExitCode:  pm.exitCode,
+				Uptime:    time.Since(pm.startedAt),
+				Requested: pm.stopRequested,
+			}
+			onExit := pm.onExit
 			pm.mu.Unlock()
 
 			pm.logger.Info().
 				Int("pid", pid).
 				Msg("game server process exited")
+
+			if onExit != nil {
+				onExit(exit)
+			}
 			return
 		}
 	}
@@ -143,6 +271,7 @@ func (pm *ProcessManager) Stop() error {
 	}
 
 	pm.logger.Info().Int("pid", pm.pid).Msg("stopping game server process")
+	pm.stopRequested = true
 
 	if runtime.GOOS == "windows" {
 		// Windows: use stored process handle for reliable TerminateProcess
@@ -158,6 +287,19 @@ func (pm *ProcessManager) Stop() error {
 		return nil
 	}
 
+	// Linux: adopted processes are signalled by PID. Still marked running,
+	// so nothing else starts while the lock is released for the wait.
+	if pm.adopted {
+		pid, exited := pm.pid, pm.exited
+		pm.mu.Unlock()
+		pm.stopAdopted(pid, exited)
+		pm.mu.Lock()
+		if pm.adopted && pm.pid == pid {
+			pm.running = false
+		}
+		return nil
+	}
+
 	// Linux: Try SIGTERM first for graceful shutdown
 	if pm.cmd == nil || pm.cmd.Process == nil {
 		pm.running = false
@@ -170,14 +312,10 @@ func (pm *ProcessManager) Stop() error {
 		return pm.cmd.Process.Kill()
 	}
 
-	// Give it 10 seconds to shut down gracefully
-	done := make(chan error, 1)
-	go func() {
-		done <- pm.cmd.Wait()
-	}()
-
+	// Give it 10 seconds to shut down gracefully. monitor() owns cmd.Wait(),
+	// so wait for it to observe the exit rather than calling Wait twice.
 	select {
-	case <-done:
+	case <-pm.exited:
 		pm.logger.Info().Msg("process stopped gracefully")
 	case <-time.After(10 * time.Second):
 		pm.logger.Warn().Msg("process didn't stop in 10s, force killing")
@@ -198,6 +336,7 @@ func (pm *ProcessManager) Kill() error {
 	}
 
 	pm.logger.Warn().Int("pid", pm.pid).Msg("force killing game server process")
+	pm.stopRequested = true
 
 	if runtime.GOOS == "windows" {
 		if pm.processHandle != 0 {
@@ -215,10 +354,37 @@ func (pm *ProcessManager) Kill() error {
 		return err
 	}
 
+	if pm.adopted {
+		terminateProcessPlatform(pm.pid)
+	}
+
 	pm.running = false
 	return nil
 }
 
+// stopAdopted interrupts an adopted process and force kills it if it has not
+// exited within 10 seconds. monitorDirect() closes exited once it is gone.
+// Must be called without pm.mu held.
+func (pm *ProcessManager) stopAdopted(pid int, exited chan struct{}) {
+	p, err := os.FindProcess(pid)
+	if err == nil {
+		err = p.Signal(os.Interrupt)
+	}
+	if err != nil {
+		pm.logger.Warn().Err(err).Msg("graceful shutdown failed, force killing")
+		terminateProcessPlatform(pid)
+		return
+	}
+
+	select {
+	case <-exited:
+		pm.logger.Info().Msg("process stopped gracefully")
+	case <-time.After(10 * time.Second):
+		pm.logger.Warn().Msg("process didn't stop in 10s, force killing")
+		terminateProcessPlatform(pid)
+	}
+}
+
 // IsRunning returns whether the process is currently running.
 func (pm *ProcessManager) IsRunning() bool {
 	pm.mu.Lock()
@@ -356,27 +522,44 @@ func (pm *ProcessManager) setCPUAffinity() {
 }
 
 // monitor watches the process and updates state when it exits.
-func (pm *ProcessManager) monitor() {
-	if pm.cmd == nil {
-		return
-	}
-
-	err := pm.cmd.Wait()
+// It is the only caller of cmd.Wait(); Stop() waits on the exited channel.
+func (pm *ProcessManager) monitor(cmd *exec.Cmd, exited chan struct{}) {
+	err := cmd.Wait()
+	close(exited)
 
 	pm.mu.Lock()
+	if pm.cmd != cmd {
+		// A newer process was started after Stop(); nothing to report.
+		pm.mu.Unlock()
+		return
+	}
 	pm.running = false
 	pm.exitErr = err
-	if pm.cmd.ProcessState != nil {
-		pm.exitCode = pm.cmd.ProcessState.ExitCode()
+	pm.leaveCgroup()
+	var signal string
+	if cmd.ProcessState != nil {
+		pm.exitCode = cmd.ProcessState.ExitCode()
+		signal = exitSignal(cmd.ProcessState)
 	}
-	pid := pm.pid
-	exitCode := pm.exitCode
+	exit := ProcessExit{
+		PID:       pm.pid,
+		ExitCode:  pm.exitCode,
+		Signal:    signal,
+		Uptime:    time.Since(pm.startedAt),
+		Requested: pm.stopRequested,
+	}
+	onExit := pm.onExit
 	pm.mu.Unlock()
 
 	pm.logger.Info().
-		Int("pid", pid).
-		Int("exit_code", exitCode).
+		Int("pid", exit.PID).
+		Int("exit_code", exit.ExitCode).
+		Str("signal", exit.Signal).
 		Msg("game server process exited")
+
+	if onExit != nil {
+		onExit(exit)
+	}
 }
 
 // NOTE: The following functions are implemented in platform-specific files:
@@ -384,3 +567,4 @@ func (pm *ProcessManager) monitor() {
 //   setWindowsPriority(pid int, high bool) error       → process_windows.go / process_linux.go
 //   setCPUAffinityPlatform(pid int, cores []int32)     → process_windows.go / process_linux.go
 //   terminateProcessPlatform(pid int)                  → process_windows.go / process_linux.go
+//   exitSignal(state *os.ProcessState) string          → process_windows.go / process_linux.go
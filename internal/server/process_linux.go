@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
@@ -46,7 +47,29 @@ func (pm *ProcessManager) startPlatform(_ context.Context) error {
 
 	setPlatformProcessAttrs(pm.cmd)
 
-	if err := pm.cmd.Start(); err != nil {
+	if pm.sandbox != nil {
+		if err := applySandbox(pm.cmd, pm.sandbox); err != nil {
+			return fmt.Errorf("failed to set up sandbox: %w", err)
+		}
+	}
+
+	var releaseOutput func(started bool)
+	var err error
+	switch {
+	case pm.consoleFile != "":
+		releaseOutput, err = consoleFile(pm.cmd, pm.consoleFile)
+	case pm.console != nil:
+		releaseOutput, err = consolePipes(pm.cmd, pm.console)
+	}
+	if err != nil {
+		pm.logger.Warn().Err(err).Msg("failed to capture console output")
+	}
+
+	err = pm.cmd.Start()
+	if releaseOutput != nil {
+		releaseOutput(err == nil)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to start process: %w", err)
 	}
 
@@ -64,15 +87,71 @@ func (pm *ProcessManager) startPlatform(_ context.Context) error {
 		pm.proc = p
 	}
 
+	pm.enterCgroup()
+
 	if len(pm.cpuAffinity) > 0 {
 		go pm.setCPUAffinity()
 	}
 
-	go pm.monitor()
+	pm.exited = make(chan struct{})
+	go pm.monitor(pm.cmd, pm.exited)
 
 	return nil
 }
 
+// consolePipes redirects cmd's stdout and stderr into pipes read by console.
+// The parent's write ends are passed to the child, so the returned function
+// must be called once Start has returned to close them; the readers then see
+// EOF when the game server exits. Pipes are used instead of an io.Writer so
+// that cmd.Wait does not block on output copying.
+func consolePipes(cmd *exec.Cmd, console *Console) (func(started bool), error) {
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		return nil, err
+	}
+
+	cmd.Stdout = outW
+	cmd.Stderr = errW
+
+	return func(started bool) {
+		outW.Close()
+		errW.Close()
+		if !started {
+			outR.Close()
+			errR.Close()
+			return
+		}
+		go console.Capture(outR, ConsoleStdout)
+		go console.Capture(errR, ConsoleStderr)
+	}, nil
+}
+
+// consoleFile redirects cmd's stdout and stderr into the file at path,
+// opened for appending. Unlike consolePipes, the child writes to the file
+// directly and keeps doing so after Energizer exits, which adopted servers
+// need. The returned function closes the parent's handle once Start has
+// returned.
+func consoleFile(cmd *exec.Cmd, path string) (func(started bool), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.Stdout = f
+	cmd.Stderr = f
+
+	return func(bool) { f.Close() }, nil
+}
+
 // syscallHandle is a no-op on Linux (returns 0).
 func syscallHandle(h uintptr) uintptr { return h }
 
@@ -87,17 +166,14 @@ func terminateProcessWithHandle(handle uintptr) error {
 // setPlatformProcessAttrs sets Linux-specific process creation attributes.
 // Mirrors HoNfigurator-Central: subprocess.Popen(cmdline, close_fds=True,
 //
-//	start_new_session=True, stdout=subprocess.DEVNULL, stderr=subprocess.DEVNULL)
+//	start_new_session=True)
+//
+// stdout/stderr are set by consolePipes or consoleFile; exec sends them to
+// /dev/null when neither is used.
 func setPlatformProcessAttrs(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid: true, // Equivalent to start_new_session=True
 	}
-	// Redirect stdout/stderr to /dev/null (matches subprocess.DEVNULL)
-	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
-	if err == nil {
-		cmd.Stdout = devnull
-		cmd.Stderr = devnull
-	}
 }
 
 // setWindowsPriority is a no-op on Linux.
@@ -110,6 +186,15 @@ func terminateProcessPlatform(pid int) {
 	syscall.Kill(pid, syscall.SIGKILL)
 }
 
+// exitSignal returns the name of the signal that terminated the process,
+// or an empty string if it exited normally.
+func exitSignal(state *os.ProcessState) string {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ws.Signal().String()
+	}
+	return ""
+}
+
 // setCPUAffinityPlatform sets CPU affinity for a process on Linux.
 // Uses sched_setaffinity syscall, mirroring psutil.Process.cpu_affinity().
 func setCPUAffinityPlatform(pid int, cores []int32) error {
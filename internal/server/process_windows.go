@@ -5,6 +5,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"syscall"
@@ -74,7 +75,8 @@ func (pm *ProcessManager) startPlatform(ctx context.Context) error {
 	}
 
 	// Monitor the process using gopsutil (since we don't have exec.Cmd)
-	go pm.monitorDirect(ctx)
+	pm.exited = make(chan struct{})
+	go pm.monitorDirect(ctx, pm.exited)
 
 	return nil
 }
@@ -320,6 +322,11 @@ func terminateProcessPlatform(pid int) {
 	cmd.Run()
 }
 
+// exitSignal always returns an empty string on Windows (no POSIX signals).
+func exitSignal(state *os.ProcessState) string {
+	return ""
+}
+
 // setCPUAffinityPlatform sets CPU affinity for a process on Windows.
 // Mirrors HoNfigurator-Central: psutil.Process(pid).cpu_affinity([...])
 func setCPUAffinityPlatform(pid int, cores []int32) error {
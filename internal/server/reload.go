@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+// ConfigReport describes what a configuration reload changed and what is
+// still waiting to take effect.
+type ConfigReport struct {
+	Changes []config.FieldChange `json:"changes"` // Changed since the previous reload
+	Pending []config.FieldChange `json:"pending"` // Saved but not yet in effect everywhere
+
+	ServersAdded      []uint16 `json:"servers_added"`
+	ServersRemoving   []uint16 `json:"servers_removing"`   // Removed, or removed once their match ends
+	ServersRestarting []uint16 `json:"servers_restarting"` // Idle, restarted now
+	ServersStale      []uint16 `json:"servers_stale"`      // Restarted once their match ends
+}
+
+// ReconcileConfig compares the saved configuration with the one last applied
+// and brings the fleet in line:
+//
+//   - live changes take effect right away; svr_total adds or removes servers
+//     (busy servers are removed once their match ends),
+//   - idle_restart changes are rolled out by restarting idle servers in
+//     batches, while busy servers restart once they are idle,
+//   - manager_restart changes are only reported as pending.
+//
+// It is safe to call repeatedly; a reconcile without changes does nothing.
+func (m *Manager) ReconcileConfig() ConfigReport {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	honData := m.cfg.GetHoNData()
+	appData := m.cfg.GetApplicationData()
+
+	changes := append(
+		config.DiffHoNData(m.appliedHoN, honData),
+		config.DiffApplicationData(m.appliedApp, appData)...,
+	)
+	m.appliedHoN, m.appliedApp = honData, appData
+
+	for _, ch := range changes {
+		log.Info().
+			Str("field", ch.Field).
+			Str("apply", string(ch.Apply)).
+			Msg("configuration changed")
+	}
+
+	report := ConfigReport{Changes: changes}
+
+	// Fleet size is compared with the pool rather than the previous config,
+	// since AddServers/RemoveServers persist svr_total themselves
+	report.ServersAdded, report.ServersRemoving = m.reconcileFleetSize(honData.TotalServers)
+
+	if changedUnder(changes, "application_data.restart_policy") {
+		for _, inst := range m.GetAllInstances() {
+			inst.reloadRestartPolicy()
+		}
+	}
+	if changedUnder(changes, "hon_data.man_cowmaster_pool_size") && m.cowMaster != nil {
+		m.cowMaster.triggerRefill()
+	}
+
+	if idle := config.FilterChanges(changes, config.ApplyIdleRestart); len(idle) > 0 {
+		m.staleChanges = mergeChanges(m.staleChanges, idle)
+		report.ServersRestarting, report.ServersStale = m.markStaleServers(report.ServersAdded)
+	}
+
+	report.Pending = m.pendingChanges(honData, appData)
+	if report.Changes == nil {
+		report.Changes = []config.FieldChange{}
+	}
+	return report
+}
+
+// reconcileFleetSize adds or removes servers until the pool (not counting
+// servers already on their way out) holds target servers. Idle and stopped
+// servers are removed before busy ones, highest port first.
+func (m *Manager) reconcileFleetSize(target int) (added, removing []uint16) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active := len(m.servers) - len(m.removing)
+	switch {
+	case target > active:
+		added = m.addInstancesLocked(context.Background(), target-active)
+		log.Info().Int("count", len(added)).Int("total", target).Msg("svr_total raised, added servers")
+
+	case target < active && target >= 0:
+		candidates := make([]*Instance, 0, active)
+		for port, inst := range m.servers {
+			if !m.removing[port] {
+				candidates = append(candidates, inst)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			iBusy, jBusy := isBusy(candidates[i]), isBusy(candidates[j])
+			if iBusy != jBusy {
+				return jBusy
+			}
+			return candidates[i].Port() > candidates[j].Port()
+		})
+
+		for _, inst := range candidates[:active-target] {
+			port := inst.Port()
+			removing = append(removing, port)
+			if !isBusy(inst) {
+				m.removeInstanceLocked(port)
+				continue
+			}
+
+			m.removing[port] = true
+			go func(inst *Instance) {
+				<-inst.Drain(DrainStop)
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				if m.removing[inst.Port()] {
+					m.removeInstanceLocked(inst.Port())
+				}
+			}(inst)
+		}
+		log.Info().Int("count", len(removing)).Int("total", target).Msg("svr_total lowered, removing servers")
+	}
+	return added, removing
+}
+
+// markStaleServers flags every running server (except the ones just added)
+// for a restart and returns the ports restarted now and those that restart
+// once their match ends.
+func (m *Manager) markStaleServers(skip []uint16) (restarting, stale []uint16) {
+	skipped := make(map[uint16]bool, len(skip))
+	for _, port := range skip {
+		skipped[port] = true
+	}
+
+	m.mu.RLock()
+	servers := make([]*Instance, 0, len(m.servers))
+	for port, inst := range m.servers {
+		if !skipped[port] && !m.removing[port] {
+			servers = append(servers, inst)
+		}
+	}
+	m.mu.RUnlock()
+
+	return m.restartWhenIdle(servers)
+}
+
+// restartWhenIdle flags the running servers among servers for a restart,
+// restarts the idle ones in batches, and leaves the busy ones to restart once
+// their match ends (see restartDecision). It returns the ports of both.
+func (m *Manager) restartWhenIdle(servers []*Instance) (restarting, stale []uint16) {
+	var idle []*Instance
+	for _, inst := range servers {
+		if !inst.markConfigStale() {
+			continue
+		}
+		if isBusy(inst) {
+			stale = append(stale, inst.Port())
+		} else {
+			idle = append(idle, inst)
+			restarting = append(restarting, inst.Port())
+		}
+	}
+
+	sortByPort(idle)
+	sort.Slice(stale, func(i, j int) bool { return stale[i] < stale[j] })
+	sort.Slice(restarting, func(i, j int) bool { return restarting[i] < restarting[j] })
+
+	if len(idle) > 0 {
+		go m.rollStaleServers(idle)
+	}
+	return restarting, stale
+}
+
+// rollStaleServers restarts servers in batches of svr_max_concurrent_starts,
+// waiting for each batch to become READY before the next. A server that
+// has started a match in the meantime is restarted once it ends.
+func (m *Manager) rollStaleServers(servers []*Instance) {
+	ctx := context.Background()
+	batchSize := cap(m.startSemaphore)
+
+	log.Info().Int("count", len(servers)).Int("batch_size", batchSize).Msg("restarting idle servers to apply configuration changes")
+
+	for start := 0; start < len(servers); start += batchSize {
+		end := start + batchSize
+		if end > len(servers) {
+			end = len(servers)
+		}
+
+		var batch []*Instance
+		var drained []<-chan struct{}
+		for _, inst := range servers[start:end] {
+			// Already restarted by something else
+			if !inst.ConfigStale() {
+				continue
+			}
+			batch = append(batch, inst)
+			drained = append(drained, inst.Drain(DrainRestart))
+		}
+		for _, done := range drained {
+			<-done
+		}
+		if len(batch) > 0 {
+			m.waitForBatchReady(ctx, batch, 120*time.Second)
+		}
+	}
+
+	m.savePIDFile()
+}
+
+// pendingChanges lists the changes that are saved but not yet in effect:
+// idle_restart changes while some server still runs the old settings, and
+// manager_restart changes made since Energizer started.
+func (m *Manager) pendingChanges(honData config.HoNData, appData config.ApplicationData) []config.FieldChange {
+	stale := false
+	for _, inst := range m.GetAllInstances() {
+		if inst.ConfigStale() {
+			stale = true
+			break
+		}
+	}
+	if !stale {
+		m.staleChanges = nil
+	}
+
+	pending := append([]config.FieldChange{}, m.staleChanges...) // Never nil, for the API
+	boot := append(
+		config.DiffHoNData(m.bootHoN, honData),
+		config.DiffApplicationData(m.bootApp, appData)...,
+	)
+	return append(pending, config.FilterChanges(boot, config.ApplyManagerRestart)...)
+}
+
+// mergeChanges folds newer changes into older ones, keeping the oldest Old
+// value per field and dropping fields that went back to it.
+func mergeChanges(older, newer []config.FieldChange) []config.FieldChange {
+	merged := append([]config.FieldChange{}, older...)
+	for _, ch := range newer {
+		found := false
+		for idx := range merged {
+			if merged[idx].Field == ch.Field {
+				merged[idx].New = ch.New
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, ch)
+		}
+	}
+
+	out := merged[:0]
+	for _, ch := range merged {
+		if !reflect.DeepEqual(ch.Old, ch.New) {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// changedUnder reports whether any change is at or below path.
+func changedUnder(changes []config.FieldChange, path string) bool {
+	for _, ch := range changes {
+		if ch.Field == path || strings.HasPrefix(ch.Field, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isBusy reports whether a server is hosting a lobby or match.
+func isBusy(inst *Instance) bool {
+	return inst.State().GetPhase() != events.GamePhaseIdle ||
+		inst.State().GetStatus() == events.GameStatusOccupied
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/energizer-project/energizer/internal/config"
+)
+
+// RestartRule is one condition of a RestartPolicy.
+type RestartRule interface {
+	// Name identifies the rule in instance info and logs.
+	Name() string
+	// Evaluate reports whether the rule fires for the given server statistics,
+	// and when it is expected to fire (zero if it cannot be predicted).
+	Evaluate(stats RestartStats) (due bool, at time.Time)
+}
+
+// RestartStats is what restart rules are evaluated against.
+type RestartStats struct {
+	Now        time.Time
+	StartedAt  time.Time // When the current process started
+	Matches    int       // Matches completed since the process started
+	MemoryMB   float64   // Current RSS
+	BaselineMB float64   // RSS when the server first became READY (0 if not yet measured)
+}
+
+// RestartPolicy decides when a server is due for a periodic restart.
+// It is built from config on every server start, so configuration changes
+// apply from the next start.
+type RestartPolicy struct {
+	rules []RestartRule
+}
+
+// RestartDecision is the outcome of evaluating a RestartPolicy.
+type RestartDecision struct {
+	Due  bool      // A rule has fired and the server restarts once idle
+	Rule string    // The rule that fired, or the one expected to fire first
+	At   time.Time // When Rule is expected to fire (zero if unknown)
+}
+
+// NewRestartPolicy builds a policy from the enabled rules in cfg.
+func NewRestartPolicy(cfg config.RestartPolicyConfig) *RestartPolicy {
+	p := &RestartPolicy{}
+
+	if cfg.MaxUptimeHours > 0 {
+		maxUptime := time.Duration(cfg.MaxUptimeHours) * time.Hour
+		if cfg.UptimeJitterHours > 0 {
+			maxUptime += time.Duration(rand.Int63n(int64(time.Duration(cfg.UptimeJitterHours) * time.Hour)))
+		}
+		p.rules = append(p.rules, uptimeRule{max: maxUptime})
+	}
+	if cfg.AfterMatches > 0 {
+		p.rules = append(p.rules, matchCountRule{limit: cfg.AfterMatches})
+	}
+	if cfg.MemoryGrowthPercent > 0 {
+		p.rules = append(p.rules, memoryGrowthRule{percent: float64(cfg.MemoryGrowthPercent)})
+	}
+	if cfg.DailyTime != "" {
+		if clock, err := time.Parse("15:04", cfg.DailyTime); err == nil {
+			p.rules = append(p.rules, dailyRule{hour: clock.Hour(), minute: clock.Minute()})
+		}
+	}
+
+	return p
+}
+
+// Evaluate returns the first rule that has fired. If none has, it returns the
+// time-based rule expected to fire first, or else the first rule that
+// cannot be predicted (match count, memory growth).
+func (p *RestartPolicy) Evaluate(stats RestartStats) RestartDecision {
+	var next RestartDecision
+	for _, rule := range p.rules {
+		due, at := rule.Evaluate(stats)
+		if due {
+			return RestartDecision{Due: true, Rule: rule.Name(), At: at}
+		}
+		switch {
+		case !at.IsZero() && (next.At.IsZero() || at.Before(next.At)):
+			next = RestartDecision{Rule: rule.Name(), At: at}
+		case at.IsZero() && next.Rule == "":
+			next.Rule = rule.Name()
+		}
+	}
+	return next
+}
+
+// uptimeRule restarts a server after a maximum uptime.
+type uptimeRule struct {
+	max time.Duration
+}
+
+func (r uptimeRule) Name() string {
+	return fmt.Sprintf("max_uptime (%s)", r.max.Round(time.Minute))
+}
+
+func (r uptimeRule) Evaluate(stats RestartStats) (bool, time.Time) {
+	at := stats.StartedAt.Add(r.max)
+	return !stats.Now.Before(at), at
+}
+
+// matchCountRule restarts a server after a number of completed matches.
+type matchCountRule struct {
+	limit int
+}
+
+func (r matchCountRule) Name() string {
+	return fmt.Sprintf("after_matches (%d)", r.limit)
+}
+
+func (r matchCountRule) Evaluate(stats RestartStats) (bool, time.Time) {
+	return stats.Matches >= r.limit, time.Time{}
+}
+
+// memoryGrowthRule restarts a server whose RSS has grown by a percentage
+// over its baseline, to contain slow memory leaks.
+type memoryGrowthRule struct {
+	percent float64
+}
+
+func (r memoryGrowthRule) Name() string {
+	return fmt.Sprintf("memory_growth (%.0f%%)", r.percent)
+}
+
+func (r memoryGrowthRule) Evaluate(stats RestartStats) (bool, time.Time) {
+	if stats.BaselineMB <= 0 || stats.MemoryMB <= 0 {
+		return false, time.Time{}
+	}
+	return stats.MemoryMB >= stats.BaselineMB*(1+r.percent/100), time.Time{}
+}
+
+// dailyRule restarts a server once a day at a fixed local time.
+type dailyRule struct {
+	hour, minute int
+}
+
+func (r dailyRule) Name() string {
+	return fmt.Sprintf("daily (%02d:%02d)", r.hour, r.minute)
+}
+
+func (r dailyRule) Evaluate(stats RestartStats) (bool, time.Time) {
+	started := stats.StartedAt.Local()
+	at := time.Date(started.Year(), started.Month(), started.Day(), r.hour, r.minute, 0, 0, started.Location())
+	if !at.After(started) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return !stats.Now.Before(at), at
+}
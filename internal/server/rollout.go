@@ -0,0 +1,667 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+const rolloutFileName = "energizer_rollout.json"
+
+// Rollout states.
+const (
+	RolloutIdle     = "idle"     // No rollout, every server runs the current install
+	RolloutCanary   = "canary"   // Moving and observing the canary share
+	RolloutRolling  = "rolling"  // Moving the rest of the fleet
+	RolloutPaused   = "paused"   // Stopped moving servers, by request or on a regression
+	RolloutComplete = "complete" // Every server runs the candidate, waiting for promote
+)
+
+const (
+	// maxRolloutEvents is the number of rollout events kept for the API.
+	maxRolloutEvents = 100
+
+	// rolloutReadyTimeout is how long a moved server may take to report READY
+	// on the candidate install before the rollout pauses.
+	rolloutReadyTimeout = 3 * time.Minute
+
+	// Lag is only compared once the candidate has this much server time and
+	// this many long frames, so a single spike does not pause the rollout.
+	rolloutMinLagMinutes = 10
+	rolloutMinLongFrames = 5
+)
+
+// Rollout moves the fleet from the current HoN install to a candidate install
+// (hon_candidate_install_directory) one idle server at a time: a canary share
+// first, then, after the canary period, the rest. Crash and lag rates of both
+// installs are compared on every check, and the rollout pauses if the
+// candidate does worse. Promote makes the candidate the current install;
+// Rollback moves every server back.
+type Rollout struct {
+	mu      sync.Mutex
+	cfg     *config.Config
+	manager *Manager
+	bus     *events.EventBus
+	ctx     context.Context // Set by Start, used to relaunch the CowMaster
+
+	state       string
+	pausedFrom  string // State to resume to
+	pauseReason string
+	startedAt   time.Time
+	canaryReady time.Time // When the canary share was first reached
+	moving      *Instance // Server moved last, waiting for READY
+	movingSince time.Time
+	lastSample  time.Time
+	current     TrackStats
+	candidate   TrackStats
+	history     []RolloutEvent
+}
+
+// TrackStats aggregates the health of the servers running one install since
+// the rollout started (or was resumed).
+type TrackStats struct {
+	Servers       int     `json:"servers"`
+	ServerMinutes float64 `json:"server_minutes"` // Time spent READY or OCCUPIED, summed over servers
+	Crashes       int     `json:"crashes"`
+	LongFrames    int     `json:"long_frames"`
+	CrashesPerHr  float64 `json:"crashes_per_hour"` // Per server-hour
+	LagPerHr      float64 `json:"long_frames_per_hour"`
+}
+
+// RolloutEvent records a rollout step.
+type RolloutEvent struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"` // "start", "move", "canary_passed", "complete", "pause", "resume", "promote", "rollback"
+	Port    uint16    `json:"port,omitempty"`
+	Message string    `json:"message"`
+}
+
+// RolloutStatus is a JSON-serializable view of the rollout.
+type RolloutStatus struct {
+	State            string         `json:"state"`
+	PauseReason      string         `json:"pause_reason,omitempty"`
+	CurrentVersion   string         `json:"current_version"`
+	CandidateVersion string         `json:"candidate_version"`
+	CandidateDir     string         `json:"candidate_install_directory"`
+	StartedAt        time.Time      `json:"started_at"`
+	CanaryTarget     int            `json:"canary_target"`
+	CandidatePorts   []uint16       `json:"candidate_ports"`
+	Current          TrackStats     `json:"current"`
+	Candidate        TrackStats     `json:"candidate"`
+	Events           []RolloutEvent `json:"events"`
+}
+
+// rolloutFile is the rollout state persisted across Energizer restarts.
+type rolloutFile struct {
+	State          string    `json:"state"`
+	PausedFrom     string    `json:"paused_from,omitempty"`
+	PauseReason    string    `json:"pause_reason,omitempty"`
+	StartedAt      time.Time `json:"started_at"`
+	CandidatePorts []uint16  `json:"candidate_ports"`
+}
+
+// NewRollout creates the rollout controller and restores a rollout that was
+// in progress when Energizer last stopped.
+func NewRollout(cfg *config.Config, eventBus *events.EventBus, manager *Manager) *Rollout {
+	r := &Rollout{
+		cfg:     cfg,
+		manager: manager,
+		bus:     eventBus,
+		ctx:     context.Background(),
+		state:   RolloutIdle,
+	}
+	r.load()
+
+	eventBus.Subscribe(events.EventServerCrashed, "rollout.serverCrashed", r.onServerCrashed)
+	eventBus.Subscribe(events.EventLongFrame, "rollout.longFrame", r.onLongFrame)
+	return r
+}
+
+// Start runs the rollout loop until the context is cancelled.
+func (r *Rollout) Start(ctx context.Context) {
+	interval := time.Duration(r.cfg.GetApplicationData().Rollout.CheckIntervalSec) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.tick(time.Now())
+		}
+	}
+}
+
+// Begin starts moving servers to the candidate install.
+func (r *Rollout) Begin() error {
+	honData := r.cfg.GetHoNData()
+	if honData.CandidateInstallDirectory == "" {
+		return fmt.Errorf("no candidate install configured (hon_candidate_install_directory)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state != RolloutIdle {
+		return fmt.Errorf("rollout already %s", r.state)
+	}
+	now := time.Now()
+	r.state = RolloutCanary
+	r.startedAt = now
+	r.canaryReady = time.Time{}
+	r.moving = nil
+	r.resetStatsLocked(now)
+	r.recordLocked("start", 0, fmt.Sprintf("rolling out %s from %s",
+		versionName(honData.CandidateVersion), honData.CandidateInstallDirectory))
+	r.saveLocked()
+	return nil
+}
+
+// Pause stops moving servers. Servers already on the candidate stay there.
+func (r *Rollout) Pause(reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state != RolloutCanary && r.state != RolloutRolling {
+		return fmt.Errorf("no rollout in progress")
+	}
+	r.pauseLocked(reason)
+	return nil
+}
+
+// Resume continues a paused rollout. The crash and lag counters start over,
+// so the regression that paused it does not pause it again right away.
+func (r *Rollout) Resume() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state != RolloutPaused {
+		return fmt.Errorf("rollout is not paused")
+	}
+	r.state = r.pausedFrom
+	r.pausedFrom = ""
+	r.pauseReason = ""
+	r.moving = nil
+	r.resetStatsLocked(time.Now())
+	r.recordLocked("resume", 0, "rollout resumed")
+	r.saveLocked()
+	return nil
+}
+
+// Promote makes the candidate install the current one: hon_install_directory
+// and svr_version take the candidate's values and the candidate settings are
+// cleared. Servers already on the candidate keep running; the others restart
+// once idle.
+func (r *Rollout) Promote() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state == RolloutIdle {
+		return fmt.Errorf("no rollout in progress")
+	}
+
+	m := r.manager
+	m.reloadMu.Lock()
+	honData := m.cfg.GetHoNData()
+	if honData.CandidateInstallDirectory == "" {
+		m.reloadMu.Unlock()
+		return fmt.Errorf("no candidate install configured (hon_candidate_install_directory)")
+	}
+	oldVersion := honData.ServerVersion
+	honData.InstallDirectory = honData.CandidateInstallDirectory
+	honData.ServerVersion = honData.CandidateVersion
+	honData.CandidateInstallDirectory = ""
+	honData.CandidateVersion = ""
+	m.cfg.SetHoNData(honData)
+	if err := m.cfg.Save(); err != nil {
+		log.Warn().Err(err).Msg("failed to save config after promoting rollout")
+	}
+	// The fleet is moved here rather than by ReconcileConfig, which would
+	// restart the servers already running the new install
+	m.appliedHoN = honData
+	m.versionAppliedLocked(honData.ServerVersion)
+	m.reloadMu.Unlock()
+
+	var old []*Instance
+	for _, inst := range m.GetAllInstances() {
+		if inst.OnCandidate() {
+			inst.setCandidate(false)
+		} else {
+			old = append(old, inst)
+		}
+	}
+	restarting, stale := m.restartWhenIdle(old)
+
+	if m.cowMaster != nil {
+		go func(ctx context.Context) {
+			if err := m.cowMaster.Relaunch(ctx); err != nil {
+				log.Error().Err(err).Msg("failed to relaunch CowMaster on the promoted install")
+			}
+		}(r.ctx)
+	}
+
+	r.recordLocked("promote", 0, fmt.Sprintf("%s promoted over %s, %d server(s) restarting now, %d after their match",
+		versionName(honData.ServerVersion), versionName(oldVersion), len(restarting), len(stale)))
+	r.finishLocked()
+	r.emitConfigChanged()
+	return nil
+}
+
+// Rollback moves every server back to the current install: idle servers
+// restart now, busy ones once their match ends.
+func (r *Rollout) Rollback() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state == RolloutIdle {
+		return fmt.Errorf("no rollout in progress")
+	}
+
+	var moved []*Instance
+	for _, inst := range r.manager.GetAllInstances() {
+		if inst.OnCandidate() {
+			inst.setCandidate(false)
+			moved = append(moved, inst)
+		}
+	}
+	restarting, stale := r.manager.restartWhenIdle(moved)
+
+	r.recordLocked("rollback", 0, fmt.Sprintf("rolled back, %d server(s) restarting now, %d after their match",
+		len(restarting), len(stale)))
+	r.finishLocked()
+	return nil
+}
+
+// Status returns the rollout state, per-install health, and event log.
+func (r *Rollout) Status() RolloutStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sampleLocked(time.Now())
+	honData := r.cfg.GetHoNData()
+
+	status := RolloutStatus{
+		State:            r.state,
+		PauseReason:      r.pauseReason,
+		CurrentVersion:   honData.ServerVersion,
+		CandidateVersion: honData.CandidateVersion,
+		CandidateDir:     honData.CandidateInstallDirectory,
+		StartedAt:        r.startedAt,
+		CandidatePorts:   []uint16{},
+		Current:          r.current.withRates(),
+		Candidate:        r.candidate.withRates(),
+		Events:           make([]RolloutEvent, len(r.history)),
+	}
+	copy(status.Events, r.history)
+
+	servers := r.eligibleServers()
+	status.CanaryTarget = r.canaryTarget(len(servers))
+	for _, inst := range servers {
+		if inst.OnCandidate() {
+			status.CandidatePorts = append(status.CandidatePorts, inst.Port())
+		}
+	}
+	return status
+}
+
+// tick samples server time, checks the candidate's health, and moves the
+// next idle server once the previous one is back.
+func (r *Rollout) tick(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sampleLocked(now)
+	if r.state != RolloutCanary && r.state != RolloutRolling {
+		return
+	}
+
+	if reason := r.regressionLocked(); reason != "" {
+		r.pauseLocked(reason)
+		r.notifyPause(reason)
+		return
+	}
+
+	// One server at a time: wait for the last one to come back
+	if r.moving != nil {
+		status := r.moving.State().GetStatus()
+		switch {
+		case status == events.GameStatusReady || status == events.GameStatusOccupied:
+			r.moving = nil
+		case now.Sub(r.movingSince) > rolloutReadyTimeout:
+			reason := fmt.Sprintf("server %d did not become ready on the candidate install", r.moving.Port())
+			r.pauseLocked(reason)
+			r.notifyPause(reason)
+			return
+		default:
+			return
+		}
+	}
+
+	servers := r.eligibleServers()
+	moved := 0
+	for _, inst := range servers {
+		if inst.OnCandidate() {
+			moved++
+		}
+	}
+
+	if r.state == RolloutCanary {
+		target := r.canaryTarget(len(servers))
+		if moved < target {
+			r.moveNextLocked(servers, now)
+			return
+		}
+		if r.canaryReady.IsZero() {
+			r.canaryReady = now
+		}
+		canaryFor := time.Duration(r.cfg.GetApplicationData().Rollout.CanaryMinutes) * time.Minute
+		if now.Sub(r.canaryReady) < canaryFor {
+			return
+		}
+		r.state = RolloutRolling
+		r.recordLocked("canary_passed", 0, fmt.Sprintf("%d canary server(s) healthy for %s", moved, canaryFor))
+		r.saveLocked()
+	}
+
+	if moved >= len(servers) {
+		r.state = RolloutComplete
+		r.recordLocked("complete", 0, "every server runs the candidate install, promote to finish")
+		r.saveLocked()
+		return
+	}
+	r.moveNextLocked(servers, now)
+}
+
+// moveNextLocked moves the idle server on the current install with the lowest
+// port to the candidate. A stopped server is moved without a restart.
+func (r *Rollout) moveNextLocked(servers []*Instance, now time.Time) {
+	for _, inst := range servers {
+		if inst.OnCandidate() || inst.IsDraining() {
+			continue
+		}
+
+		if !inst.IsRunning() {
+			inst.setCandidate(true)
+			r.recordLocked("move", inst.Port(), "stopped server will start on the candidate install")
+			r.saveLocked()
+			return
+		}
+		if isBusy(inst) {
+			continue
+		}
+
+		inst.setCandidate(true)
+		inst.Drain(DrainRestart)
+		r.moving = inst
+		r.movingSince = now
+		r.recordLocked("move", inst.Port(), "restarting on the candidate install")
+		r.saveLocked()
+		return
+	}
+}
+
+// eligibleServers returns the enabled, non-sleeping servers sorted by port.
+func (r *Rollout) eligibleServers() []*Instance {
+	var servers []*Instance
+	for _, inst := range r.manager.GetAllInstances() {
+		if inst.IsEnabled() && !inst.IsSleeping() {
+			servers = append(servers, inst)
+		}
+	}
+	sortByPort(servers)
+	return servers
+}
+
+// canaryTarget returns how many of total servers form the canary (at least one).
+func (r *Rollout) canaryTarget(total int) int {
+	target := (total*r.cfg.GetApplicationData().Rollout.CanaryPercent + 99) / 100
+	if target < 1 && total > 0 {
+		target = 1
+	}
+	return target
+}
+
+// regressionLocked compares the candidate's crash and lag rates with the
+// current install's and describes the regression, if any.
+func (r *Rollout) regressionLocked() string {
+	tolerance := 1 + float64(r.cfg.GetApplicationData().Rollout.TolerancePercent)/100
+	cur, cand := r.current.withRates(), r.candidate.withRates()
+
+	// Crashes before ever becoming ready count as a regression too
+	if cand.Crashes > 0 && (cand.ServerMinutes == 0 || cand.CrashesPerHr > cur.CrashesPerHr*tolerance) {
+		return fmt.Sprintf("candidate crash rate %.2f/h exceeds current %.2f/h", cand.CrashesPerHr, cur.CrashesPerHr)
+	}
+	if cand.ServerMinutes >= rolloutMinLagMinutes && cand.LongFrames >= rolloutMinLongFrames &&
+		cand.LagPerHr > cur.LagPerHr*tolerance {
+		return fmt.Sprintf("candidate lag rate %.1f/h exceeds current %.1f/h", cand.LagPerHr, cur.LagPerHr)
+	}
+	return ""
+}
+
+// sampleLocked adds the time since the last sample to each install's server
+// time, counting servers that are READY or OCCUPIED.
+func (r *Rollout) sampleLocked(now time.Time) {
+	elapsed := now.Sub(r.lastSample).Minutes()
+	r.lastSample = now
+	if r.state == RolloutIdle || elapsed <= 0 {
+		return
+	}
+
+	r.current.Servers, r.candidate.Servers = 0, 0
+	for _, inst := range r.manager.GetAllInstances() {
+		status := inst.State().GetStatus()
+		if status != events.GameStatusReady && status != events.GameStatusOccupied {
+			continue
+		}
+		track := r.trackLocked(inst)
+		track.Servers++
+		track.ServerMinutes += elapsed
+	}
+}
+
+func (r *Rollout) resetStatsLocked(now time.Time) {
+	r.current = TrackStats{}
+	r.candidate = TrackStats{}
+	r.lastSample = now
+}
+
+// trackLocked returns the stats of the install inst runs.
+func (r *Rollout) trackLocked(inst *Instance) *TrackStats {
+	if inst.OnCandidate() {
+		return &r.candidate
+	}
+	return &r.current
+}
+
+func (r *Rollout) pauseLocked(reason string) {
+	r.pausedFrom = r.state
+	r.state = RolloutPaused
+	r.pauseReason = reason
+	r.recordLocked("pause", 0, reason)
+	r.saveLocked()
+}
+
+// finishLocked returns to idle after a promote or rollback.
+func (r *Rollout) finishLocked() {
+	r.state = RolloutIdle
+	r.pausedFrom = ""
+	r.pauseReason = ""
+	r.moving = nil
+	r.canaryReady = time.Time{}
+	r.saveLocked()
+}
+
+func (r *Rollout) onServerCrashed(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerCrashedPayload)
+	if !ok {
+		return nil
+	}
+	r.count(payload.Port, func(t *TrackStats) { t.Crashes++ })
+	return nil
+}
+
+func (r *Rollout) onLongFrame(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.LongFramePayload)
+	if !ok {
+		return nil
+	}
+	r.count(payload.Port, func(t *TrackStats) { t.LongFrames++ })
+	return nil
+}
+
+// count applies fn to the stats of the install the server on port runs,
+// while a rollout is in progress.
+func (r *Rollout) count(port uint16, fn func(*TrackStats)) {
+	inst, ok := r.manager.GetInstance(port)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == RolloutIdle {
+		return
+	}
+	fn(r.trackLocked(inst))
+}
+
+// recordLocked appends an event to the bounded rollout log.
+func (r *Rollout) recordLocked(action string, port uint16, message string) {
+	log.Info().
+		Str("action", action).
+		Uint16("port", port).
+		Str("state", r.state).
+		Msg("rollout: " + message)
+
+	r.history = append(r.history, RolloutEvent{
+		Time:    time.Now(),
+		Action:  action,
+		Port:    port,
+		Message: message,
+	})
+	if len(r.history) > maxRolloutEvents {
+		r.history = r.history[len(r.history)-maxRolloutEvents:]
+	}
+}
+
+// notifyPause tells the admin that the rollout paused itself.
+func (r *Rollout) notifyPause(reason string) {
+	r.bus.Emit(context.Background(), events.Event{
+		Type:   events.EventNotifyDiscordAdmin,
+		Source: "rollout",
+		Payload: events.NotifyDiscordPayload{
+			Title:   "Rollout Paused",
+			Message: fmt.Sprintf("The rollout of %s was paused: %s", versionName(r.cfg.GetHoNData().CandidateVersion), reason),
+			Level:   "warning",
+		},
+	})
+}
+
+// emitConfigChanged lets other subsystems pick up the promoted install.
+func (r *Rollout) emitConfigChanged() {
+	r.bus.Emit(context.Background(), events.Event{
+		Type:    events.EventConfigChanged,
+		Source:  "rollout",
+		Payload: events.ConfigChangedPayload{Section: "hon_data"},
+	})
+}
+
+// saveLocked persists the rollout state and the servers on the candidate.
+func (r *Rollout) saveLocked() {
+	path := filepath.Join("config", rolloutFileName)
+	if r.state == RolloutIdle {
+		os.Remove(path)
+		return
+	}
+
+	state := rolloutFile{
+		State:       r.state,
+		PausedFrom:  r.pausedFrom,
+		PauseReason: r.pauseReason,
+		StartedAt:   r.startedAt,
+	}
+	for _, inst := range r.manager.GetAllInstances() {
+		if inst.OnCandidate() {
+			state.CandidatePorts = append(state.CandidatePorts, inst.Port())
+		}
+	}
+	sort.Slice(state.CandidatePorts, func(i, j int) bool { return state.CandidatePorts[i] < state.CandidatePorts[j] })
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to save rollout state")
+	}
+}
+
+// load restores the state saved by saveLocked. A moving server is not
+// restored; the next check picks up where the rollout left off.
+func (r *Rollout) load() {
+	data, err := os.ReadFile(filepath.Join("config", rolloutFileName))
+	if err != nil {
+		return
+	}
+	var state rolloutFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Warn().Err(err).Msg("ignoring unreadable rollout state")
+		return
+	}
+	if r.cfg.GetHoNData().CandidateInstallDirectory == "" {
+		log.Warn().Msg("rollout in progress but no candidate install configured, ignoring it")
+		return
+	}
+
+	for _, port := range state.CandidatePorts {
+		if inst, ok := r.manager.GetInstance(port); ok {
+			inst.setCandidate(true)
+		}
+	}
+	r.state = state.State
+	r.pausedFrom = state.PausedFrom
+	r.pauseReason = state.PauseReason
+	r.startedAt = state.StartedAt
+	r.resetStatsLocked(time.Now())
+
+	log.Info().
+		Str("state", r.state).
+		Int("candidates", len(state.CandidatePorts)).
+		Msg("restored rollout in progress")
+}
+
+// withRates returns a copy of t with the per-hour rates filled in.
+func (t TrackStats) withRates() TrackStats {
+	if hours := t.ServerMinutes / 60; hours > 0 {
+		t.CrashesPerHr = float64(t.Crashes) / hours
+		t.LagPerHr = float64(t.LongFrames) / hours
+	}
+	return t
+}
+
+// versionName returns version, or "auto" when it is empty.
+func versionName(version string) string {
+	if version == "" {
+		return "auto"
+	}
+	return version
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/energizer-project/energizer/internal/config"
+)
+
+// SandboxInitArg is the first argument of an Energizer process re-executed as
+// the namespace helper of a sandboxed game server (see RunSandboxInit).
+const SandboxInitArg = "__sandbox-init"
+
+// SandboxOptions isolates a game server process (Linux only). The user and
+// group are resolved when the process starts.
+type SandboxOptions struct {
+	User        string   // Name or uid, empty to keep Energizer's user
+	Group       string   // Name or gid, empty for the user's primary group
+	Home        string   // Per-instance home directory, empty to share Energizer's
+	Namespaces  bool     // New mount and PID namespace
+	PrivateDirs []string // Directories replaced by a per-instance copy under Home
+}
+
+// sandboxOptionsFor returns the sandbox of the server on the given port, or
+// nil if no isolation is configured or the platform does not support it.
+func sandboxOptionsFor(cfg config.SandboxConfig, port uint16) *SandboxOptions {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	if cfg.RunAsUser == "" && !cfg.IsolateHome && !cfg.Namespaces {
+		return nil
+	}
+
+	opts := &SandboxOptions{
+		User:        cfg.RunAsUser,
+		Group:       cfg.RunAsGroup,
+		Namespaces:  cfg.Namespaces,
+		PrivateDirs: cfg.PrivateDirs,
+	}
+	if cfg.IsolateHome {
+		home := filepath.Join(cfg.HomeRoot, fmt.Sprintf("server-%d", port))
+		if abs, err := filepath.Abs(home); err == nil {
+			home = abs
+		}
+		opts.Home = home
+	}
+	return opts
+}
@@ -0,0 +1,248 @@
+//go:build linux
+
+package server
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// applySandbox configures cmd to run in the sandbox. Without namespaces the
+// process is started directly with the sandbox credentials. With namespaces,
+// Energizer re-executes itself as a helper in the new namespaces, which sets up
+// the private mounts, drops privileges and then executes the game server in
+// place, so the PID seen by Energizer is the game server's.
+func applySandbox(cmd *exec.Cmd, opts *SandboxOptions) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+
+	cred, err := resolveCredential(opts.User, opts.Group)
+	if err != nil {
+		return err
+	}
+
+	if opts.Home != "" {
+		// The home root must stay traversable for the sandbox user
+		if err := os.MkdirAll(filepath.Dir(opts.Home), 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(opts.Home), err)
+		}
+		for _, dir := range []string{"", ".config", ".cache", ".local", ".local/share", ".local/state"} {
+			if err := mkdirOwned(opts.Home, dir, cred); err != nil {
+				return err
+			}
+		}
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		env = setEnv(env, "HOME", opts.Home)
+		env = setEnv(env, "XDG_CONFIG_HOME", filepath.Join(opts.Home, ".config"))
+		env = setEnv(env, "XDG_CACHE_HOME", filepath.Join(opts.Home, ".cache"))
+		env = setEnv(env, "XDG_DATA_HOME", filepath.Join(opts.Home, ".local", "share"))
+		env = setEnv(env, "XDG_STATE_HOME", filepath.Join(opts.Home, ".local", "state"))
+		cmd.Env = env
+	}
+
+	if !opts.Namespaces {
+		cmd.SysProcAttr.Credential = cred
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate energizer executable: %w", err)
+	}
+
+	args := []string{exe, SandboxInitArg}
+	if cred != nil {
+		args = append(args,
+			"-uid", strconv.FormatUint(uint64(cred.Uid), 10),
+			"-gid", strconv.FormatUint(uint64(cred.Gid), 10))
+	}
+	if opts.Home != "" {
+		args = append(args, "-home", opts.Home)
+	}
+	for _, dir := range opts.PrivateDirs {
+		if opts.Home == "" {
+			break
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+		rel, _ := filepath.Rel(opts.Home, privateDirSource(opts.Home, dir))
+		if err := mkdirOwned(opts.Home, rel, cred); err != nil {
+			return err
+		}
+		args = append(args, "-private", dir)
+	}
+	args = append(args, "--", cmd.Path)
+	args = append(args, cmd.Args[1:]...)
+
+	cmd.Path = exe
+	cmd.Args = args
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNS | syscall.CLONE_NEWPID
+	return nil
+}
+
+// RunSandboxInit is the namespace helper started by applySandbox. It runs as
+// root inside the game server's new mount and PID namespaces, bind mounts the
+// private directories, mounts /proc for the new PID namespace, switches to the
+// sandbox user and executes the game server. It only returns by exiting.
+//
+// The game server becomes PID 1 of its namespace, so it only receives the
+// interrupt sent by Stop() if it handles it; otherwise it is killed after the
+// stop timeout.
+func RunSandboxInit(args []string) {
+	fs := flag.NewFlagSet(SandboxInitArg, flag.ContinueOnError)
+	uid := fs.Int("uid", -1, "user to run the game server as")
+	gid := fs.Int("gid", -1, "group to run the game server as")
+	home := fs.String("home", "", "per-instance home directory")
+	var private stringsFlag
+	fs.Var(&private, "private", "directory to replace with a per-instance copy (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		sandboxFail(err)
+	}
+	argv := fs.Args()
+	if len(argv) == 0 {
+		sandboxFail(fmt.Errorf("no executable given"))
+	}
+
+	// Keep the mounts below out of the host's mount namespace
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		sandboxFail(fmt.Errorf("make / private: %w", err))
+	}
+	for _, dir := range private {
+		if err := syscall.Mount(privateDirSource(*home, dir), dir, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			sandboxFail(fmt.Errorf("bind mount %s: %w", dir, err))
+		}
+	}
+	if err := syscall.Mount("proc", "/proc", "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		sandboxFail(fmt.Errorf("mount /proc: %w", err))
+	}
+
+	if *gid >= 0 {
+		if err := syscall.Setgroups(nil); err != nil {
+			sandboxFail(fmt.Errorf("setgroups: %w", err))
+		}
+		if err := syscall.Setgid(*gid); err != nil {
+			sandboxFail(fmt.Errorf("setgid: %w", err))
+		}
+	}
+	if *uid >= 0 {
+		if err := syscall.Setuid(*uid); err != nil {
+			sandboxFail(fmt.Errorf("setuid: %w", err))
+		}
+	}
+
+	err := syscall.Exec(argv[0], argv, os.Environ())
+	sandboxFail(fmt.Errorf("exec %s: %w", argv[0], err))
+}
+
+// sandboxFail reports a helper error and exits with the shell's "cannot
+// execute" status, which the crash policy treats like any failed start.
+func sandboxFail(err error) {
+	fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	os.Exit(127)
+}
+
+// privateDirSource returns where the per-instance copy of dir is kept.
+func privateDirSource(home, dir string) string {
+	return filepath.Join(home, "private", strings.TrimPrefix(filepath.Clean(dir), "/"))
+}
+
+// resolveCredential resolves the sandbox user and group to a credential, or
+// nil if no user is configured.
+func resolveCredential(userSpec, groupSpec string) (*syscall.Credential, error) {
+	if userSpec == "" {
+		return nil, nil
+	}
+
+	u, err := user.Lookup(userSpec)
+	if err != nil {
+		u, err = user.LookupId(userSpec)
+	}
+
+	var uid, gid uint64
+	switch {
+	case err == nil:
+		uid, _ = strconv.ParseUint(u.Uid, 10, 32)
+		gid, _ = strconv.ParseUint(u.Gid, 10, 32)
+	case groupSpec != "":
+		// A bare uid without a passwd entry is fine if the group is given
+		if uid, err = strconv.ParseUint(userSpec, 10, 32); err != nil {
+			return nil, fmt.Errorf("unknown user %q", userSpec)
+		}
+	default:
+		return nil, fmt.Errorf("unknown user %q", userSpec)
+	}
+
+	if groupSpec != "" {
+		g, err := user.LookupGroup(groupSpec)
+		if err != nil {
+			g, err = user.LookupGroupId(groupSpec)
+		}
+		if err == nil {
+			gid, _ = strconv.ParseUint(g.Gid, 10, 32)
+		} else if gid, err = strconv.ParseUint(groupSpec, 10, 32); err != nil {
+			return nil, fmt.Errorf("unknown group %q", groupSpec)
+		}
+	}
+
+	if uid == 0 {
+		return nil, fmt.Errorf("refusing to run game servers as root via run_as_user")
+	}
+
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
+
+// mkdirOwned creates base/rel and every missing directory between them, owned
+// by the sandbox user if one is set.
+func mkdirOwned(base, rel string, cred *syscall.Credential) error {
+	dir := base
+	parts := []string{""}
+	if rel != "" && rel != "." {
+		parts = append(parts, strings.Split(filepath.Clean(rel), string(filepath.Separator))...)
+	}
+	for _, part := range parts {
+		dir = filepath.Join(dir, part)
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+		if cred != nil {
+			if err := os.Chown(dir, int(cred.Uid), int(cred.Gid)); err != nil {
+				return fmt.Errorf("failed to chown %s: %w", dir, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setEnv sets key in an environment list, replacing any existing entry.
+func setEnv(env []string, key, value string) []string {
+	prefix := key + "="
+	out := env[:0:0]
+	for _, e := range env {
+		if !strings.HasPrefix(e, prefix) {
+			out = append(out, e)
+		}
+	}
+	return append(out, prefix+value)
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package server
+
+import (
+	"fmt"
+	"os"
+)
+
+// RunSandboxInit is not supported on Windows.
+func RunSandboxInit(args []string) {
+	fmt.Fprintln(os.Stderr, "sandbox: namespaces not supported on Windows")
+	os.Exit(127)
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/db"
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+const (
+	// PingSpikeDelta is how far (ms) above a player's session average a ping
+	// must jump to count as a spike. Consecutive high samples count once.
+	PingSpikeDelta = 100
+	// pingSpikeMinSamples is the number of samples needed before spikes are counted.
+	pingSpikeMinSamples = 5
+	// maxSessionPingSamples bounds the samples kept per session for the p95.
+	maxSessionPingSamples = 20000
+	// sessionFlushInterval is how often the statistics of open sessions are written.
+	sessionFlushInterval = time.Minute
+)
+
+// SessionRecorder records every player connection as a session, together with
+// ping statistics taken from the PlayerPings of each server status packet.
+// Sessions are written when they open, at most once per sessionFlushInterval
+// while open, and when the player leaves or the lobby closes.
+type SessionRecorder struct {
+	mu       sync.Mutex
+	sessions *db.SessionDatabase
+
+	open      map[uint16]map[string]*liveSession // port -> player name -> session
+	portMatch map[uint16]uint32                  // Current match ID per port
+}
+
+// liveSession is an open session with its raw ping samples.
+type liveSession struct {
+	db.PlayerSession
+	samples   []uint16
+	sum       uint64
+	inSpike   bool
+	lastFlush time.Time
+}
+
+// NewSessionRecorder creates a recorder and subscribes it to the player events.
+// Sessions left open by a previous run are closed.
+func NewSessionRecorder(eventBus *events.EventBus, sessions *db.SessionDatabase) *SessionRecorder {
+	sr := &SessionRecorder{
+		sessions:  sessions,
+		open:      make(map[uint16]map[string]*liveSession),
+		portMatch: make(map[uint16]uint32),
+	}
+
+	if n, err := sessions.CloseOpenSessions(time.Now()); err != nil {
+		log.Warn().Err(err).Msg("failed to close stale player sessions")
+	} else if n > 0 {
+		log.Info().Int64("sessions", n).Msg("closed player sessions left open by previous run")
+	}
+
+	eventBus.Subscribe(events.EventPlayerConnection, "session_recorder.playerConnection", sr.onPlayerConnection)
+	eventBus.Subscribe(events.EventServerStatus, "session_recorder.serverStatus", sr.onServerStatus)
+	eventBus.Subscribe(events.EventLobbyCreated, "session_recorder.lobbyCreated", sr.onLobbyCreated)
+	eventBus.Subscribe(events.EventLobbyClosed, "session_recorder.lobbyClosed", sr.onLobbyClosed)
+	eventBus.Subscribe(events.EventServerCrashed, "session_recorder.serverCrashed", sr.onServerCrashed)
+
+	return sr
+}
+
+func (sr *SessionRecorder) onPlayerConnection(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.PlayerConnectionPayload)
+	if !ok {
+		return nil
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	now := time.Now()
+	if !payload.Connected {
+		if s, ok := sr.open[payload.Port][payload.PlayerName]; ok {
+			sr.close(s, now)
+		}
+		return nil
+	}
+
+	// A reconnect without a disconnect closes the previous session
+	if s, ok := sr.open[payload.Port][payload.PlayerName]; ok {
+		sr.close(s, now)
+	}
+	s := sr.begin(payload.Port, payload.PlayerName, now)
+	if s != nil && payload.PlayerID != 0 {
+		s.AccountID = payload.PlayerID
+		sr.flush(s, now)
+	}
+	return nil
+}
+
+func (sr *SessionRecorder) onServerStatus(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerStatusPayload)
+	if !ok {
+		return nil
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	now := time.Now()
+	if payload.MatchID != 0 {
+		sr.portMatch[payload.Port] = payload.MatchID
+	}
+
+	for name, ping := range payload.PlayerPings {
+		s, ok := sr.open[payload.Port][name]
+		if !ok {
+			// Player connected before we were watching (e.g. adopted server)
+			if s = sr.begin(payload.Port, name, now); s == nil {
+				continue
+			}
+		}
+		if s.MatchID == 0 && payload.MatchID != 0 {
+			s.MatchID = payload.MatchID
+		}
+		s.addSample(ping)
+
+		if now.Sub(s.lastFlush) >= sessionFlushInterval {
+			sr.flush(s, now)
+		}
+	}
+	return nil
+}
+
+func (sr *SessionRecorder) onLobbyCreated(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.LobbyCreatedPayload)
+	if !ok {
+		return nil
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sr.portMatch[payload.Port] = payload.MatchID
+	return nil
+}
+
+func (sr *SessionRecorder) onLobbyClosed(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerAnnouncePayload)
+	if !ok {
+		return nil
+	}
+
+	sr.closePort(payload.Port)
+	return nil
+}
+
+func (sr *SessionRecorder) onServerCrashed(ctx context.Context, event events.Event) error {
+	payload, ok := event.Payload.(events.ServerCrashedPayload)
+	if !ok {
+		return nil
+	}
+
+	sr.closePort(payload.Port)
+	return nil
+}
+
+// closePort ends every session on a server and forgets its match.
+func (sr *SessionRecorder) closePort(port uint16) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	now := time.Now()
+	for _, s := range sr.open[port] {
+		sr.close(s, now)
+	}
+	delete(sr.portMatch, port)
+}
+
+// begin opens and stores a new session. Caller must hold sr.mu.
+func (sr *SessionRecorder) begin(port uint16, name string, now time.Time) *liveSession {
+	s := &liveSession{
+		PlayerSession: db.PlayerSession{
+			PlayerName: name,
+			Port:       port,
+			MatchID:    sr.portMatch[port],
+			JoinedAt:   now,
+		},
+		lastFlush: now,
+	}
+	if err := sr.sessions.CreateSession(&s.PlayerSession); err != nil {
+		log.Warn().Err(err).Str("player", name).Msg("failed to record player session")
+		return nil
+	}
+
+	if sr.open[port] == nil {
+		sr.open[port] = make(map[string]*liveSession)
+	}
+	sr.open[port][name] = s
+	return s
+}
+
+// close ends a session and writes its final statistics. Caller must hold sr.mu.
+func (sr *SessionRecorder) close(s *liveSession, now time.Time) {
+	s.LeftAt = &now
+	sr.flush(s, now)
+	delete(sr.open[s.Port], s.PlayerName)
+
+	log.Debug().
+		Str("player", s.PlayerName).
+		Uint16("port", s.Port).
+		Int("samples", s.Samples).
+		Float64("ping_avg", s.PingAvg).
+		Uint16("ping_p95", s.PingP95).
+		Int("spikes", s.Spikes).
+		Msg("player session ended")
+}
+
+// flush computes the session's statistics and writes them. Caller must hold sr.mu.
+func (sr *SessionRecorder) flush(s *liveSession, now time.Time) {
+	s.PingP95 = percentile(s.samples, 0.95)
+	s.lastFlush = now
+	if err := sr.sessions.UpdateSession(&s.PlayerSession); err != nil {
+		log.Warn().Err(err).Str("player", s.PlayerName).Msg("failed to update player session")
+	}
+}
+
+// addSample folds one ping measurement into the session's statistics.
+func (s *liveSession) addSample(ping uint16) {
+	if ping == 0 {
+		return
+	}
+
+	if s.Samples >= pingSpikeMinSamples {
+		spike := float64(ping) >= s.PingAvg+PingSpikeDelta
+		if spike && !s.inSpike {
+			s.Spikes++
+		}
+		s.inSpike = spike
+	}
+
+	s.Samples++
+	s.sum += uint64(ping)
+	s.PingAvg = float64(s.sum) / float64(s.Samples)
+	if s.PingMin == 0 || ping < s.PingMin {
+		s.PingMin = ping
+	}
+	if ping > s.PingMax {
+		s.PingMax = ping
+	}
+	if len(s.samples) < maxSessionPingSamples {
+		s.samples = append(s.samples, ping)
+	}
+}
+
+// percentile returns the p-th percentile (0..1) of samples using nearest rank.
+func percentile(samples []uint16, p float64) uint16 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	sorted := make([]uint16, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/energizer-project/energizer/internal/config"
+	"github.com/energizer-project/energizer/internal/simulate"
+)
+
+// Simulation mode is process-wide: it is chosen on the command line before
+// any instance is created.
+var (
+	simMu       sync.RWMutex
+	simEnabled  bool
+	simScenario string
+)
+
+// EnableSimulation makes every game server instance created afterwards run
+// Energizer's built-in fake game server (see package simulate) instead of the
+// HoN executable. An empty scenarioPath selects the built-in scenario.
+func EnableSimulation(scenarioPath string) {
+	simMu.Lock()
+	defer simMu.Unlock()
+	simEnabled = true
+	simScenario = scenarioPath
+}
+
+// Simulating reports whether simulation mode is enabled.
+func Simulating() bool {
+	simMu.RLock()
+	defer simMu.RUnlock()
+	return simEnabled
+}
+
+// simulationProcessConfig replaces the executable, arguments, and working
+// directory of procCfg with those of a fake game server if simulation mode is
+// enabled.
+func simulationProcessConfig(procCfg *ProcessConfig, honData config.HoNData) {
+	simMu.RLock()
+	enabled, scenario := simEnabled, simScenario
+	simMu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to locate the Energizer executable for simulation")
+		exe = os.Args[0]
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		workDir = "."
+	}
+
+	procCfg.Executable = exe
+	procCfg.Args = simulate.ServerArgs(procCfg.Port, honData.ManagerPort, scenario)
+	procCfg.WorkDir = workDir
+}
@@ -4,21 +4,150 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/rs/zerolog"
+
 	"github.com/energizer-project/energizer/internal/events"
 )
 
+// timelineSize is the number of transitions kept per server.
+const timelineSize = 256
+
+// Actors of a state transition.
+const (
+	ActorGameServer = "game_server" // Reported by the game server (packets, process exit)
+	ActorEnergizer  = "energizer"   // Decided by Energizer itself
+)
+
+// ErrIllegalTransition is returned when a state change reported by the game
+// server is not allowed from the current state and was rejected.
+var ErrIllegalTransition = errors.New("illegal state transition")
+
+// Cause describes what triggered a state transition.
+type Cause struct {
+	Source string `json:"cause"` // e.g. "packet:0x42", "api:stop_server", "health_check:general_health"
+	Actor  string `json:"actor"` // ActorGameServer, ActorEnergizer, or the API user
+}
+
+// PacketCause is a transition reported by the game server in a packet.
+func PacketCause(packet string) Cause {
+	return Cause{Source: "packet:" + packet, Actor: ActorGameServer}
+}
+
+// APICause is a transition requested through the API by user.
+func APICause(action, user string) Cause {
+	return Cause{Source: "api:" + action, Actor: user}
+}
+
+// HealthCheckCause is a transition made by a health check.
+func HealthCheckCause(check string) Cause {
+	return Cause{Source: "health_check:" + check, Actor: ActorEnergizer}
+}
+
+// energizerCause is a transition Energizer made on its own.
+func energizerCause(source string) Cause {
+	return Cause{Source: source, Actor: ActorEnergizer}
+}
+
+type causeKey struct{}
+
+// WithCause returns a context that attributes the state transitions made by
+// Instance.Start, StopContext, and Restart to cause.
+func WithCause(ctx context.Context, cause Cause) context.Context {
+	return context.WithValue(ctx, causeKey{}, cause)
+}
+
+// causeFrom returns the cause attached to ctx, or fallback.
+func causeFrom(ctx context.Context, fallback Cause) Cause {
+	if cause, ok := ctx.Value(causeKey{}).(Cause); ok {
+		return cause
+	}
+	return fallback
+}
+
+// Transition is one recorded status or phase change.
+type Transition struct {
+	Time  time.Time `json:"time"`
+	Field string    `json:"field"` // "status" or "phase"
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Cause
+	Illegal  bool `json:"illegal,omitempty"`  // Not an allowed transition
+	Rejected bool `json:"rejected,omitempty"` // Illegal and not applied
+}
+
+// statusTransitions lists the statuses each status may change to. Any status
+// may be reset to QUEUED (see Reset), and UNKNOWN may change to anything.
+var statusTransitions = map[events.GameStatus][]events.GameStatus{
+	events.GameStatusQueued:   {events.GameStatusStarting, events.GameStatusStopped, events.GameStatusSleeping},
+	events.GameStatusStarting: {events.GameStatusReady, events.GameStatusOccupied, events.GameStatusStopped},
+	events.GameStatusReady:    {events.GameStatusOccupied, events.GameStatusStopped},
+	events.GameStatusOccupied: {events.GameStatusReady, events.GameStatusStopped},
+	events.GameStatusSleeping: {events.GameStatusStopped},
+	events.GameStatusStopped:  {events.GameStatusStarting, events.GameStatusSleeping},
+}
+
+// statusAllowed reports whether status may change from one value to another.
+func statusAllowed(from, to events.GameStatus) bool {
+	allowed, ok := statusTransitions[from]
+	if !ok {
+		return true
+	}
+	for _, status := range allowed {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// phaseAllowed reports whether the game phase may change from one value to
+// another: phases only move forward through a match, except that a match may
+// end (back to idle) at any point and a new lobby may follow a finished game
+// before idle was observed. A server re-adopted mid-match may jump from idle
+// to any phase.
+func phaseAllowed(from, to events.GamePhase) bool {
+	switch {
+	case to >= from, to == events.GamePhaseIdle:
+		return true
+	case to == events.GamePhaseInLobby:
+		return from == events.GamePhaseGameEnding || from == events.GamePhaseGameEnded
+	default:
+		return false
+	}
+}
+
 // GameState encapsulates the current state of a game server instance.
 // It is thread-safe and tracks both server status and game phase.
+//
+// Every change is checked against the allowed transitions and recorded in a
+// timeline. Status is Energizer's view of the server: an illegal status
+// change reported by the game server (e.g. a lobby on a stopped server) is
+// rejected, while one Energizer made itself is applied and flagged, since it
+// reflects something that already happened. The game phase is reported by the
+// game server, so illegal phase changes are applied and flagged.
 type GameState struct {
-	mu sync.RWMutex
+	mu     sync.RWMutex
+	logger zerolog.Logger
+
+	// Ring buffer of the most recent transitions, kept across restarts
+	timeline     []Transition
+	timelineNext int
+	timelineLen  int
 
 	// Current status and phase
 	Status    events.GameStatus
 	Phase     events.GamePhase
 
+	// Set while the server drains: a server whose match ends stays
+	// OCCUPIED instead of returning to READY
+	holdOccupied bool
+
 	// Match info
 	MatchID     uint32
 	MapName     string
@@ -59,10 +188,13 @@ type SkippedFrame struct {
 	Duration  uint32    `json:"duration_ms"`
 }
 
-// NewGameState creates a new GameState with initial values.
-func NewGameState() *GameState {
+// NewGameState creates a new GameState with initial values. Illegal
+// transitions are logged to logger.
+func NewGameState(logger zerolog.Logger) *GameState {
 	now := time.Now()
 	return &GameState{
+		logger:          logger,
+		timeline:        make([]Transition, timelineSize),
 		Status:          events.GameStatusQueued,
 		Phase:           events.GamePhaseIdle,
 		Players:         make(map[string]PlayerInfo),
@@ -73,14 +205,38 @@ func NewGameState() *GameState {
 	}
 }
 
-// SetStatus updates the server status and records the transition time.
-func (s *GameState) SetStatus(status events.GameStatus) events.GameStatus {
+// SetStatus updates the server status and records the transition. It
+// returns an ErrIllegalTransition error if the change was rejected.
+func (s *GameState) SetStatus(status events.GameStatus, cause Cause) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.setStatusLocked(status, cause, time.Now())
+}
+
+func (s *GameState) setStatusLocked(status events.GameStatus, cause Cause, now time.Time) error {
 	old := s.Status
+	if old == status {
+		return nil
+	}
+
+	t := Transition{
+		Time:    now,
+		Field:   "status",
+		From:    old.String(),
+		To:      status.String(),
+		Cause:   cause,
+		Illegal: !statusAllowed(old, status),
+	}
+	if t.Illegal && cause.Actor == ActorGameServer {
+		t.Rejected = true
+		s.recordLocked(t)
+		return fmt.Errorf("%w: status %s -> %s (%s)", ErrIllegalTransition, old, status, cause.Source)
+	}
+
 	s.Status = status
-	s.StatusChangedAt = time.Now()
-	return old
+	s.StatusChangedAt = now
+	s.recordLocked(t)
+	return nil
 }
 
 // GetStatus returns the current server status.
@@ -90,16 +246,34 @@ func (s *GameState) GetStatus() events.GameStatus {
 	return s.Status
 }
 
-// SetPhase updates the game phase.
-func (s *GameState) SetPhase(phase events.GamePhase) events.GamePhase {
+// SetPhase updates the game phase and records the transition. It returns
+// the previous phase.
+func (s *GameState) SetPhase(phase events.GamePhase, cause Cause) events.GamePhase {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	old := s.Phase
-	s.Phase = phase
-	s.PhaseChangedAt = time.Now()
+	s.setPhaseLocked(phase, cause, time.Now())
 	return old
 }
 
+func (s *GameState) setPhaseLocked(phase events.GamePhase, cause Cause, now time.Time) {
+	old := s.Phase
+	if old == phase {
+		return
+	}
+
+	s.Phase = phase
+	s.PhaseChangedAt = now
+	s.recordLocked(Transition{
+		Time:    now,
+		Field:   "phase",
+		From:    old.String(),
+		To:      phase.String(),
+		Cause:   cause,
+		Illegal: !phaseAllowed(old, phase),
+	})
+}
+
 // GetPhase returns the current game phase.
 func (s *GameState) GetPhase() events.GamePhase {
 	s.mu.RLock()
@@ -109,10 +283,11 @@ func (s *GameState) GetPhase() events.GamePhase {
 
 // UpdateTelemetry updates the server telemetry data from a status packet.
 func (s *GameState) UpdateTelemetry(uptime uint32, cpuUsage float32, playerCount uint8,
-	phase events.GamePhase, matchID uint32, pings map[string]uint16) {
+	phase events.GamePhase, matchID uint32, pings map[string]uint16, cause Cause) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	now := time.Now()
 
 	s.Uptime = uptime
 	s.CPUUsage = cpuUsage
@@ -120,22 +295,24 @@ func (s *GameState) UpdateTelemetry(uptime uint32, cpuUsage float32, playerCount
 	s.MatchID = matchID
 	s.PlayerPings = pings
 
-	// Update phase if changed
-	if s.Phase != phase {
-		s.Phase = phase
-		s.PhaseChangedAt = time.Now()
-	}
+	s.setPhaseLocked(phase, cause, now)
 
 	// Update status based on player count
 	if playerCount > 0 && s.Status == events.GameStatusReady {
-		s.Status = events.GameStatusOccupied
-		s.StatusChangedAt = time.Now()
-	} else if playerCount == 0 && s.Status == events.GameStatusOccupied {
-		s.Status = events.GameStatusReady
-		s.StatusChangedAt = time.Now()
+		s.setStatusLocked(events.GameStatusOccupied, cause, now)
+	} else if playerCount == 0 && s.Status == events.GameStatusOccupied && !s.holdOccupied {
+		s.setStatusLocked(events.GameStatusReady, cause, now)
 	}
 }
 
+// SetHoldOccupied sets whether an OCCUPIED server is kept OCCUPIED when its
+// match ends, so it is not offered for a new one.
+func (s *GameState) SetHoldOccupied(hold bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.holdOccupied = hold
+}
+
 // AddPlayer adds a player to the state.
 func (s *GameState) AddPlayer(name string, id uint32) {
 	s.mu.Lock()
@@ -204,11 +381,18 @@ func (s *GameState) SetMatchInfo(matchID uint32, mapName, mode string) {
 	s.GameMode = mode
 }
 
-// Reset resets the game state to defaults (for server restart).
-func (s *GameState) Reset() {
+// Reset resets the game state to defaults (for server restart). The reset is
+// recorded in the timeline, which is kept.
+func (s *GameState) Reset(cause Cause) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	now := time.Now()
+	if s.Status != events.GameStatusQueued {
+		s.recordLocked(Transition{Time: now, Field: "status", From: s.Status.String(), To: events.GameStatusQueued.String(), Cause: cause})
+	}
+	if s.Phase != events.GamePhaseIdle {
+		s.recordLocked(Transition{Time: now, Field: "phase", From: s.Phase.String(), To: events.GamePhaseIdle.String(), Cause: cause})
+	}
 	s.Status = events.GameStatusQueued
 	s.Phase = events.GamePhaseIdle
 	s.MatchID = 0
@@ -224,6 +408,44 @@ func (s *GameState) Reset() {
 	s.PhaseChangedAt = now
 }
 
+// recordLocked appends t to the timeline and logs it if it was illegal.
+// Caller must hold s.mu.
+func (s *GameState) recordLocked(t Transition) {
+	s.timeline[s.timelineNext] = t
+	s.timelineNext = (s.timelineNext + 1) % len(s.timeline)
+	if s.timelineLen < len(s.timeline) {
+		s.timelineLen++
+	}
+
+	if t.Illegal {
+		s.logger.Warn().
+			Str("field", t.Field).
+			Str("from", t.From).
+			Str("to", t.To).
+			Str("cause", t.Source).
+			Str("actor", t.Actor).
+			Bool("rejected", t.Rejected).
+			Msg("illegal state transition")
+	}
+}
+
+// Timeline returns up to n of the most recent transitions, oldest first.
+// n <= 0 returns all recorded transitions.
+func (s *GameState) Timeline(n int) []Transition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if n <= 0 || n > s.timelineLen {
+		n = s.timelineLen
+	}
+	out := make([]Transition, n)
+	start := (s.timelineNext - n + len(s.timeline)) % len(s.timeline)
+	for idx := range out {
+		out[idx] = s.timeline[(start+idx)%len(s.timeline)]
+	}
+	return out
+}
+
 // Snapshot returns a read-only snapshot of the current state.
 func (s *GameState) Snapshot() GameStateSnapshot {
 	s.mu.RLock()
@@ -0,0 +1,396 @@
+package simulate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/energizer-project/energizer/internal/events"
+	"github.com/energizer-project/energizer/internal/protocol"
+)
+
+// ServerArg is the first argument Energizer is re-executed with to act as a
+// fake game server in simulation mode.
+const ServerArg = "__simulate-server"
+
+// connectTimeout is how long the fake server keeps retrying to reach the
+// manager, which may still be starting its TCP listener.
+const connectTimeout = 30 * time.Second
+
+// ServerArgs returns the arguments that make Energizer run a fake game server
+// on port, reporting to the manager on managerPort.
+func ServerArgs(port uint16, managerPort int, scenarioPath string) []string {
+	args := []string{
+		ServerArg,
+		"-port", fmt.Sprint(port),
+		"-manager-port", fmt.Sprint(managerPort),
+	}
+	if scenarioPath != "" {
+		args = append(args, "-scenario", scenarioPath)
+	}
+	return args
+}
+
+// fakePlayer is a player connected to the fake server.
+type fakePlayer struct {
+	name string
+	id   uint32
+}
+
+// fakeServer plays a scenario against the manager.
+type fakeServer struct {
+	port     uint16
+	scenario *Scenario
+	conn     net.Conn
+	started  time.Time
+	rng      *rand.Rand
+
+	writeMu sync.Mutex // Serializes packets on conn
+
+	mu         sync.Mutex
+	phase      events.GamePhase
+	matchID    uint32
+	matchCount uint32
+	players    []fakePlayer
+	nextPlayer uint32
+}
+
+// RunFakeServer runs a fake game server until the manager tells it to quit,
+// the connection is lost, or the scenario crashes it. It never returns.
+func RunFakeServer(args []string) {
+	fs := flag.NewFlagSet(ServerArg, flag.ContinueOnError)
+	port := fs.Uint("port", 0, "game port to announce")
+	managerPort := fs.Int("manager-port", 1134, "manager TCP port")
+	scenarioPath := fs.String("scenario", "", "scenario file (built-in scenario if empty)")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+
+	sc, err := LoadScenario(*scenarioPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fake server: %v\n", err)
+		os.Exit(1)
+	}
+
+	conn, err := dialManager(*managerPort)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fake server: %v\n", err)
+		os.Exit(1)
+	}
+
+	s := &fakeServer{
+		port:       uint16(*port),
+		scenario:   sc,
+		conn:       conn,
+		started:    time.Now(),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano() + int64(*port))),
+		nextPlayer: uint32(*port) * 1000,
+	}
+	s.logf("simulated game server on port %d running scenario %q", s.port, sc.Name)
+
+	if err := s.send(protocol.BuildServerAnnounce(s.port)); err != nil {
+		s.fail(err)
+	}
+
+	go s.readLoop()
+	go s.statusLoop()
+	s.runScenario()
+
+	s.logf("scenario finished, idling")
+	select {}
+}
+
+// dialManager connects to the manager, retrying until connectTimeout.
+func dialManager(managerPort int) (net.Conn, error) {
+	addr := fmt.Sprintf("127.0.0.1:%d", managerPort)
+	deadline := time.Now().Add(connectTimeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+		if err == nil {
+			return conn, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to connect to manager at %s: %w", addr, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// send writes one length-prefixed packet to the manager.
+func (s *fakeServer) send(data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return protocol.WritePacket(s.conn, data)
+}
+
+// logf prints a console line, which the manager captures like real server output.
+func (s *fakeServer) logf(format string, args ...interface{}) {
+	fmt.Printf("[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+}
+
+// fail exits after losing the manager connection.
+func (s *fakeServer) fail(err error) {
+	fmt.Fprintf(os.Stderr, "fake server: lost connection to manager: %v\n", err)
+	os.Exit(1)
+}
+
+// readLoop handles manager packets: commands, kicks, and chat messages.
+func (s *fakeServer) readLoop() {
+	for {
+		data, err := protocol.ReadPacket(s.conn)
+		if err != nil {
+			s.fail(err)
+		}
+		if len(data) < 1 {
+			continue
+		}
+
+		r := bytes.NewReader(data[1:])
+		switch data[0] {
+		case protocol.PktManagerCommand:
+			command := readNullString(r)
+			s.logf("> %s", command)
+			s.handleCommand(command)
+		case protocol.PktManagerKick:
+			var playerID uint32
+			binary.Read(r, binary.LittleEndian, &playerID)
+			reason := readNullString(r)
+			s.kick(playerID, reason)
+		case protocol.PktManagerMessage:
+			s.logf("[SERVER] %s", readNullString(r))
+		default:
+			s.logf("ignoring manager packet 0x%02X", data[0])
+		}
+	}
+}
+
+// handleCommand reacts to console commands that affect the simulation.
+func (s *fakeServer) handleCommand(command string) {
+	fields := strings.Fields(strings.ToLower(command))
+	if len(fields) == 0 {
+		return
+	}
+	switch fields[0] {
+	case "quit", "exit":
+		s.logf("shutting down")
+		s.send(protocol.BuildServerClosed(s.port))
+		s.conn.Close()
+		os.Exit(0)
+	}
+}
+
+// statusLoop sends a status packet (0x42) every StatusInterval seconds.
+func (s *fakeServer) statusLoop() {
+	ticker := time.NewTicker(time.Duration(s.scenario.StatusInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := s.send(s.statusPacket()); err != nil {
+			s.fail(err)
+		}
+		<-ticker.C
+	}
+}
+
+func (s *fakeServer) statusPacket() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Jitter keeps pings moving so the manager does not take players for idle
+	pings := make([]protocol.PlayerPing, len(s.players))
+	for idx, p := range s.players {
+		pings[idx] = protocol.PlayerPing{
+			Name: p.name,
+			Ping: s.scenario.BasePing + uint16(s.rng.Intn(20)),
+		}
+	}
+
+	cpu := s.scenario.CPUPercent
+	if s.phase >= events.GamePhaseLoading {
+		cpu *= 2
+	}
+	cpu += s.rng.Float32() * 5
+
+	return protocol.BuildServerStatus(
+		s.port,
+		uint32(time.Since(s.started).Seconds()),
+		cpu,
+		uint8(s.phase),
+		s.matchID,
+		pings,
+	)
+}
+
+// runScenario plays the scenario steps, once or forever.
+func (s *fakeServer) runScenario() {
+	for {
+		for _, step := range s.scenario.Steps {
+			time.Sleep(time.Duration(step.Wait * float64(time.Second)))
+			if err := s.runStep(step); err != nil {
+				s.fail(err)
+			}
+		}
+		if !s.scenario.Loop {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) runStep(step Step) error {
+	switch step.Action {
+	case ActionLobby:
+		return s.createLobby(step.Map, step.Mode)
+	case ActionPhase:
+		phase, _ := parsePhase(step.Phase)
+		s.mu.Lock()
+		s.phase = phase
+		s.mu.Unlock()
+		s.logf("phase: %s", phase)
+		return s.send(s.statusPacket())
+	case ActionConnect:
+		if step.Player != "" {
+			return s.connect(step.Player)
+		}
+		for n := 0; n < step.Count; n++ {
+			if err := s.connect(""); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ActionDisconnect:
+		return s.disconnect(step.Player)
+	case ActionLongFrame:
+		s.logf("long frame: %dms", step.DurationMS)
+		return s.send(protocol.BuildLongFrame(s.port, step.DurationMS))
+	case ActionReplay:
+		status, _ := parseReplayStatus(step.Status)
+		s.mu.Lock()
+		matchID := s.matchID
+		s.mu.Unlock()
+		s.logf("replay %d: %s", matchID, status)
+		return s.send(protocol.BuildReplayStatus(s.port, matchID, byte(status)))
+	case ActionCloseLobby:
+		if err := s.disconnect(""); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.phase = events.GamePhaseIdle
+		s.matchID = 0
+		s.mu.Unlock()
+		s.logf("lobby closed")
+		return s.send(protocol.BuildLobbyClosed(s.port))
+	case ActionLog:
+		s.logf("%s", step.Message)
+		return nil
+	case ActionCrash:
+		code := step.ExitCode
+		if code == 0 {
+			code = 1
+		}
+		fmt.Fprintf(os.Stderr, "simulated crash (exit code %d)\n", code)
+		os.Exit(code)
+	}
+	return nil
+}
+
+func (s *fakeServer) createLobby(mapName, mode string) error {
+	if mapName == "" {
+		mapName = "caldavar"
+	}
+	if mode == "" {
+		mode = "normal"
+	}
+
+	s.mu.Lock()
+	s.matchCount++
+	s.matchID = uint32(s.port)*10000 + s.matchCount
+	s.phase = events.GamePhaseInLobby
+	matchID := s.matchID
+	s.mu.Unlock()
+
+	s.logf("lobby created: match %d on %s (%s)", matchID, mapName, mode)
+	if err := s.send(protocol.BuildLobbyCreated(s.port, matchID, mapName, mode)); err != nil {
+		return err
+	}
+	return s.send(s.statusPacket())
+}
+
+// connect adds a player, generating a name if name is empty.
+func (s *fakeServer) connect(name string) error {
+	s.mu.Lock()
+	s.nextPlayer++
+	if name == "" {
+		name = fmt.Sprintf("SimPlayer%d", s.nextPlayer%1000)
+	}
+	player := fakePlayer{name: name, id: s.nextPlayer}
+	s.players = append(s.players, player)
+	s.mu.Unlock()
+
+	s.logf("player connected: %s (%d)", player.name, player.id)
+	return s.send(protocol.BuildPlayerConnection(s.port, player.name, player.id, true))
+}
+
+// disconnect removes the named player, or everyone if name is empty.
+func (s *fakeServer) disconnect(name string) error {
+	s.mu.Lock()
+	var gone, kept []fakePlayer
+	for _, p := range s.players {
+		if name == "" || p.name == name {
+			gone = append(gone, p)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	s.players = kept
+	s.mu.Unlock()
+
+	for _, p := range gone {
+		s.logf("player disconnected: %s (%d)", p.name, p.id)
+		if err := s.send(protocol.BuildPlayerConnection(s.port, p.name, p.id, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kick disconnects a player at the manager's request.
+func (s *fakeServer) kick(playerID uint32, reason string) {
+	s.mu.Lock()
+	name := ""
+	for _, p := range s.players {
+		if p.id == playerID {
+			name = p.name
+		}
+	}
+	s.mu.Unlock()
+
+	if name == "" {
+		s.logf("kick for unknown player %d ignored", playerID)
+		return
+	}
+	s.logf("kicking %s: %s", name, reason)
+	if err := s.disconnect(name); err != nil {
+		s.fail(err)
+	}
+}
+
+// readNullString reads a null-terminated string.
+func readNullString(r *bytes.Reader) string {
+	var buf []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil || c == 0 {
+			return string(buf)
+		}
+		buf = append(buf, c)
+	}
+}
@@ -0,0 +1,169 @@
+// Package simulate implements Energizer's simulation mode: a built-in fake
+// game server that speaks the manager protocol and plays a scripted
+// scenario, so the whole stack can be exercised without HoN game files.
+package simulate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/energizer-project/energizer/internal/events"
+)
+
+// Scenario actions.
+const (
+	ActionLobby      = "lobby"       // Create a lobby (0x44) with Map and Mode
+	ActionPhase      = "phase"       // Switch the reported game phase
+	ActionConnect    = "connect"     // Connect Player, or Count generated players (0x47)
+	ActionDisconnect = "disconnect"  // Disconnect Player, or everyone (0x47)
+	ActionLongFrame  = "long_frame"  // Report a long frame of DurationMS (0x43)
+	ActionReplay     = "replay"      // Report the replay Status of the current match (0x4A)
+	ActionCloseLobby = "close_lobby" // Disconnect everyone and close the lobby (0x45)
+	ActionLog        = "log"         // Print Message to the console
+	ActionCrash      = "crash"       // Exit with ExitCode without saying goodbye
+)
+
+// Scenario is a scripted game server session. Steps run in order, each after
+// waiting its Wait seconds; with Loop set the script starts over once done.
+type Scenario struct {
+	Name           string  `json:"name"`
+	StatusInterval int     `json:"status_interval"` // Seconds between status packets (0x42)
+	CPUPercent     float32 `json:"cpu_percent"`     // Baseline CPU usage reported in status packets
+	BasePing       uint16  `json:"base_ping"`       // Baseline player ping in milliseconds
+	Loop           bool    `json:"loop"`
+	Steps          []Step  `json:"steps"`
+}
+
+// Step is one scenario action. Only the fields used by Action are read.
+type Step struct {
+	Wait       float64 `json:"wait"` // Seconds to wait before the action
+	Action     string  `json:"action"`
+	Map        string  `json:"map,omitempty"`
+	Mode       string  `json:"mode,omitempty"`
+	Phase      string  `json:"phase,omitempty"`
+	Player     string  `json:"player,omitempty"`
+	Count      int     `json:"count,omitempty"`
+	DurationMS uint32  `json:"duration_ms,omitempty"`
+	Status     string  `json:"status,omitempty"`
+	Message    string  `json:"message,omitempty"`
+	ExitCode   int     `json:"exit_code,omitempty"`
+}
+
+// DefaultScenario returns the built-in scenario: an endless loop of 10-player
+// matches going through every phase, with a few lag spikes and a replay
+// upload at the end of each match.
+func DefaultScenario() *Scenario {
+	return &Scenario{
+		Name:           "default",
+		StatusInterval: 5,
+		CPUPercent:     15,
+		BasePing:       40,
+		Loop:           true,
+		Steps: []Step{
+			{Wait: 10, Action: ActionLobby, Map: "caldavar", Mode: "normal"},
+			{Wait: 5, Action: ActionConnect, Count: 10},
+			{Wait: 10, Action: ActionPhase, Phase: "banning"},
+			{Wait: 15, Action: ActionPhase, Phase: "picking"},
+			{Wait: 30, Action: ActionPhase, Phase: "loading"},
+			{Wait: 10, Action: ActionPhase, Phase: "preparation"},
+			{Wait: 30, Action: ActionPhase, Phase: "match_started"},
+			{Wait: 60, Action: ActionLongFrame, DurationMS: 250},
+			{Wait: 60, Action: ActionLongFrame, DurationMS: 1200},
+			{Wait: 120, Action: ActionPhase, Phase: "game_ending"},
+			{Wait: 10, Action: ActionPhase, Phase: "game_ended"},
+			{Wait: 2, Action: ActionReplay, Status: "uploading"},
+			{Wait: 8, Action: ActionReplay, Status: "uploaded"},
+			{Wait: 5, Action: ActionCloseLobby},
+		},
+	}
+}
+
+// LoadScenario reads and validates a scenario file. An empty path returns
+// the built-in scenario.
+func LoadScenario(path string) (*Scenario, error) {
+	if path == "" {
+		return DefaultScenario(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	sc := &Scenario{}
+	if err := json.Unmarshal(data, sc); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// Validate checks the scenario and fills in defaults.
+func (sc *Scenario) Validate() error {
+	if sc.StatusInterval <= 0 {
+		sc.StatusInterval = 5
+	}
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("scenario has no steps")
+	}
+
+	total := 0.0
+	for idx, step := range sc.Steps {
+		if step.Wait < 0 {
+			return fmt.Errorf("step %d: wait must not be negative", idx+1)
+		}
+		total += step.Wait
+
+		switch step.Action {
+		case ActionLobby, ActionDisconnect, ActionCloseLobby, ActionLog, ActionCrash:
+		case ActionPhase:
+			if _, ok := parsePhase(step.Phase); !ok {
+				return fmt.Errorf("step %d: unknown phase %q", idx+1, step.Phase)
+			}
+		case ActionConnect:
+			if step.Player == "" && step.Count < 1 {
+				return fmt.Errorf("step %d: connect needs a player or a count", idx+1)
+			}
+		case ActionLongFrame:
+			if step.DurationMS == 0 {
+				return fmt.Errorf("step %d: long_frame needs duration_ms", idx+1)
+			}
+		case ActionReplay:
+			if _, ok := parseReplayStatus(step.Status); !ok {
+				return fmt.Errorf("step %d: unknown replay status %q", idx+1, step.Status)
+			}
+		default:
+			return fmt.Errorf("step %d: unknown action %q", idx+1, step.Action)
+		}
+	}
+
+	// A looping scenario without waits would flood the manager
+	if sc.Loop && total == 0 {
+		return fmt.Errorf("looping scenario must wait between steps")
+	}
+	return nil
+}
+
+// parsePhase resolves a phase name such as "match_started".
+func parsePhase(name string) (events.GamePhase, bool) {
+	for p := events.GamePhaseIdle; p <= events.GamePhaseGameEnded; p++ {
+		if strings.EqualFold(p.String(), name) {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// parseReplayStatus resolves a replay status name such as "uploaded".
+func parseReplayStatus(name string) (events.ReplayStatus, bool) {
+	for s := events.ReplayStatusNone; s <= events.ReplayStatusReady; s++ {
+		if strings.EqualFold(s.String(), name) {
+			return s, true
+		}
+	}
+	return 0, false
+}